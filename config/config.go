@@ -2,11 +2,15 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"net"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
@@ -17,13 +21,139 @@ const (
 	defaultPageSize        = 20
 	defaultDisplayMode     = "compact"
 	defaultResultDensity   = "compact"
+
+	defaultSearchCacheTTLSeconds = 300
+	defaultSearchCacheMaxEntries = 1000
+	defaultSearchCacheMaxBytes   = 64 << 20
+
+	defaultInstanceDir            = "instance"
+	defaultAnalyticsRetentionDays = 90
+	defaultLogFile                = "log/app.log"
+	defaultLogMaxSizeMB           = 100
+	defaultLogMaxBackups          = 7
+	defaultLogMaxAgeDays          = 30
+
+	defaultReadHeaderTimeoutSeconds = 10
+	defaultReadTimeoutSeconds       = 60
+	defaultWriteTimeoutSeconds      = 1800
+	defaultIdleTimeoutSeconds       = 120
+	defaultShutdownTimeoutSeconds   = 30
+
+	defaultTokenTTLMinutes = 24 * 60
+
+	defaultLoginMaxAttempts          = 5
+	defaultLoginBaseDelayMs          = 1000
+	defaultLoginMaxDelayMs           = 60 * 1000
+	defaultLoginLockoutMinutes       = 15
+	defaultLoginGlobalMaxFailuresMin = 100
+
+	defaultSearchPerMinute        = 120
+	defaultSearchBurst            = 30
+	defaultMaxConcurrentDownloads = 3
+
+	// DefaultSlowQueryThreshold 是未配置 queryLog.slowQueryMs 时的慢查询阈值。
+	DefaultSlowQueryThreshold = time.Second
 )
 
-// DatasourceConfig 描述单个数据源的必要信息。
+// DatasourceConfig 描述单个数据源的必要信息与可选的运行参数。
 type DatasourceConfig struct {
-	Name string `mapstructure:"name"`
-	Type string `mapstructure:"type"`
-	Path string `mapstructure:"path"`
+	Name string `mapstructure:"name" json:"name"`
+	Type string `mapstructure:"type" json:"type"`
+	Path string `mapstructure:"path" json:"path"`
+
+	// Enabled 为空时视为启用，显式设置为 false 的数据源不会被 DBManager 注册。
+	Enabled *bool `mapstructure:"enabled" json:"enabled,omitempty"`
+	// Label 是展示给用户的名称，留空时使用 Name。
+	Label string `mapstructure:"label" json:"label,omitempty"`
+	// Priority 越大的数据源在结果归并时越靠前。
+	Priority int `mapstructure:"priority" json:"priority,omitempty"`
+	// QueryTimeoutMs 为单次搜索的超时时间（毫秒），0 表示仅受全局超时限制。
+	QueryTimeoutMs int `mapstructure:"queryTimeoutMs" json:"queryTimeoutMs,omitempty"`
+	// ReadOnly 为 true 时以只读模式打开数据库，不修改 PRAGMA 也不重建 FTS 索引。
+	ReadOnly bool `mapstructure:"readOnly" json:"readOnly,omitempty"`
+	// DefaultFuzzy 在请求未指定 fuzzy 时作为该数据源的默认模糊匹配模式。
+	DefaultFuzzy *bool `mapstructure:"defaultFuzzy" json:"defaultFuzzy,omitempty"`
+	// Tokenizer 指定新建 FTS5 索引使用的分词器，留空时为 unicode61。
+	Tokenizer string `mapstructure:"tokenizer" json:"tokenizer,omitempty"`
+	// Options 保存适配器专属配置，可用键见 datasourceOptionKeys。
+	Options map[string]string `mapstructure:"options" json:"options,omitempty"`
+	// Access 限制可检索与下载该数据源的角色和用户，为空时所有人可见（仍受 auth.accessPolicy 约束）。
+	Access *DatasourceAccess `mapstructure:"access" json:"access,omitempty"`
+}
+
+// DatasourceAccess 描述数据源的访问控制：Search 决定谁能检索与查看封面，Download 在此基础上
+// 进一步限制下载。规则为空表示不限制，admin 始终可以访问。
+type DatasourceAccess struct {
+	Search   AccessRule `mapstructure:"search" json:"search"`
+	Download AccessRule `mapstructure:"download" json:"download"`
+}
+
+// AccessRule 列出允许访问的角色与用户名，满足任一条件即可。角色按等级匹配，
+// 例如 reader 同时允许 librarian 与 admin；用户名不区分大小写。
+type AccessRule struct {
+	Roles []string `mapstructure:"roles" json:"roles,omitempty"`
+	Users []string `mapstructure:"users" json:"users,omitempty"`
+}
+
+// Restricted 返回规则是否限制了访问者。
+func (r AccessRule) Restricted() bool {
+	return len(r.Roles) > 0 || len(r.Users) > 0
+}
+
+// IsEnabled 返回数据源是否启用。
+func (d DatasourceConfig) IsEnabled() bool {
+	return d.Enabled == nil || *d.Enabled
+}
+
+// DisplayLabel 返回数据源的展示名称。
+func (d DatasourceConfig) DisplayLabel() string {
+	if d.Label != "" {
+		return d.Label
+	}
+	return d.Name
+}
+
+// QueryTimeout 返回单次搜索的超时时间，未配置时返回 0。
+func (d DatasourceConfig) QueryTimeout() time.Duration {
+	if d.QueryTimeoutMs <= 0 {
+		return 0
+	}
+	return time.Duration(d.QueryTimeoutMs) * time.Millisecond
+}
+
+// Option 返回适配器专属配置项，不存在时返回 fallback。
+func (d DatasourceConfig) Option(key, fallback string) string {
+	if value, ok := d.Options[key]; ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// datasourceOptionKeys 列出各数据源类型支持的 options 键。viper 会将键统一转为小写，
+// 因此以小写键索引并映射回规范写法。
+var datasourceOptionKeys = map[string]map[string]string{
+	"calibre": {
+		"coverfile": "coverFile",
+	},
+	"legacy_db": {
+		"authorseparators": "authorSeparators",
+	},
+}
+
+// accessRoles 列出 access 规则中可用的角色名称，与 internal/auth 中定义的角色保持一致。
+var accessRoles = map[string]struct{}{
+	"admin":     {},
+	"librarian": {},
+	"reader":    {},
+	"guest":     {},
+}
+
+// fts5Tokenizers 列出允许配置的 FTS5 分词器名称。
+var fts5Tokenizers = map[string]struct{}{
+	"unicode61": {},
+	"ascii":     {},
+	"porter":    {},
+	"trigram":   {},
 }
 
 // Config 描述 static/settings.json 中的关键配置项。
@@ -37,6 +167,231 @@ type Config struct {
 	AdminPassword      string             `mapstructure:"adminPassword"`
 	CORSAllowedOrigins []string           `mapstructure:"corsAllowedOrigins"`
 	Datasources        []DatasourceConfig `mapstructure:"datasources"`
+	// SettingsHistoryLimit 为后台保存设置时保留的历史版本数量，未配置时为 DefaultHistoryLimit。
+	SettingsHistoryLimit int `mapstructure:"settingsHistoryLimit"`
+	// SearchCache 控制搜索结果缓存的有效期与容量。
+	SearchCache SearchCacheConfig `mapstructure:"searchCache"`
+	// InstanceDir 保存服务运行时生成的数据（如搜索统计库），未配置时为 instance。
+	InstanceDir string `mapstructure:"instanceDir"`
+	// QueryLog 控制适配器的逐条 SQL 日志与慢查询阈值。
+	QueryLog QueryLogConfig `mapstructure:"queryLog"`
+	// Analytics 控制是否记录匿名的搜索统计。
+	Analytics AnalyticsConfig `mapstructure:"analytics"`
+	// Logging 控制日志级别、格式与输出位置，可在运行中通过后台调整。
+	Logging LoggingConfig `mapstructure:"logging"`
+	// HTTP 控制 HTTP 服务的超时与关闭时的排空时长，修改后需重启生效。
+	HTTP HTTPConfig `mapstructure:"http"`
+	// Auth 控制后台登录凭证。
+	Auth AuthConfig `mapstructure:"auth"`
+	// RateLimit 限制单个用户或 IP 的搜索频率、同时下载数与下载速度。
+	RateLimit RateLimitConfig `mapstructure:"rateLimit"`
+}
+
+// RateLimitConfig 描述按调用者（登录用户、API Key 或未登录时的 IP）计算的限流设置。
+// 搜索使用令牌桶：每分钟补充 SearchPerMinute 次，最多累积 SearchBurst 次。未配置或为 0 的项使用默认值，
+// DownloadBytesPerSecond 为 0 时不限制下载速度。
+type RateLimitConfig struct {
+	// Enabled 为空时视为启用，为 false 时关闭搜索限流与同时下载数限制，下载限速仍按 DownloadBytesPerSecond 生效。
+	Enabled                *bool `mapstructure:"enabled" json:"enabled,omitempty"`
+	SearchPerMinute        int   `mapstructure:"searchPerMinute" json:"searchPerMinute"`
+	SearchBurst            int   `mapstructure:"searchBurst" json:"searchBurst"`
+	MaxConcurrentDownloads int   `mapstructure:"maxConcurrentDownloads" json:"maxConcurrentDownloads"`
+	// DownloadBytesPerSecond 限制单个下载的传输速度，需确保 http.writeTimeoutSeconds 足以传完大文件。
+	DownloadBytesPerSecond int64 `mapstructure:"downloadBytesPerSecond" json:"downloadBytesPerSecond"`
+}
+
+// IsEnabled 返回是否启用搜索限流与同时下载数限制。
+func (c RateLimitConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// 访问策略决定搜索、封面与下载接口是否需要登录，后台接口始终需要登录。
+const (
+	// AccessPublic 表示所有前台接口均公开，是未配置时的默认值。
+	AccessPublic = "public"
+	// AccessLoginForDownloads 表示搜索与封面公开，下载需要登录。
+	AccessLoginForDownloads = "loginForDownloads"
+	// AccessLoginRequired 表示搜索、数据源列表、封面与下载均需要登录。
+	AccessLoginRequired = "loginRequired"
+)
+
+// AuthConfig 描述后台登录凭证与前台访问策略的设置。
+type AuthConfig struct {
+	// TokenTTLMinutes 为登录令牌的有效期（分钟），默认 1440（24 小时）。
+	TokenTTLMinutes int `mapstructure:"tokenTTLMinutes" json:"tokenTTLMinutes"`
+	// AccessPolicy 取 AccessPublic、AccessLoginForDownloads 或 AccessLoginRequired，默认 AccessPublic。
+	AccessPolicy string `mapstructure:"accessPolicy" json:"accessPolicy"`
+	// LoginThrottle 限制登录失败后的重试频率。
+	LoginThrottle LoginThrottleConfig `mapstructure:"loginThrottle" json:"loginThrottle"`
+}
+
+// LoginThrottleConfig 描述登录防暴力破解的设置：同一 IP 首次失败后可立即重试，之后每次失败需等待的时间
+// 从 BaseDelayMs 起逐次翻倍，最长 MaxDelayMs；连续失败 MaxAttempts 次后锁定 LockoutMinutes 分钟。
+// 所有 IP 每分钟合计失败达到 GlobalMaxFailuresPerMinute 次时，在该分钟内拒绝全部登录尝试。
+// 未配置或为 0 的项使用默认值。
+type LoginThrottleConfig struct {
+	// Enabled 为空时视为启用。
+	Enabled                    *bool `mapstructure:"enabled" json:"enabled,omitempty"`
+	MaxAttempts                int   `mapstructure:"maxAttempts" json:"maxAttempts"`
+	BaseDelayMs                int   `mapstructure:"baseDelayMs" json:"baseDelayMs"`
+	MaxDelayMs                 int   `mapstructure:"maxDelayMs" json:"maxDelayMs"`
+	LockoutMinutes             int   `mapstructure:"lockoutMinutes" json:"lockoutMinutes"`
+	GlobalMaxFailuresPerMinute int   `mapstructure:"globalMaxFailuresPerMinute" json:"globalMaxFailuresPerMinute"`
+}
+
+// IsEnabled 返回是否限制登录重试。
+func (c LoginThrottleConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// BaseDelay 返回首次失败后的等待时间。
+func (c LoginThrottleConfig) BaseDelay() time.Duration {
+	return time.Duration(c.BaseDelayMs) * time.Millisecond
+}
+
+// MaxDelay 返回单次等待时间的上限。
+func (c LoginThrottleConfig) MaxDelay() time.Duration {
+	return time.Duration(c.MaxDelayMs) * time.Millisecond
+}
+
+// Lockout 返回锁定时长，同时也是失败记录的保留时长。
+func (c LoginThrottleConfig) Lockout() time.Duration {
+	return time.Duration(c.LockoutMinutes) * time.Minute
+}
+
+// DownloadRequiresLogin 返回下载接口是否需要登录。
+func (c AuthConfig) DownloadRequiresLogin() bool {
+	return c.AccessPolicy == AccessLoginForDownloads || c.AccessPolicy == AccessLoginRequired
+}
+
+// BrowseRequiresLogin 返回搜索、数据源列表与封面接口是否需要登录。
+func (c AuthConfig) BrowseRequiresLogin() bool {
+	return c.AccessPolicy == AccessLoginRequired
+}
+
+// TokenTTL 返回登录令牌的有效期。
+func (c AuthConfig) TokenTTL() time.Duration {
+	return time.Duration(c.TokenTTLMinutes) * time.Minute
+}
+
+// HTTPConfig 描述 HTTP 服务的超时设置（单位均为秒）与可信反向代理。
+type HTTPConfig struct {
+	// ReadHeaderTimeoutSeconds 为读取请求头的超时，默认 10 秒。
+	ReadHeaderTimeoutSeconds int `mapstructure:"readHeaderTimeoutSeconds" json:"readHeaderTimeoutSeconds"`
+	// ReadTimeoutSeconds 为读取完整请求的超时，默认 60 秒。
+	ReadTimeoutSeconds int `mapstructure:"readTimeoutSeconds" json:"readTimeoutSeconds"`
+	// WriteTimeoutSeconds 为写出响应的超时，需覆盖大文件下载，默认 1800 秒。
+	WriteTimeoutSeconds int `mapstructure:"writeTimeoutSeconds" json:"writeTimeoutSeconds"`
+	// IdleTimeoutSeconds 为 keep-alive 连接的空闲超时，默认 120 秒。
+	IdleTimeoutSeconds int `mapstructure:"idleTimeoutSeconds" json:"idleTimeoutSeconds"`
+	// ShutdownTimeoutSeconds 为收到退出信号后等待进行中请求完成的最长时间，默认 30 秒。
+	ShutdownTimeoutSeconds int `mapstructure:"shutdownTimeoutSeconds" json:"shutdownTimeoutSeconds"`
+	// TrustedProxies 列出可信反向代理的 IP 或 CIDR，只有来自这些地址的请求才按 X-Forwarded-For 识别客户端 IP。
+	// 未配置时一律使用连接的对端地址。
+	TrustedProxies []string `mapstructure:"trustedProxies" json:"trustedProxies,omitempty"`
+}
+
+// ReadHeaderTimeout 返回读取请求头的超时。
+func (c HTTPConfig) ReadHeaderTimeout() time.Duration {
+	return time.Duration(c.ReadHeaderTimeoutSeconds) * time.Second
+}
+
+// ReadTimeout 返回读取完整请求的超时。
+func (c HTTPConfig) ReadTimeout() time.Duration {
+	return time.Duration(c.ReadTimeoutSeconds) * time.Second
+}
+
+// WriteTimeout 返回写出响应的超时。
+func (c HTTPConfig) WriteTimeout() time.Duration {
+	return time.Duration(c.WriteTimeoutSeconds) * time.Second
+}
+
+// IdleTimeout 返回空闲连接的超时。
+func (c HTTPConfig) IdleTimeout() time.Duration {
+	return time.Duration(c.IdleTimeoutSeconds) * time.Second
+}
+
+// ShutdownTimeout 返回关闭时的最长排空时间。
+func (c HTTPConfig) ShutdownTimeout() time.Duration {
+	return time.Duration(c.ShutdownTimeoutSeconds) * time.Second
+}
+
+// LoggingConfig 描述日志输出：写入按大小轮转的文件，并可同时输出到标准输出。
+type LoggingConfig struct {
+	// Level 为 debug、info、warn 或 error，默认 info。
+	Level string `mapstructure:"level" json:"level"`
+	// Format 为 json 或 text，默认 json。
+	Format string `mapstructure:"format" json:"format"`
+	// File 为日志文件路径，默认 log/app.log。
+	File string `mapstructure:"file" json:"file"`
+	// MaxSizeMB、MaxBackups 与 MaxAgeDays 控制日志轮转，未配置时分别为 100、7 与 30。
+	MaxSizeMB  int `mapstructure:"maxSizeMB" json:"maxSizeMB"`
+	MaxBackups int `mapstructure:"maxBackups" json:"maxBackups"`
+	MaxAgeDays int `mapstructure:"maxAgeDays" json:"maxAgeDays"`
+	// Compress 为空时压缩轮转出的旧日志。
+	Compress *bool `mapstructure:"compress" json:"compress,omitempty"`
+	// Stdout 为空时同时输出到标准输出。
+	Stdout *bool `mapstructure:"stdout" json:"stdout,omitempty"`
+	// AddSource 为 true 时在每条日志中记录源码位置。
+	AddSource bool `mapstructure:"addSource" json:"addSource"`
+}
+
+// CompressEnabled 返回是否压缩轮转出的旧日志。
+func (c LoggingConfig) CompressEnabled() bool {
+	return c.Compress == nil || *c.Compress
+}
+
+// StdoutEnabled 返回是否同时输出到标准输出。
+func (c LoggingConfig) StdoutEnabled() bool {
+	return c.Stdout == nil || *c.Stdout
+}
+
+// InstancePath 返回实例目录下指定文件的路径。
+func (c *Config) InstancePath(name string) string {
+	return filepath.Join(c.InstanceDir, name)
+}
+
+// QueryLogConfig 描述适配器查询日志：默认只记录超过阈值的慢查询。
+type QueryLogConfig struct {
+	// LogQueries 为 true 时每条查询都以 Info 级别记录 SQL，否则仅在 Debug 级别记录。
+	LogQueries bool `mapstructure:"logQueries" json:"logQueries"`
+	// SlowQueryMs 为慢查询阈值（毫秒），达到阈值的查询以 Warn 级别记录完整 SQL 与参数。
+	SlowQueryMs int `mapstructure:"slowQueryMs" json:"slowQueryMs"`
+}
+
+// SlowQueryThreshold 返回慢查询阈值。
+func (c QueryLogConfig) SlowQueryThreshold() time.Duration {
+	return time.Duration(c.SlowQueryMs) * time.Millisecond
+}
+
+// AnalyticsConfig 描述搜索统计的记录开关与保留天数。
+type AnalyticsConfig struct {
+	// Enabled 为空时视为启用。
+	Enabled *bool `mapstructure:"enabled" json:"enabled,omitempty"`
+	// RetentionDays 为统计记录的保留天数，未配置时为 90。
+	RetentionDays int `mapstructure:"retentionDays" json:"retentionDays"`
+}
+
+// IsEnabled 返回是否记录搜索统计。
+func (c AnalyticsConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// Retention 返回统计记录的保留时长。
+func (c AnalyticsConfig) Retention() time.Duration {
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
+// SearchCacheConfig 描述搜索结果缓存的有效期与容量上限，未配置或为 0 的项使用默认值。
+type SearchCacheConfig struct {
+	TTLSeconds int   `mapstructure:"ttlSeconds" json:"ttlSeconds"`
+	MaxEntries int   `mapstructure:"maxEntries" json:"maxEntries"`
+	MaxBytes   int64 `mapstructure:"maxBytes" json:"maxBytes"`
+}
+
+// TTL 返回缓存条目的有效期。
+func (c SearchCacheConfig) TTL() time.Duration {
+	return time.Duration(c.TTLSeconds) * time.Second
 }
 
 // LoadConfig 读取配置文件并解析为 Config 结构体。configPath 参数允许调用方指定自定义配置路径。
@@ -59,11 +414,102 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("读取配置文件失败: %w", err)
 	}
 
+	return decodeConfig(v)
+}
+
+// ParseConfig 按与 LoadConfig 相同的规则解析 JSON 格式的配置内容，常用于写入文件前的校验。
+func ParseConfig(data []byte) (*Config, error) {
+	return parseConfig(data, false)
+}
+
+// ValidateConfig 与 ParseConfig 相同，但会拒绝 Config 中不存在的配置项以及类型不匹配的值，
+// 用于校验后台提交的完整设置。
+func ValidateConfig(data []byte) (*Config, error) {
+	return parseConfig(data, true)
+}
+
+func parseConfig(data []byte, strict bool) (*Config, error) {
+	v := viper.New()
+	v.SetConfigType(defaultConfigType)
+	v.SetEnvPrefix("ebookdatabase")
+	v.AutomaticEnv()
+
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("读取配置内容失败: %w", err)
+	}
+
+	if strict {
+		var probe Config
+		if err := v.Unmarshal(&probe, func(dc *mapstructure.DecoderConfig) {
+			dc.ErrorUnused = true
+		}); err != nil {
+			return nil, fmt.Errorf("配置不符合格式要求: %w", err)
+		}
+	}
+
+	return decodeConfig(v)
+}
+
+func decodeConfig(v *viper.Viper) (*Config, error) {
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("解析配置失败: %w", err)
 	}
 
+	if cfg.SettingsHistoryLimit < 0 {
+		return nil, fmt.Errorf("配置项 settingsHistoryLimit 不能为负数")
+	}
+	if cfg.SettingsHistoryLimit == 0 {
+		cfg.SettingsHistoryLimit = DefaultHistoryLimit
+	}
+
+	searchCache, err := normalizeSearchCache(cfg.SearchCache)
+	if err != nil {
+		return nil, err
+	}
+	cfg.SearchCache = searchCache
+
+	if cfg.QueryLog.SlowQueryMs < 0 {
+		return nil, fmt.Errorf("配置项 queryLog.slowQueryMs 不能为负数")
+	}
+	if cfg.QueryLog.SlowQueryMs == 0 {
+		cfg.QueryLog.SlowQueryMs = int(DefaultSlowQueryThreshold / time.Millisecond)
+	}
+	if cfg.Analytics.RetentionDays < 0 {
+		return nil, fmt.Errorf("配置项 analytics.retentionDays 不能为负数")
+	}
+	if cfg.Analytics.RetentionDays == 0 {
+		cfg.Analytics.RetentionDays = defaultAnalyticsRetentionDays
+	}
+	logging, err := NormalizeLogging(cfg.Logging)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Logging = logging
+
+	httpConfig, err := normalizeHTTP(cfg.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	cfg.HTTP = httpConfig
+
+	rateLimit, err := normalizeRateLimit(cfg.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+	cfg.RateLimit = rateLimit
+
+	authConfig, err := normalizeAuth(cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Auth = authConfig
+
+	cfg.InstanceDir = strings.TrimSpace(cfg.InstanceDir)
+	if cfg.InstanceDir == "" {
+		cfg.InstanceDir = defaultInstanceDir
+	}
+
 	if cfg.PageSize <= 0 {
 		raw := v.Get("pageSize")
 		parsed, err := parsePageSize(raw)
@@ -110,6 +556,171 @@ func LoadConfig(configPath string) (*Config, error) {
 	return &cfg, nil
 }
 
+func normalizeSearchCache(c SearchCacheConfig) (SearchCacheConfig, error) {
+	if c.TTLSeconds < 0 || c.MaxEntries < 0 || c.MaxBytes < 0 {
+		return c, fmt.Errorf("配置项 searchCache 中的 ttlSeconds、maxEntries 与 maxBytes 不能为负数")
+	}
+	if c.TTLSeconds == 0 {
+		c.TTLSeconds = defaultSearchCacheTTLSeconds
+	}
+	if c.MaxEntries == 0 {
+		c.MaxEntries = defaultSearchCacheMaxEntries
+	}
+	if c.MaxBytes == 0 {
+		c.MaxBytes = defaultSearchCacheMaxBytes
+	}
+	return c, nil
+}
+
+func normalizeHTTP(c HTTPConfig) (HTTPConfig, error) {
+	fields := []struct {
+		value    *int
+		fallback int
+	}{
+		{&c.ReadHeaderTimeoutSeconds, defaultReadHeaderTimeoutSeconds},
+		{&c.ReadTimeoutSeconds, defaultReadTimeoutSeconds},
+		{&c.WriteTimeoutSeconds, defaultWriteTimeoutSeconds},
+		{&c.IdleTimeoutSeconds, defaultIdleTimeoutSeconds},
+		{&c.ShutdownTimeoutSeconds, defaultShutdownTimeoutSeconds},
+	}
+	for _, field := range fields {
+		if *field.value < 0 {
+			return c, fmt.Errorf("配置项 http 中的超时时间不能为负数")
+		}
+		if *field.value == 0 {
+			*field.value = field.fallback
+		}
+	}
+
+	proxies := make([]string, 0, len(c.TrustedProxies))
+	for _, item := range c.TrustedProxies {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if net.ParseIP(item) == nil {
+			if _, _, err := net.ParseCIDR(item); err != nil {
+				return c, fmt.Errorf("配置项 http.trustedProxies 中的地址无效: %s", item)
+			}
+		}
+		proxies = append(proxies, item)
+	}
+	c.TrustedProxies = nil
+	if len(proxies) > 0 {
+		c.TrustedProxies = proxies
+	}
+	return c, nil
+}
+
+// NormalizeLogging 校验日志配置并填充默认值，供配置解析与后台单独修改日志配置时共用。
+func NormalizeLogging(c LoggingConfig) (LoggingConfig, error) {
+	c.Level = strings.ToLower(strings.TrimSpace(c.Level))
+	switch c.Level {
+	case "":
+		c.Level = "info"
+	case "debug", "info", "warn", "error":
+	default:
+		return c, fmt.Errorf("配置项 logging.level 不受支持: %s（可选 debug、info、warn、error）", c.Level)
+	}
+
+	c.Format = strings.ToLower(strings.TrimSpace(c.Format))
+	switch c.Format {
+	case "":
+		c.Format = "json"
+	case "json", "text":
+	default:
+		return c, fmt.Errorf("配置项 logging.format 不受支持: %s（可选 json、text）", c.Format)
+	}
+
+	c.File = strings.TrimSpace(c.File)
+	if c.File == "" {
+		c.File = filepath.FromSlash(defaultLogFile)
+	}
+
+	if c.MaxSizeMB < 0 || c.MaxBackups < 0 || c.MaxAgeDays < 0 {
+		return c, fmt.Errorf("配置项 logging 中的 maxSizeMB、maxBackups 与 maxAgeDays 不能为负数")
+	}
+	if c.MaxSizeMB == 0 {
+		c.MaxSizeMB = defaultLogMaxSizeMB
+	}
+	if c.MaxBackups == 0 {
+		c.MaxBackups = defaultLogMaxBackups
+	}
+	if c.MaxAgeDays == 0 {
+		c.MaxAgeDays = defaultLogMaxAgeDays
+	}
+	return c, nil
+}
+
+func normalizeAuth(cfg AuthConfig) (AuthConfig, error) {
+	if cfg.TokenTTLMinutes < 0 {
+		return cfg, fmt.Errorf("配置项 auth.tokenTTLMinutes 不能为负数")
+	}
+	if cfg.TokenTTLMinutes == 0 {
+		cfg.TokenTTLMinutes = defaultTokenTTLMinutes
+	}
+
+	throttle, err := normalizeLoginThrottle(cfg.LoginThrottle)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.LoginThrottle = throttle
+
+	policy := strings.TrimSpace(cfg.AccessPolicy)
+	switch strings.ToLower(policy) {
+	case "", strings.ToLower(AccessPublic):
+		cfg.AccessPolicy = AccessPublic
+	case strings.ToLower(AccessLoginForDownloads):
+		cfg.AccessPolicy = AccessLoginForDownloads
+	case strings.ToLower(AccessLoginRequired):
+		cfg.AccessPolicy = AccessLoginRequired
+	default:
+		return cfg, fmt.Errorf("配置项 auth.accessPolicy 不支持 %q，可选值为 %s、%s、%s", policy, AccessPublic, AccessLoginForDownloads, AccessLoginRequired)
+	}
+	return cfg, nil
+}
+
+func normalizeRateLimit(c RateLimitConfig) (RateLimitConfig, error) {
+	if c.SearchPerMinute < 0 || c.SearchBurst < 0 || c.MaxConcurrentDownloads < 0 || c.DownloadBytesPerSecond < 0 {
+		return c, fmt.Errorf("配置项 rateLimit 中的数值不能为负数")
+	}
+	if c.SearchPerMinute == 0 {
+		c.SearchPerMinute = defaultSearchPerMinute
+	}
+	if c.SearchBurst == 0 {
+		c.SearchBurst = defaultSearchBurst
+	}
+	if c.MaxConcurrentDownloads == 0 {
+		c.MaxConcurrentDownloads = defaultMaxConcurrentDownloads
+	}
+	return c, nil
+}
+
+func normalizeLoginThrottle(c LoginThrottleConfig) (LoginThrottleConfig, error) {
+	fields := []struct {
+		value    *int
+		fallback int
+	}{
+		{&c.MaxAttempts, defaultLoginMaxAttempts},
+		{&c.BaseDelayMs, defaultLoginBaseDelayMs},
+		{&c.MaxDelayMs, defaultLoginMaxDelayMs},
+		{&c.LockoutMinutes, defaultLoginLockoutMinutes},
+		{&c.GlobalMaxFailuresPerMinute, defaultLoginGlobalMaxFailuresMin},
+	}
+	for _, field := range fields {
+		if *field.value < 0 {
+			return c, fmt.Errorf("配置项 auth.loginThrottle 中的数值不能为负数")
+		}
+		if *field.value == 0 {
+			*field.value = field.fallback
+		}
+	}
+	if c.MaxDelayMs < c.BaseDelayMs {
+		return c, fmt.Errorf("配置项 auth.loginThrottle.maxDelayMs 不能小于 baseDelayMs")
+	}
+	return c, nil
+}
+
 func normalizeDisplayMode(value string) string {
 	switch strings.ToLower(strings.TrimSpace(value)) {
 	case "compact", "detail", "table", "card":
@@ -202,16 +813,136 @@ func normalizeDatasources(items []DatasourceConfig) ([]DatasourceConfig, error)
 			return nil, fmt.Errorf("数据源 %s 的路径不能为空", name)
 		}
 
+		if item.QueryTimeoutMs < 0 {
+			return nil, fmt.Errorf("数据源 %s 的 queryTimeoutMs 不能为负数", name)
+		}
+
+		tokenizer, err := normalizeTokenizer(item.Tokenizer)
+		if err != nil {
+			return nil, fmt.Errorf("数据源 %s 的 tokenizer 无效: %w", name, err)
+		}
+
+		options, err := normalizeDatasourceOptions(dsType, item.Options)
+		if err != nil {
+			return nil, fmt.Errorf("数据源 %s 的 options 无效: %w", name, err)
+		}
+
+		access, err := normalizeDatasourceAccess(item.Access)
+		if err != nil {
+			return nil, fmt.Errorf("数据源 %s 的 access 无效: %w", name, err)
+		}
+
 		normalized = append(normalized, DatasourceConfig{
-			Name: name,
-			Type: dsType,
-			Path: path,
+			Name:           name,
+			Type:           dsType,
+			Path:           path,
+			Enabled:        item.Enabled,
+			Label:          strings.TrimSpace(item.Label),
+			Priority:       item.Priority,
+			QueryTimeoutMs: item.QueryTimeoutMs,
+			ReadOnly:       item.ReadOnly,
+			DefaultFuzzy:   item.DefaultFuzzy,
+			Tokenizer:      tokenizer,
+			Options:        options,
+			Access:         access,
 		})
 	}
 
 	return normalized, nil
 }
 
+// normalizeDatasourceAccess 校验角色名称并去除空白与重复项，两条规则都为空时返回 nil。
+func normalizeDatasourceAccess(access *DatasourceAccess) (*DatasourceAccess, error) {
+	if access == nil {
+		return nil, nil
+	}
+	search, err := normalizeAccessRule(access.Search)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	download, err := normalizeAccessRule(access.Download)
+	if err != nil {
+		return nil, fmt.Errorf("download: %w", err)
+	}
+	if !search.Restricted() && !download.Restricted() {
+		return nil, nil
+	}
+	return &DatasourceAccess{Search: search, Download: download}, nil
+}
+
+func normalizeAccessRule(rule AccessRule) (AccessRule, error) {
+	var normalized AccessRule
+	seen := make(map[string]struct{})
+	for _, role := range rule.Roles {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if role == "" {
+			continue
+		}
+		if _, ok := accessRoles[role]; !ok {
+			return AccessRule{}, fmt.Errorf("未知的角色 %s", role)
+		}
+		if _, ok := seen["role:"+role]; !ok {
+			seen["role:"+role] = struct{}{}
+			normalized.Roles = append(normalized.Roles, role)
+		}
+	}
+	for _, user := range rule.Users {
+		user = strings.TrimSpace(user)
+		if user == "" {
+			continue
+		}
+		key := "user:" + strings.ToLower(user)
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			normalized.Users = append(normalized.Users, user)
+		}
+	}
+	return normalized, nil
+}
+
+// normalizeTokenizer 校验分词器配置。分词器会被拼接进 CREATE VIRTUAL TABLE 语句，
+// 因此只接受已知的分词器名称及由字母、数字、下划线组成的参数。
+func normalizeTokenizer(value string) (string, error) {
+	fields := strings.Fields(strings.ToLower(value))
+	if len(fields) == 0 {
+		return "", nil
+	}
+	if _, ok := fts5Tokenizers[fields[0]]; !ok {
+		return "", fmt.Errorf("不支持的分词器 %s", fields[0])
+	}
+	for _, field := range fields[1:] {
+		for _, r := range field {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_') {
+				return "", fmt.Errorf("分词器参数包含非法字符: %s", field)
+			}
+		}
+	}
+	return strings.Join(fields, " "), nil
+}
+
+func normalizeDatasourceOptions(dsType string, options map[string]string) (map[string]string, error) {
+	if len(options) == 0 {
+		return nil, nil
+	}
+	allowed, known := datasourceOptionKeys[strings.ToLower(dsType)]
+	normalized := make(map[string]string, len(options))
+	for key, value := range options {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("配置键不能为空")
+		}
+		if known {
+			canonical, ok := allowed[strings.ToLower(key)]
+			if !ok {
+				return nil, fmt.Errorf("类型 %s 不支持配置项 %s", dsType, key)
+			}
+			key = canonical
+		}
+		normalized[key] = strings.TrimSpace(value)
+	}
+	return normalized, nil
+}
+
 func normalizeOrigins(items []string) []string {
 	normalized := make([]string, 0, len(items))
 	seen := make(map[string]struct{})
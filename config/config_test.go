@@ -0,0 +1,263 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigParsesDatasourceOptions(t *testing.T) {
+	path := writeConfig(t, `{
+  "pageSize": 10,
+  "datasources": [
+    {
+      "name": "calibre",
+      "type": "calibre",
+      "path": "/books",
+      "enabled": false,
+      "label": " 我的书库 ",
+      "priority": 5,
+      "queryTimeoutMs": 1500,
+      "readOnly": true,
+      "defaultFuzzy": true,
+      "tokenizer": "Unicode61 remove_diacritics 2",
+      "options": {"coverFile": "folder.jpg"}
+    },
+    {"name": "legacy", "type": "legacy_db", "path": "/legacy.db"}
+  ]
+}`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if len(cfg.Datasources) != 2 {
+		t.Fatalf("expected 2 datasources, got %d", len(cfg.Datasources))
+	}
+
+	calibre := cfg.Datasources[0]
+	if calibre.IsEnabled() || calibre.DisplayLabel() != "我的书库" || calibre.Priority != 5 || !calibre.ReadOnly {
+		t.Fatalf("unexpected calibre options: %+v", calibre)
+	}
+	if calibre.QueryTimeout().Milliseconds() != 1500 {
+		t.Fatalf("unexpected query timeout: %v", calibre.QueryTimeout())
+	}
+	if calibre.DefaultFuzzy == nil || !*calibre.DefaultFuzzy {
+		t.Fatalf("expected defaultFuzzy=true, got %v", calibre.DefaultFuzzy)
+	}
+	if calibre.Tokenizer != "unicode61 remove_diacritics 2" {
+		t.Fatalf("unexpected tokenizer: %q", calibre.Tokenizer)
+	}
+	if calibre.Option("coverFile", "cover.jpg") != "folder.jpg" {
+		t.Fatalf("unexpected options: %+v", calibre.Options)
+	}
+
+	legacy := cfg.Datasources[1]
+	if !legacy.IsEnabled() || legacy.DisplayLabel() != "legacy" || legacy.QueryTimeout() != 0 || legacy.DefaultFuzzy != nil {
+		t.Fatalf("unexpected legacy defaults: %+v", legacy)
+	}
+}
+
+func TestLoadConfigRejectsInvalidDatasourceOptions(t *testing.T) {
+	cases := map[string]string{
+		"tokenizer":      `{"name": "a", "type": "legacy_db", "path": "/a.db", "tokenizer": "icu"}`,
+		"tokenizer args": `{"name": "a", "type": "legacy_db", "path": "/a.db", "tokenizer": "unicode61 ');DROP"}`,
+		"timeout":        `{"name": "a", "type": "legacy_db", "path": "/a.db", "queryTimeoutMs": -1}`,
+		"option key":     `{"name": "a", "type": "legacy_db", "path": "/a.db", "options": {"coverFile": "x.jpg"}}`,
+	}
+	for name, datasource := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := writeConfig(t, `{"datasources": [`+datasource+`]}`)
+			if _, err := LoadConfig(path); err == nil {
+				t.Fatalf("expected error for %s", name)
+			} else if !strings.Contains(err.Error(), "数据源 a") {
+				t.Fatalf("expected datasource name in error, got %v", err)
+			}
+		})
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestSearchCacheDefaultsAndValidation(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`{"searchCache": {"maxEntries": 50}}`))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+	if cfg.SearchCache.MaxEntries != 50 || cfg.SearchCache.TTL() != 5*time.Minute || cfg.SearchCache.MaxBytes != defaultSearchCacheMaxBytes {
+		t.Fatalf("unexpected search cache config: %+v", cfg.SearchCache)
+	}
+
+	if _, err := ParseConfig([]byte(`{"searchCache": {"ttlSeconds": -1}}`)); err == nil {
+		t.Fatalf("expected negative ttlSeconds to be rejected")
+	}
+}
+
+func TestQueryLogAndAnalyticsDefaults(t *testing.T) {
+	cfg, err := ValidateConfig([]byte(`{"queryLog": {"logQueries": true}, "analytics": {"enabled": false}}`))
+	if err != nil {
+		t.Fatalf("ValidateConfig returned error: %v", err)
+	}
+	if !cfg.QueryLog.LogQueries || cfg.QueryLog.SlowQueryThreshold() != DefaultSlowQueryThreshold {
+		t.Fatalf("unexpected query log config: %+v", cfg.QueryLog)
+	}
+	if cfg.Analytics.IsEnabled() || cfg.Analytics.RetentionDays != defaultAnalyticsRetentionDays {
+		t.Fatalf("unexpected analytics config: %+v", cfg.Analytics)
+	}
+	if cfg.InstancePath("analytics.db") != filepath.Join("instance", "analytics.db") {
+		t.Fatalf("unexpected instance path: %s", cfg.InstancePath("analytics.db"))
+	}
+
+	if _, err := ParseConfig([]byte(`{"queryLog": {"slowQueryMs": -5}}`)); err == nil {
+		t.Fatalf("expected negative slowQueryMs to be rejected")
+	}
+}
+
+func TestLoggingDefaultsAndValidation(t *testing.T) {
+	cfg, err := ValidateConfig([]byte(`{"logging": {"level": "WARN", "stdout": false}}`))
+	if err != nil {
+		t.Fatalf("ValidateConfig returned error: %v", err)
+	}
+	logging := cfg.Logging
+	if logging.Level != "warn" || logging.Format != "json" || logging.File != filepath.FromSlash(defaultLogFile) {
+		t.Fatalf("unexpected logging config: %+v", logging)
+	}
+	if logging.StdoutEnabled() || !logging.CompressEnabled() || logging.MaxSizeMB != defaultLogMaxSizeMB {
+		t.Fatalf("unexpected logging defaults: %+v", logging)
+	}
+
+	for _, raw := range []string{
+		`{"logging": {"format": "xml"}}`,
+		`{"logging": {"level": "trace"}}`,
+		`{"logging": {"maxBackups": -1}}`,
+	} {
+		if _, err := ParseConfig([]byte(raw)); err == nil {
+			t.Fatalf("expected %s to be rejected", raw)
+		}
+	}
+}
+
+func TestHTTPTimeoutDefaults(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`{"http": {"writeTimeoutSeconds": 60}}`))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+	if cfg.HTTP.WriteTimeout() != time.Minute || cfg.HTTP.ShutdownTimeout() != 30*time.Second || cfg.HTTP.ReadHeaderTimeout() != 10*time.Second {
+		t.Fatalf("unexpected http config: %+v", cfg.HTTP)
+	}
+	if _, err := ParseConfig([]byte(`{"http": {"idleTimeoutSeconds": -1}}`)); err == nil {
+		t.Fatalf("expected negative timeout to be rejected")
+	}
+
+	if cfg.HTTP.TrustedProxies != nil {
+		t.Fatalf("expected no trusted proxies by default, got %v", cfg.HTTP.TrustedProxies)
+	}
+	proxied, err := ParseConfig([]byte(`{"http": {"trustedProxies": [" 127.0.0.1 ", "", "10.0.0.0/8"]}}`))
+	if err != nil || len(proxied.HTTP.TrustedProxies) != 2 || proxied.HTTP.TrustedProxies[0] != "127.0.0.1" {
+		t.Fatalf("unexpected trusted proxies: %+v, %v", proxied.HTTP.TrustedProxies, err)
+	}
+	if _, err := ParseConfig([]byte(`{"http": {"trustedProxies": ["proxy.local"]}}`)); err == nil {
+		t.Fatalf("expected invalid trusted proxy to be rejected")
+	}
+}
+
+func TestAuthTokenTTLDefault(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`{}`))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+	if cfg.Auth.TokenTTL() != 24*time.Hour {
+		t.Fatalf("unexpected token ttl: %v", cfg.Auth.TokenTTL())
+	}
+	if _, err := ParseConfig([]byte(`{"auth": {"tokenTTLMinutes": -1}}`)); err == nil {
+		t.Fatalf("expected negative tokenTTLMinutes to be rejected")
+	}
+}
+
+func TestAuthAccessPolicy(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`{}`))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+	if cfg.Auth.AccessPolicy != AccessPublic || cfg.Auth.DownloadRequiresLogin() || cfg.Auth.BrowseRequiresLogin() {
+		t.Fatalf("expected public policy by default, got %+v", cfg.Auth)
+	}
+
+	cfg, err = ParseConfig([]byte(`{"auth": {"accessPolicy": " LoginForDownloads "}}`))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+	if cfg.Auth.AccessPolicy != AccessLoginForDownloads || !cfg.Auth.DownloadRequiresLogin() || cfg.Auth.BrowseRequiresLogin() {
+		t.Fatalf("unexpected policy: %+v", cfg.Auth)
+	}
+
+	if _, err := ParseConfig([]byte(`{"auth": {"accessPolicy": "private"}}`)); err == nil {
+		t.Fatalf("expected unknown accessPolicy to be rejected")
+	}
+}
+
+func TestDatasourceAccessNormalization(t *testing.T) {
+	cfg, err := ValidateConfig([]byte(`{"datasources": [
+    {"name": "private", "type": "legacy_db", "path": "/p.db", "access": {"search": {"roles": [" Reader ", "reader"], "users": ["alice", "ALICE", ""]}}},
+    {"name": "open", "type": "legacy_db", "path": "/o.db", "access": {"search": {}, "download": {}}}
+  ]}`))
+	if err != nil {
+		t.Fatalf("ValidateConfig returned error: %v", err)
+	}
+	access := cfg.Datasources[0].Access
+	if access == nil || len(access.Search.Roles) != 1 || access.Search.Roles[0] != "reader" || len(access.Search.Users) != 1 || access.Download.Restricted() {
+		t.Fatalf("unexpected access rules: %+v", access)
+	}
+	if cfg.Datasources[1].Access != nil {
+		t.Fatalf("expected empty access rules to be dropped, got %+v", cfg.Datasources[1].Access)
+	}
+
+	if _, err := ParseConfig([]byte(`{"datasources": [{"name": "a", "type": "legacy_db", "path": "/a.db", "access": {"download": {"roles": ["owner"]}}}]}`)); err == nil || !strings.Contains(err.Error(), "数据源 a") {
+		t.Fatalf("expected unknown role to be rejected, got %v", err)
+	}
+}
+
+func TestLoginThrottleDefaultsAndValidation(t *testing.T) {
+	cfg, err := ValidateConfig([]byte(`{"auth": {"loginThrottle": {"maxAttempts": 3}}}`))
+	if err != nil {
+		t.Fatalf("ValidateConfig returned error: %v", err)
+	}
+	throttle := cfg.Auth.LoginThrottle
+	if !throttle.IsEnabled() || throttle.MaxAttempts != 3 || throttle.BaseDelay() != time.Second || throttle.Lockout() != 15*time.Minute {
+		t.Fatalf("unexpected login throttle config: %+v", throttle)
+	}
+
+	for _, raw := range []string{
+		`{"auth": {"loginThrottle": {"maxAttempts": -1}}}`,
+		`{"auth": {"loginThrottle": {"baseDelayMs": 5000, "maxDelayMs": 1000}}}`,
+	} {
+		if _, err := ParseConfig([]byte(raw)); err == nil {
+			t.Fatalf("expected %s to be rejected", raw)
+		}
+	}
+}
+
+func TestRateLimitDefaultsAndValidation(t *testing.T) {
+	cfg, err := ValidateConfig([]byte(`{"rateLimit": {"downloadBytesPerSecond": 1048576}}`))
+	if err != nil {
+		t.Fatalf("ValidateConfig returned error: %v", err)
+	}
+	limits := cfg.RateLimit
+	if !limits.IsEnabled() || limits.SearchPerMinute != defaultSearchPerMinute || limits.SearchBurst != defaultSearchBurst ||
+		limits.MaxConcurrentDownloads != defaultMaxConcurrentDownloads || limits.DownloadBytesPerSecond != 1<<20 {
+		t.Fatalf("unexpected rate limit config: %+v", limits)
+	}
+	if _, err := ParseConfig([]byte(`{"rateLimit": {"searchBurst": -1}}`)); err == nil {
+		t.Fatalf("expected negative searchBurst to be rejected")
+	}
+}
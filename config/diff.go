@@ -0,0 +1,87 @@
+// path: config/diff.go
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// secretFields 中的配置项在差异中只提示“已修改”，不输出具体内容。
+var secretFields = map[string]struct{}{
+	"adminPassword": {},
+}
+
+// Diff 比较两份配置并返回可读的差异描述，数据源按名称逐个比较，敏感字段不输出具体值。
+func Diff(old, updated *Config) []string {
+	if old == nil || updated == nil {
+		if old == updated {
+			return nil
+		}
+		return []string{"配置整体替换"}
+	}
+
+	var changes []string
+	oldValue := reflect.ValueOf(*old)
+	newValue := reflect.ValueOf(*updated)
+	fields := oldValue.Type()
+
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		name := fieldKey(field)
+		if name == "datasources" {
+			changes = append(changes, diffDatasources(old.Datasources, updated.Datasources)...)
+			continue
+		}
+
+		before := oldValue.Field(i).Interface()
+		after := newValue.Field(i).Interface()
+		if reflect.DeepEqual(before, after) {
+			continue
+		}
+		if _, secret := secretFields[name]; secret {
+			changes = append(changes, name+": 已修改")
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%s: %v -> %v", name, before, after))
+	}
+	return changes
+}
+
+func diffDatasources(old, updated []DatasourceConfig) []string {
+	before := make(map[string]DatasourceConfig, len(old))
+	for _, item := range old {
+		before[item.Name] = item
+	}
+	after := make(map[string]DatasourceConfig, len(updated))
+	for _, item := range updated {
+		after[item.Name] = item
+	}
+
+	var changes []string
+	for name, item := range after {
+		previous, ok := before[name]
+		switch {
+		case !ok:
+			changes = append(changes, "datasources: 新增 "+name)
+		case !reflect.DeepEqual(previous, item):
+			changes = append(changes, "datasources: 修改 "+name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, "datasources: 删除 "+name)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+func fieldKey(field reflect.StructField) string {
+	tag := field.Tag.Get("mapstructure")
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return field.Name
+}
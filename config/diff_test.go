@@ -0,0 +1,39 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffReportsFieldAndDatasourceChanges(t *testing.T) {
+	old := &Config{
+		PageSize:      20,
+		AdminPassword: "old-secret",
+		Datasources: []DatasourceConfig{
+			{Name: "alpha", Type: "legacy_db", Path: "a.db"},
+			{Name: "beta", Type: "legacy_db", Path: "b.db"},
+		},
+	}
+	updated := &Config{
+		PageSize:      30,
+		AdminPassword: "new-secret",
+		Datasources: []DatasourceConfig{
+			{Name: "alpha", Type: "legacy_db", Path: "a2.db"},
+			{Name: "gamma", Type: "calibre", Path: "lib"},
+		},
+	}
+
+	changes := Diff(old, updated)
+	joined := strings.Join(changes, "\n")
+	for _, want := range []string{"pageSize", "adminPassword", "alpha", "beta", "gamma"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("expected diff to mention %s, got %v", want, changes)
+		}
+	}
+	if strings.Contains(joined, "secret") {
+		t.Fatalf("expected secrets to be redacted, got %v", changes)
+	}
+	if len(Diff(old, old)) != 0 {
+		t.Fatalf("expected no changes for identical configs")
+	}
+}
@@ -0,0 +1,192 @@
+// path: config/history.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultHistoryLimit 是未配置 settingsHistoryLimit 时保留的历史版本数量。
+	DefaultHistoryLimit = 10
+
+	historyDirSuffix  = ".history"
+	historyTimeLayout = "20060102T150405.000000000"
+
+	// 历史版本可能包含旧的明文 adminPassword 等敏感字段，只允许属主读写。
+	historyDirMode  = 0o700
+	historyFileMode = 0o600
+)
+
+// Version 描述设置文件的一个历史版本。
+type Version struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Size      int64     `json:"size"`
+}
+
+// ErrVersionNotFound 表示请求的历史版本不存在。
+var ErrVersionNotFound = errors.New("历史版本不存在")
+
+// WriteFileAtomic 先写入同目录下的临时文件并同步到磁盘，再通过 rename 替换目标文件，
+// 保证读取方只会看到完整的旧文件或新文件。
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer func() {
+		if tmpName != "" {
+			_ = os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("同步临时文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("关闭临时文件失败: %w", err)
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return fmt.Errorf("设置文件权限失败: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("替换目标文件失败: %w", err)
+	}
+	tmpName = ""
+	return nil
+}
+
+// SaveVersioned 在覆盖 path 之前把当前内容归档为带时间戳的历史版本，然后原子写入新内容，
+// 最后仅保留最近 keep 个历史版本。keep 小于等于 0 时使用 DefaultHistoryLimit。
+func SaveVersioned(path string, data []byte, keep int) error {
+	if keep <= 0 {
+		keep = DefaultHistoryLimit
+	}
+
+	current, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := archiveVersion(path, current); err != nil {
+			return err
+		}
+	case errors.Is(err, os.ErrNotExist):
+	default:
+		return fmt.Errorf("读取当前配置失败: %w", err)
+	}
+
+	if err := WriteFileAtomic(path, data, 0o644); err != nil {
+		return err
+	}
+	return pruneVersions(path, keep)
+}
+
+// ListVersions 返回 path 的历史版本，最新的排在最前。
+func ListVersions(path string) ([]Version, error) {
+	entries, err := os.ReadDir(historyDir(path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []Version{}, nil
+		}
+		return nil, fmt.Errorf("读取历史目录失败: %w", err)
+	}
+
+	versions := make([]Version, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		id, createdAt, ok := parseVersionName(path, entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		versions = append(versions, Version{ID: id, CreatedAt: createdAt, Size: info.Size()})
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].ID > versions[j].ID
+	})
+	return versions, nil
+}
+
+// ReadVersion 读取指定历史版本的内容。
+func ReadVersion(path, id string) ([]byte, error) {
+	if _, err := time.Parse(historyTimeLayout, id); err != nil {
+		return nil, ErrVersionNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(historyDir(path), versionFileName(path, id)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrVersionNotFound
+		}
+		return nil, fmt.Errorf("读取历史版本失败: %w", err)
+	}
+	return data, nil
+}
+
+func archiveVersion(path string, data []byte) error {
+	dir := historyDir(path)
+	if err := os.MkdirAll(dir, historyDirMode); err != nil {
+		return fmt.Errorf("创建历史目录失败: %w", err)
+	}
+	id := time.Now().UTC().Format(historyTimeLayout)
+	if err := WriteFileAtomic(filepath.Join(dir, versionFileName(path, id)), data, historyFileMode); err != nil {
+		return fmt.Errorf("归档历史版本失败: %w", err)
+	}
+	return nil
+}
+
+func pruneVersions(path string, keep int) error {
+	versions, err := ListVersions(path)
+	if err != nil {
+		return err
+	}
+	for _, version := range versions[min(keep, len(versions)):] {
+		if err := os.Remove(filepath.Join(historyDir(path), versionFileName(path, version.ID))); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("清理历史版本失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// historyDir 返回 path 对应的历史目录，例如 static/settings.json 对应 static/settings.json.history。
+func historyDir(path string) string {
+	return path + historyDirSuffix
+}
+
+func versionFileName(path, id string) string {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "-" + id + ext
+}
+
+func parseVersionName(path, name string) (string, time.Time, bool) {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+		return "", time.Time{}, false
+	}
+	id := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+	createdAt, err := time.Parse(historyTimeLayout, id)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return id, createdAt, true
+}
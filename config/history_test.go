@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSaveVersionedKeepsLimitedHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.WriteFile(path, []byte(`{"v":0}`), 0o644); err != nil {
+		t.Fatalf("failed to seed settings: %v", err)
+	}
+
+	for i := 1; i <= 4; i++ {
+		if err := SaveVersioned(path, []byte(`{"v":`+string(rune('0'+i))+`}`), 2); err != nil {
+			t.Fatalf("SaveVersioned #%d returned error: %v", i, err)
+		}
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil || string(current) != `{"v":4}` {
+		t.Fatalf("unexpected current settings %q, err = %v", current, err)
+	}
+
+	versions, err := ListVersions(path)
+	if err != nil {
+		t.Fatalf("ListVersions returned error: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %+v", versions)
+	}
+
+	latest, err := ReadVersion(path, versions[0].ID)
+	if err != nil || string(latest) != `{"v":3}` {
+		t.Fatalf("unexpected latest version %q, err = %v", latest, err)
+	}
+	oldest, err := ReadVersion(path, versions[1].ID)
+	if err != nil || string(oldest) != `{"v":2}` {
+		t.Fatalf("unexpected oldest version %q, err = %v", oldest, err)
+	}
+
+	if _, err := ReadVersion(path, "../settings"); err != ErrVersionNotFound {
+		t.Fatalf("expected ErrVersionNotFound for invalid id, got %v", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(historyDir(path), versionFileName(path, versions[0].ID)))
+		if err != nil || info.Mode().Perm() != historyFileMode {
+			t.Fatalf("expected history files to be private, got %v, %v", info, err)
+		}
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".settings.json.tmp-*"))
+	if len(leftovers) != 0 {
+		t.Fatalf("expected temp files to be cleaned up, got %v", leftovers)
+	}
+}
+
+func TestValidateConfigRejectsUnknownKeys(t *testing.T) {
+	if _, err := ValidateConfig([]byte(`{"pageSize": 10, "pageSzie": 20}`)); err == nil {
+		t.Fatalf("expected unknown key to be rejected")
+	}
+	if _, err := ValidateConfig([]byte(`{"datasources": "legacy"}`)); err == nil {
+		t.Fatalf("expected mistyped datasources to be rejected")
+	}
+	cfg, err := ValidateConfig([]byte(`{"pageSize": "15", "datasources": [{"name": "a", "type": "legacy_db", "path": "/a.db"}]}`))
+	if err != nil {
+		t.Fatalf("ValidateConfig returned error: %v", err)
+	}
+	if cfg.PageSize != 15 || cfg.SettingsHistoryLimit != DefaultHistoryLimit {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
@@ -0,0 +1,76 @@
+// path: config/secrets.go
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SecretUnchanged 是后台接口返回设置时替换敏感字段的占位值；保存时提交该值表示沿用已保存的内容。
+const SecretUnchanged = "********"
+
+// RedactSecrets 把设置中非空的敏感字段替换为 SecretUnchanged，settings 会被原地修改。
+func RedactSecrets(settings map[string]any) {
+	for name := range secretFields {
+		if value, ok := settings[name].(string); ok && value != "" {
+			settings[name] = SecretUnchanged
+		}
+	}
+}
+
+// RestoreSecrets 把 payload 中值为 SecretUnchanged 的敏感字段恢复为 stored 中已保存的内容，
+// stored 中没有该字段时删除它，避免把占位值当作新密码写入。
+func RestoreSecrets(payload, stored map[string]any) {
+	for name := range secretFields {
+		if value, ok := payload[name].(string); !ok || value != SecretUnchanged {
+			continue
+		}
+		if previous, ok := stored[name]; ok {
+			payload[name] = previous
+		} else {
+			delete(payload, name)
+		}
+	}
+}
+
+// KeepAdminPasswordHash 在 payload 中明文的 adminPassword 与 stored 中已保存的哈希是同一密码时沿用该哈希，
+// 重复提交相同的密码不会生成新的哈希，也就不会被当作修改了密码。
+func KeepAdminPasswordHash(payload, stored map[string]any) {
+	value, ok := payload["adminPassword"].(string)
+	if !ok {
+		return
+	}
+	password := strings.TrimSpace(value)
+	hash, _ := stored["adminPassword"].(string)
+	if password == "" || IsBcryptHash(password) || !IsBcryptHash(hash) {
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+		payload["adminPassword"] = hash
+	}
+}
+
+// HashAdminPassword 把 settings 中明文的 adminPassword 替换为 bcrypt 哈希，已是哈希或为空时保持不变。
+func HashAdminPassword(settings map[string]any) error {
+	value, ok := settings["adminPassword"].(string)
+	if !ok {
+		return nil
+	}
+	password := strings.TrimSpace(value)
+	if password == "" || IsBcryptHash(password) {
+		return nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("生成管理员密码哈希失败: %w", err)
+	}
+	settings["adminPassword"] = string(hash)
+	return nil
+}
+
+// IsBcryptHash 返回 value 是否为 bcrypt 哈希。
+func IsBcryptHash(value string) bool {
+	return strings.HasPrefix(value, "$2a$") || strings.HasPrefix(value, "$2b$") || strings.HasPrefix(value, "$2y$")
+}
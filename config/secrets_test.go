@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSecretsRedactRestoreAndHash(t *testing.T) {
+	stored := map[string]any{"pageSize": 20, "adminPassword": "$2a$10$stored"}
+
+	view := map[string]any{"pageSize": 20, "adminPassword": "$2a$10$stored"}
+	RedactSecrets(view)
+	if view["adminPassword"] != SecretUnchanged || view["pageSize"] != 20 {
+		t.Fatalf("unexpected redacted view: %v", view)
+	}
+	empty := map[string]any{"adminPassword": ""}
+	RedactSecrets(empty)
+	if empty["adminPassword"] != "" {
+		t.Fatalf("expected empty password to stay empty so the UI can tell it is unset")
+	}
+
+	unchanged := map[string]any{"adminPassword": SecretUnchanged}
+	RestoreSecrets(unchanged, stored)
+	if unchanged["adminPassword"] != "$2a$10$stored" {
+		t.Fatalf("expected placeholder to restore stored password, got %v", unchanged["adminPassword"])
+	}
+	missing := map[string]any{"adminPassword": SecretUnchanged}
+	RestoreSecrets(missing, map[string]any{})
+	if _, ok := missing["adminPassword"]; ok {
+		t.Fatalf("expected placeholder without stored value to be dropped")
+	}
+
+	if err := HashAdminPassword(unchanged); err != nil || unchanged["adminPassword"] != "$2a$10$stored" {
+		t.Fatalf("expected existing hash to be kept, got %v, %v", unchanged["adminPassword"], err)
+	}
+	plain := map[string]any{"adminPassword": "  new-secret "}
+	if err := HashAdminPassword(plain); err != nil {
+		t.Fatalf("HashAdminPassword returned error: %v", err)
+	}
+	hash, _ := plain["adminPassword"].(string)
+	if !IsBcryptHash(hash) || bcrypt.CompareHashAndPassword([]byte(hash), []byte("new-secret")) != nil {
+		t.Fatalf("expected trimmed password to be stored as bcrypt hash, got %q", hash)
+	}
+}
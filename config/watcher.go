@@ -0,0 +1,118 @@
+// path: config/watcher.go
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval 是配置文件轮询检查的默认间隔。
+const DefaultWatchInterval = 2 * time.Second
+
+// Watcher 以轮询方式监视配置文件，内容发生变化时回调 onChange。
+// 轮询对编辑器的“写临时文件再 rename”以及 Docker 挂载卷同样有效。
+type Watcher struct {
+	path     string
+	interval time.Duration
+
+	mu       sync.Mutex
+	modTime  time.Time
+	size     int64
+	checksum [sha256.Size]byte
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewWatcher 创建配置文件监视器，interval 小于等于 0 时使用 DefaultWatchInterval。
+func NewWatcher(path string, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	return &Watcher{path: path, interval: interval}
+}
+
+// Start 记录当前文件状态并开始后台轮询，仅在之后的内容变化时回调 onChange。
+func (w *Watcher) Start(onChange func(data []byte)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stop != nil {
+		return fmt.Errorf("配置监视器已启动")
+	}
+	if _, err := w.detectChangeLocked(); err != nil {
+		return err
+	}
+
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	go w.loop(w.stop, w.done, onChange)
+	return nil
+}
+
+// Stop 停止后台轮询并等待其退出。
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	stop, done := w.stop, w.done
+	w.stop, w.done = nil, nil
+	w.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (w *Watcher) loop(stop, done chan struct{}, onChange func(data []byte)) {
+	defer close(done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			data, err := w.detectChangeLocked()
+			w.mu.Unlock()
+			if err != nil {
+				slog.Warn("检查配置文件失败", slog.String("path", w.path), slog.String("error", err.Error()))
+				continue
+			}
+			if data != nil {
+				onChange(data)
+			}
+		}
+	}
+}
+
+// detectChangeLocked 在文件内容变化时返回新内容，未变化时返回 nil。调用方需持有 mu。
+func (w *Watcher) detectChangeLocked() ([]byte, error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return nil, err
+	}
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return nil, err
+	}
+	w.modTime = info.ModTime()
+	w.size = info.Size()
+
+	checksum := sha256.Sum256(data)
+	if checksum == w.checksum {
+		return nil, nil
+	}
+	w.checksum = checksum
+	return data, nil
+}
@@ -1,6 +1,7 @@
 // path: frontend/src/components/BookItem.tsx
 import type { Book } from '../types/Book'
-import { buildApiUrl } from '../utils/api'
+import useGlobalStore from '../store/useGlobalStore'
+import { buildApiUrl, withAccessToken } from '../utils/api'
 
 type Props = {
   book: Book
@@ -17,11 +18,16 @@ const joinValues = (values?: string[]) => {
 const BookItem = ({ book, showCovers = true }: Props) => {
   const authorsText = joinValues(book.authors)
   const hasTags = Array.isArray(book.tags) && book.tags.length > 0
+  const token = useGlobalStore((state) => state.token)
   const coverUrl = book.has_cover
-    ? buildApiUrl(`/api/v1/cover?source=${encodeURIComponent(book.source)}&id=${encodeURIComponent(book.id)}`)
+    ? withAccessToken(
+        buildApiUrl(`/api/v1/cover?source=${encodeURIComponent(book.source)}&id=${encodeURIComponent(book.id)}`),
+        token
+      )
     : null
-  const downloadUrl = buildApiUrl(
-    `/api/v1/download?source=${encodeURIComponent(book.source)}&id=${encodeURIComponent(book.id)}`
+  const downloadUrl = withAccessToken(
+    buildApiUrl(`/api/v1/download?source=${encodeURIComponent(book.source)}&id=${encodeURIComponent(book.id)}`),
+    token
   )
   const coverWrapperClassName = [
     'relative aspect-[2/3] w-full md:w-1/3 md:min-w-[168px] flex items-center justify-center bg-[#e7dfcf]',
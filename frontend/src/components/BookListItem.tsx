@@ -1,6 +1,7 @@
 // path: frontend/src/components/BookListItem.tsx
 import type { Book } from '../types/Book'
-import { buildApiUrl } from '../utils/api'
+import useGlobalStore from '../store/useGlobalStore'
+import { buildApiUrl, withAccessToken } from '../utils/api'
 
 type Props = {
   book: Book
@@ -16,11 +17,16 @@ const joinValues = (values?: string[]) => {
 
 const BookListItem = ({ book, showCovers = true }: Props) => {
   const authorsText = joinValues(book.authors)
+  const token = useGlobalStore((state) => state.token)
   const coverUrl = book.has_cover
-    ? buildApiUrl(`/api/v1/cover?source=${encodeURIComponent(book.source)}&id=${encodeURIComponent(book.id)}`)
+    ? withAccessToken(
+        buildApiUrl(`/api/v1/cover?source=${encodeURIComponent(book.source)}&id=${encodeURIComponent(book.id)}`),
+        token
+      )
     : null
-  const downloadUrl = buildApiUrl(
-    `/api/v1/download?source=${encodeURIComponent(book.source)}&id=${encodeURIComponent(book.id)}`
+  const downloadUrl = withAccessToken(
+    buildApiUrl(`/api/v1/download?source=${encodeURIComponent(book.source)}&id=${encodeURIComponent(book.id)}`),
+    token
   )
 
   return (
@@ -2,8 +2,9 @@
 import { Link, useNavigate } from 'react-router-dom'
 import BookItem from './BookItem'
 import BookListItem from './BookListItem'
+import useGlobalStore from '../store/useGlobalStore'
 import type { Book } from '../types/Book'
-import { buildApiUrl } from '../utils/api'
+import { buildApiUrl, withAccessToken } from '../utils/api'
 
 export type DisplayMode = 'compact' | 'detail' | 'table' | 'card'
 export type ResultDensity = 'compact' | 'comfortable'
@@ -50,8 +51,10 @@ const DetailItem = ({
   density?: ResultDensity
   showIdentifiers?: boolean
 }) => {
-  const downloadUrl = buildApiUrl(
-    `/api/v1/download?source=${encodeURIComponent(book.source)}&id=${encodeURIComponent(book.id)}`
+  const token = useGlobalStore((state) => state.token)
+  const downloadUrl = withAccessToken(
+    buildApiUrl(`/api/v1/download?source=${encodeURIComponent(book.source)}&id=${encodeURIComponent(book.id)}`),
+    token
   )
   const paddingClassName = density === 'comfortable' ? 'p-5' : 'p-4'
 
@@ -65,6 +65,7 @@ const AdminPage = () => {
   const [adminPassword, setAdminPassword] = useState('')
   const [corsAllowedOrigins, setCorsAllowedOrigins] = useState('')
   const [datasources, setDatasources] = useState([emptyDatasource()])
+  const [rawConfig, setRawConfig] = useState({})
   const [message, setMessage] = useState(null)
   const [messageType, setMessageType] = useState('success')
   const [loadingConfig, setLoadingConfig] = useState(false)
@@ -85,6 +86,7 @@ const AdminPage = () => {
         throw new Error('无法获取配置')
       }
       const data = await response.json()
+      setRawConfig(data)
       setPageSize(String(data.pageSize ?? data.page_size ?? ''))
       setDefaultSearchField(String(data.defaultSearchField ?? 'title'))
       setResultDisplayMode(normalizeOption(data.resultDisplayMode, displayModeOptions, 'compact'))
@@ -96,6 +98,7 @@ const AdminPage = () => {
       if (Array.isArray(data.datasources) && data.datasources.length > 0) {
         setDatasources(
           data.datasources.map((item) => ({
+            ...item,
             name: item.name ?? '',
             type: item.type ?? 'calibre',
             path: item.path ?? ''
@@ -147,6 +150,7 @@ const AdminPage = () => {
     setMessage(null)
 
     const payload = {
+      ...rawConfig,
       pageSize,
       defaultSearchField,
       resultDisplayMode,
@@ -159,6 +163,7 @@ const AdminPage = () => {
         .map((item) => item.trim())
         .filter(Boolean),
       datasources: datasources.map((item) => ({
+        ...item,
         name: item.name.trim(),
         type: item.type.trim(),
         path: item.path.trim()
@@ -316,11 +321,12 @@ const AdminPage = () => {
                     <input
                       id="adminPassword"
                       name="adminPassword"
-                      type="text"
+                      type="password"
+                      autoComplete="new-password"
                       value={adminPassword}
                       onChange={(event) => setAdminPassword(event.target.value)}
                       className={`${inputClassName} mt-2`}
-                      placeholder="明文密码或 bcrypt 哈希"
+                      placeholder="保存时自动转为 bcrypt 哈希，保持原值表示不修改"
                     />
                   </div>
                   <div className="sm:col-span-2">
@@ -15,6 +15,7 @@ const LoginPage = () => {
   const token = useGlobalStore((state) => state.token)
   const login = useGlobalStore((state) => state.login)
 
+  const [username, setUsername] = useState('')
   const [password, setPassword] = useState('')
   const [loading, setLoading] = useState(false)
 
@@ -27,17 +28,17 @@ const LoginPage = () => {
   const handleSubmit = async (event) => {
     event.preventDefault()
     if (!password.trim()) {
-      toast.error('请输入管理员密码。')
+      toast.error('请输入密码。')
       return
     }
     setLoading(true)
-    const success = await login(password)
+    const success = await login(password, username)
     setLoading(false)
     if (success) {
       toast.success('登录成功')
       navigate('/admin', { replace: true })
     } else {
-      toast.error('登录失败，请检查密码。')
+      toast.error('登录失败，请检查用户名和密码。')
     }
   }
 
@@ -50,9 +51,24 @@ const LoginPage = () => {
         <p className="meta-label">Admin</p>
         <h1 className="mt-1 text-2xl font-bold text-ink">后台登录</h1>
         <div className="mt-6 space-y-5">
+          <div>
+            <label htmlFor="username" className="block text-sm font-bold text-ink">
+              用户名
+            </label>
+            <input
+              id="username"
+              name="username"
+              type="text"
+              autoComplete="username"
+              value={username}
+              onChange={(event) => setUsername(event.target.value)}
+              className={`${inputClassName} mt-2`}
+              placeholder="留空则使用管理员密码登录"
+            />
+          </div>
           <div>
             <label htmlFor="adminPassword" className="block text-sm font-bold text-ink">
-              管理员密码
+              密码
             </label>
             <input
               id="adminPassword"
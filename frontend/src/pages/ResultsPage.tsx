@@ -47,6 +47,7 @@ const segmentButtonClassName = (active: boolean) =>
 const ResultsPage = () => {
   const [searchParams] = useSearchParams()
   const settings = useGlobalStore((state) => state.settings)
+  const token = useGlobalStore((state) => state.token)
   const [books, setBooks] = useState<Book[]>([])
   const [meta, setMeta] = useState<ResultsMeta>({
     totalPages: 0,
@@ -76,8 +77,12 @@ const ResultsPage = () => {
       setError(null)
       try {
         const response = await fetch(buildApiUrl(`/api/v1/search?${queryString}`), {
-          signal: controller.signal
+          signal: controller.signal,
+          headers: token ? { Authorization: `Bearer ${token}` } : undefined
         })
+        if (response.status === 401) {
+          throw new Error('当前站点需要登录后才能检索，请先登录。')
+        }
         if (!response.ok) {
           throw new Error('检索失败，请稍后再试。')
         }
@@ -106,7 +111,7 @@ const ResultsPage = () => {
     }
 
     return () => controller.abort()
-  }, [queryString])
+  }, [queryString, token])
 
   const searchSeconds = (meta.searchTimeMs / 1000).toFixed(2)
   const hasQuery = queryString.length > 0
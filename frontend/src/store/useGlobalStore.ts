@@ -16,6 +16,7 @@ interface SearchSettingsPayload {
   resultDensity?: unknown
   showCovers?: unknown
   showIdentifiers?: unknown
+  accessPolicy?: unknown
   [key: string]: unknown
 }
 
@@ -28,7 +29,7 @@ export interface GlobalState {
   settings: Partial<Settings>
   loading: LoadingState
   fetchSettings: () => Promise<void>
-  login: (password: string) => Promise<boolean>
+  login: (password: string, username?: string) => Promise<boolean>
   logout: () => void
 }
 
@@ -81,6 +82,13 @@ const normalizeSettings = (payload: SearchSettingsPayload): Partial<Settings> =>
     normalized.showIdentifiers = payload.showIdentifiers
   }
 
+  if (
+    typeof payload.accessPolicy === 'string' &&
+    ['public', 'loginForDownloads', 'loginRequired'].includes(payload.accessPolicy)
+  ) {
+    normalized.accessPolicy = payload.accessPolicy as Settings['accessPolicy']
+  }
+
   return normalized
 }
 
@@ -92,7 +100,7 @@ const updateLoading = (key: LoadingKey, value: boolean) =>
     }
   })
 
-const useGlobalStore = create<GlobalState>((set) => ({
+const useGlobalStore = create<GlobalState>((set, get) => ({
   token: getInitialToken(),
   settings: {},
   loading: { settings: false },
@@ -111,14 +119,14 @@ const useGlobalStore = create<GlobalState>((set) => ({
       set(updateLoading('settings', false))
     }
   },
-  login: async (password: string) => {
+  login: async (password: string, username?: string) => {
     try {
       const response = await fetch(buildApiUrl('/api/v1/login'), {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json'
         },
-        body: JSON.stringify({ password })
+        body: JSON.stringify({ username: username?.trim() || undefined, password })
       })
       if (!response.ok) {
         throw new Error('登录失败')
@@ -143,6 +151,14 @@ const useGlobalStore = create<GlobalState>((set) => ({
     }
   },
   logout: () => {
+    const { token } = get()
+    if (token) {
+      // 通知服务端吊销当前凭证，失败时仍在本地退出登录。
+      fetch(buildApiUrl('/api/v1/logout'), {
+        method: 'POST',
+        headers: { Authorization: `Bearer ${token}` }
+      }).catch((error) => console.error(error))
+    }
     if (typeof window !== 'undefined') {
       window.localStorage.removeItem(TOKEN_STORAGE_KEY)
     }
@@ -6,4 +6,5 @@ export interface Settings {
   resultDensity: 'compact' | 'comfortable'
   showCovers: boolean
   showIdentifiers: boolean
+  accessPolicy: 'public' | 'loginForDownloads' | 'loginRequired'
 }
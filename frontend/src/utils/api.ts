@@ -8,3 +8,13 @@ export const buildApiUrl = (path: string) => {
   const normalizedPath = path.startsWith('/') ? path : `/${path}`
   return normalizedApiBaseUrl ? `${normalizedApiBaseUrl}${normalizedPath}` : normalizedPath
 }
+
+// withAccessToken 为封面与下载链接附带登录令牌：<img> 与 <a> 无法携带 Authorization 头，
+// 服务端在访问策略要求登录时会读取 token 查询参数。
+export const withAccessToken = (url: string, token?: string | null) => {
+  if (!token) {
+    return url
+  }
+  const separator = url.includes('?') ? '&' : '?'
+  return `${url}${separator}token=${encodeURIComponent(token)}`
+}
@@ -22,10 +22,13 @@ import (
 )
 
 type calibreAdapter struct {
-	name    string
-	rootDir string
-	dbPath  string
-	db      *sql.DB
+	name      string
+	rootDir   string
+	dbPath    string
+	readOnly  bool
+	tokenizer string
+	coverFile string
+	db        *sql.DB
 }
 
 // NewCalibreAdapter 根据配置创建 Calibre 数据源适配器。
@@ -39,9 +42,12 @@ func NewCalibreAdapter(cfg config.DatasourceConfig) core.Datasource {
 	}
 
 	return &calibreAdapter{
-		name:    cfg.Name,
-		rootDir: root,
-		dbPath:  dbPath,
+		name:      cfg.Name,
+		rootDir:   root,
+		dbPath:    dbPath,
+		readOnly:  cfg.ReadOnly,
+		tokenizer: cfg.Tokenizer,
+		coverFile: filepath.Base(cfg.Option("coverFile", "cover.jpg")),
 	}
 }
 
@@ -58,6 +64,9 @@ func (a *calibreAdapter) Init() error {
 	}
 
 	dsn := fmt.Sprintf("file:%s?_busy_timeout=5000&cache=shared", filepath.ToSlash(a.dbPath))
+	if a.readOnly {
+		dsn += "&mode=ro"
+	}
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return fmt.Errorf("打开 Calibre 数据库失败: %w", err)
@@ -71,11 +80,23 @@ func (a *calibreAdapter) Init() error {
 		return fmt.Errorf("Calibre 数据库连接测试失败: %w", err)
 	}
 
-	sqlitecfg.ConfigureSQLitePragmas(db)
-
-	if err := ensureCalibreFTS(db); err != nil {
-		db.Close()
-		return fmt.Errorf("Calibre FTS 初始化失败: %w", err)
+	if a.readOnly {
+		// 只读模式下无法重建索引，要求此前已以可写模式初始化过一次。
+		exists, err := sqlitecfg.TableExists(db, calibreFTSTable)
+		if err != nil {
+			db.Close()
+			return fmt.Errorf("检查 Calibre FTS 表失败: %w", err)
+		}
+		if !exists {
+			db.Close()
+			return fmt.Errorf("只读 Calibre 数据源 %s 缺少 %s 索引，请先以可写模式初始化一次或执行 reindex 子命令", a.name, calibreFTSTable)
+		}
+	} else {
+		sqlitecfg.ConfigureSQLitePragmas(db)
+		if err := timedFTSRebuild(a.name, func() error { return ensureCalibreFTS(db, a.tokenizer) }); err != nil {
+			db.Close()
+			return fmt.Errorf("Calibre FTS 初始化失败: %w", err)
+		}
 	}
 
 	a.db = db
@@ -101,7 +122,7 @@ func (a *calibreAdapter) Search(ctx context.Context, params *search.QueryParams)
 	start := time.Now()
 	rows, err := a.db.QueryContext(ctx, querySQL, queryArgs...)
 	if err != nil {
-		slog.Error("Calibre 查询失败",
+		slog.ErrorContext(ctx, "Calibre 查询失败",
 			slog.String("datasource", a.name),
 			slog.String("sql", querySQL),
 			slog.Any("sql_args", queryArgs),
@@ -115,18 +136,9 @@ func (a *calibreAdapter) Search(ctx context.Context, params *search.QueryParams)
 
 	books := make([]core.CanonicalBook, 0)
 	for rows.Next() {
-		var (
-			id          int64
-			title       sql.NullString
-			authorsRaw  sql.NullString
-			description sql.NullString
-			tagsRaw     sql.NullString
-			publisher   sql.NullString
-			hasCover    sql.NullInt64
-		)
-
-		if err := rows.Scan(&id, &title, &authorsRaw, &description, &tagsRaw, &publisher, &hasCover); err != nil {
-			slog.Error("Calibre 结果解析失败",
+		book, err := a.scanBook(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "Calibre 结果解析失败",
 				slog.String("datasource", a.name),
 				slog.String("sql", querySQL),
 				slog.Any("sql_args", queryArgs),
@@ -136,28 +148,11 @@ func (a *calibreAdapter) Search(ctx context.Context, params *search.QueryParams)
 			)
 			return nil, 0, fmt.Errorf("Calibre 结果解析失败: %w", err)
 		}
-
-		book := core.CanonicalBook{
-			ID:          strconv.FormatInt(id, 10),
-			Title:       strings.TrimSpace(title.String),
-			Authors:     splitList(authorsRaw.String),
-			Description: strings.TrimSpace(description.String),
-			Tags:        splitList(tagsRaw.String),
-			Publisher:   strings.TrimSpace(publisher.String),
-			Source:      a.name,
-			HasCover:    hasCover.Valid && hasCover.Int64 != 0,
-			CanDownload: true,
-		}
-
-		if book.Title == "" {
-			book.Title = fmt.Sprintf("ID %d", id)
-		}
-
 		books = append(books, book)
 	}
 
 	if err := rows.Err(); err != nil {
-		slog.Error("Calibre 查询遍历失败",
+		slog.ErrorContext(ctx, "Calibre 查询遍历失败",
 			slog.String("datasource", a.name),
 			slog.String("sql", querySQL),
 			slog.Any("sql_args", queryArgs),
@@ -170,7 +165,7 @@ func (a *calibreAdapter) Search(ctx context.Context, params *search.QueryParams)
 
 	var total int64
 	if err := a.db.QueryRowContext(ctx, countSQL, countArgs...).Scan(&total); err != nil {
-		slog.Error("Calibre 计数查询失败",
+		slog.ErrorContext(ctx, "Calibre 计数查询失败",
 			slog.String("datasource", a.name),
 			slog.String("sql", countSQL),
 			slog.Any("sql_args", countArgs),
@@ -181,20 +176,98 @@ func (a *calibreAdapter) Search(ctx context.Context, params *search.QueryParams)
 		return nil, 0, fmt.Errorf("Calibre 计数查询失败: %w", err)
 	}
 
-	slog.Info("Calibre 查询完成",
+	logQueryDone(ctx, "Calibre", time.Since(start),
 		slog.String("datasource", a.name),
 		slog.String("sql", querySQL),
 		slog.Any("sql_args", queryArgs),
 		slog.String("count_sql", countSQL),
 		slog.Any("count_args", countArgs),
 		slog.Any("request", params),
-		slog.Duration("elapsed", time.Since(start)),
 		slog.Int("records", len(books)),
 	)
 
 	return books, total, nil
 }
 
+// calibreBookSelect 查询 scanBook 所需的列，调用方在其后追加 FTS 连接、条件与排序。
+const calibreBookSelect = `SELECT b.id,
+       b.title,
+       (SELECT GROUP_CONCAT(a.name, ', ') FROM authors a JOIN books_authors_link bal ON bal.author = a.id WHERE bal.book = b.id ORDER BY bal.id) AS authors,
+       COALESCE(cm.text, '') AS description,
+       (SELECT GROUP_CONCAT(t.name, ', ') FROM tags t JOIN books_tags_link btl ON btl.tag = t.id WHERE btl.book = b.id ORDER BY t.name) AS tags,
+       COALESCE(p.name, '') AS publisher,
+       COALESCE(b.has_cover, 0) AS has_cover
+FROM books b
+LEFT JOIN comments cm ON cm.book = b.id
+LEFT JOIN publishers p ON p.id = b.publisher`
+
+// scanBook 把 calibreBookSelect 查询到的一行转换为 CanonicalBook。
+func (a *calibreAdapter) scanBook(rows *sql.Rows) (core.CanonicalBook, error) {
+	var (
+		id          int64
+		title       sql.NullString
+		authorsRaw  sql.NullString
+		description sql.NullString
+		tagsRaw     sql.NullString
+		publisher   sql.NullString
+		hasCover    sql.NullInt64
+	)
+	if err := rows.Scan(&id, &title, &authorsRaw, &description, &tagsRaw, &publisher, &hasCover); err != nil {
+		return core.CanonicalBook{}, err
+	}
+
+	book := core.CanonicalBook{
+		ID:          strconv.FormatInt(id, 10),
+		Title:       strings.TrimSpace(title.String),
+		Authors:     splitList(authorsRaw.String),
+		Description: strings.TrimSpace(description.String),
+		Tags:        splitList(tagsRaw.String),
+		Publisher:   strings.TrimSpace(publisher.String),
+		Source:      a.name,
+		HasCover:    hasCover.Valid && hasCover.Int64 != 0,
+		CanDownload: true,
+	}
+	if book.Title == "" {
+		book.Title = fmt.Sprintf("ID %d", id)
+	}
+	return book, nil
+}
+
+// GetBooks 按 ID 读取书籍，无法解析为整数的 ID 视为不存在。
+func (a *calibreAdapter) GetBooks(ctx context.Context, ids []string) ([]core.CanonicalBook, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("Calibre 数据源 %s 尚未初始化", a.name)
+	}
+	args := make([]any, 0, len(ids))
+	for _, raw := range ids {
+		if id, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64); err == nil {
+			args = append(args, id)
+		}
+	}
+	books := make([]core.CanonicalBook, 0, len(args))
+	if len(args) == 0 {
+		return books, nil
+	}
+
+	query := calibreBookSelect + " WHERE b.id IN (" + placeholders(len(args)) + ")"
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Calibre 查询失败: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		book, err := a.scanBook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("Calibre 结果解析失败: %w", err)
+		}
+		books = append(books, book)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Calibre 查询遍历失败: %w", err)
+	}
+	return books, nil
+}
+
 func (a *calibreAdapter) buildStatements(params *search.QueryParams) (string, []any, string, []any, error) {
 	conditions := make([]string, 0, len(params.Fields))
 	args := make([]any, 0, len(params.Fields))
@@ -240,16 +313,7 @@ func (a *calibreAdapter) buildStatements(params *search.QueryParams) (string, []
 	whereClause := buildWhereClause(conditions)
 
 	selectSQL := strings.Builder{}
-	selectSQL.WriteString(`SELECT b.id,
-       b.title,
-       (SELECT GROUP_CONCAT(a.name, ', ') FROM authors a JOIN books_authors_link bal ON bal.author = a.id WHERE bal.book = b.id ORDER BY bal.id) AS authors,
-       COALESCE(cm.text, '') AS description,
-       (SELECT GROUP_CONCAT(t.name, ', ') FROM tags t JOIN books_tags_link btl ON btl.tag = t.id WHERE btl.book = b.id ORDER BY t.name) AS tags,
-       COALESCE(p.name, '') AS publisher,
-       COALESCE(b.has_cover, 0) AS has_cover
-FROM books b
-LEFT JOIN comments cm ON cm.book = b.id
-LEFT JOIN publishers p ON p.id = b.publisher`)
+	selectSQL.WriteString(calibreBookSelect)
 	if needFTSJoin {
 		selectSQL.WriteString(" JOIN " + calibreFTSTable + " f ON f.rowid = b.id")
 	}
@@ -310,6 +374,11 @@ func calibreCondition(field, value string, fuzzy bool) (string, any, bool) {
 	return "f MATCH ?", scoped, true
 }
 
+// placeholders 返回 n 个以逗号分隔的 SQL 占位符。
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
 func buildWhereClause(conditions []string) string {
 	if len(conditions) == 0 {
 		return ""
@@ -341,15 +410,15 @@ func splitList(raw string) []string {
 
 const (
 	calibreFTSTable     = "calibre_books_fts"
-	calibreFTSCreateSQL = `CREATE VIRTUAL TABLE IF NOT EXISTS calibre_books_fts USING fts5(
+	calibreFTSCreateSQL = `CREATE VIRTUAL TABLE calibre_books_fts USING fts5(
 title,
 authors,
 tags,
 publisher,
 description,
-tokenize='unicode61'
+tokenize='%s'
 )`
-	calibreFTSClearSQL    = `DELETE FROM calibre_books_fts`
+	calibreFTSDropSQL     = `DROP TABLE IF EXISTS calibre_books_fts`
 	calibreFTSPopulateSQL = `INSERT INTO calibre_books_fts(rowid, title, authors, tags, publisher, description)
 SELECT b.id,
    lower(COALESCE(b.title, '')),
@@ -362,7 +431,8 @@ LEFT JOIN comments cm ON cm.book = b.id
 LEFT JOIN publishers p ON p.id = b.publisher`
 )
 
-func ensureCalibreFTS(db *sql.DB) error {
+// ensureCalibreFTS 在事务中删除并重建 calibre_books_fts，使分词器配置的变更在下次初始化时生效。
+func ensureCalibreFTS(db *sql.DB, tokenizer string) error {
 	exists, err := sqlitecfg.TableExists(db, "books")
 	if err != nil {
 		return fmt.Errorf("检查 Calibre books 表失败: %w", err)
@@ -370,17 +440,17 @@ func ensureCalibreFTS(db *sql.DB) error {
 	if !exists {
 		return nil
 	}
-	if _, err := db.Exec(calibreFTSCreateSQL); err != nil {
-		return fmt.Errorf("创建 Calibre FTS 表失败: %w", err)
-	}
 	tx, err := db.Begin()
 	if err != nil {
 		return fmt.Errorf("开启 Calibre FTS 事务失败: %w", err)
 	}
 	defer tx.Rollback()
-	if _, err := tx.Exec(calibreFTSClearSQL); err != nil {
+	if _, err := tx.Exec(calibreFTSDropSQL); err != nil {
 		return fmt.Errorf("清理 Calibre FTS 数据失败: %w", err)
 	}
+	if _, err := tx.Exec(fmt.Sprintf(calibreFTSCreateSQL, ftsTokenizer(tokenizer))); err != nil {
+		return fmt.Errorf("创建 Calibre FTS 表失败: %w", err)
+	}
 	if _, err := tx.Exec(calibreFTSPopulateSQL); err != nil {
 		return fmt.Errorf("重建 Calibre FTS 索引失败: %w", err)
 	}
@@ -460,13 +530,32 @@ func (a *calibreAdapter) GetBookCover(bookID string) (string, error) {
 		return "", os.ErrNotExist
 	}
 
-	coverPath := filepath.Join(a.rootDir, strings.TrimSpace(relPath.String), "cover.jpg")
+	coverPath := filepath.Join(a.rootDir, strings.TrimSpace(relPath.String), a.coverFile)
 	if _, err := os.Stat(coverPath); err != nil {
 		return "", fmt.Errorf("无法访问 Calibre 封面: %w", err)
 	}
 	return coverPath, nil
 }
 
+// Ping 检查数据库连接可用且 books 表可读。
+func (a *calibreAdapter) Ping(ctx context.Context) error {
+	if a.db == nil {
+		return fmt.Errorf("Calibre 数据源 %s 尚未初始化", a.name)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := a.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("Calibre 数据库连接不可用: %w", err)
+	}
+	var flag int
+	err := a.db.QueryRowContext(ctx, "SELECT 1 FROM books LIMIT 1").Scan(&flag)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("Calibre books 表不可读: %w", err)
+	}
+	return nil
+}
+
 func (a *calibreAdapter) Close() error {
 	if a.db != nil {
 		err := a.db.Close()
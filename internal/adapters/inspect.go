@@ -0,0 +1,112 @@
+// path: internal/adapters/inspect.go
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"ebookdatabase/internal/core"
+	"ebookdatabase/internal/infra/sqlitecfg"
+)
+
+const (
+	schemaLegacyID     = "legacy_id"
+	schemaLegacyBookID = "legacy_book_id"
+	schemaCalibre      = "calibre"
+)
+
+// Inspect 以只读方式打开 Legacy 数据库，识别表结构并统计图书数量。
+func (a *legacyAdapter) Inspect(ctx context.Context) (core.DatasourceInfo, error) {
+	info := core.DatasourceInfo{Type: "legacy_db", Path: a.path}
+
+	db, err := openReadOnly(ctx, a.path)
+	if err != nil {
+		return info, fmt.Errorf("打开 Legacy 数据库失败: %w", err)
+	}
+	defer db.Close()
+
+	schema, err := detectLegacySchema(db)
+	if err != nil {
+		return info, fmt.Errorf("Legacy 表结构识别失败: %w", err)
+	}
+	info.Schema = schemaLegacyID
+	if schema.idColumn == "book_id" {
+		info.Schema = schemaLegacyBookID
+	}
+
+	if schema.ftsTable != "" {
+		info.FTSTable = schema.ftsTable
+		if info.FTSReady, err = sqlitecfg.TableExists(db, schema.ftsTable); err != nil {
+			return info, fmt.Errorf("检查 Legacy FTS 表失败: %w", err)
+		}
+	}
+
+	if info.BookCount, err = countBooks(ctx, db); err != nil {
+		return info, err
+	}
+	return info, nil
+}
+
+// Inspect 以只读方式打开 Calibre 的 metadata.db，确认核心表存在并统计图书数量。
+func (a *calibreAdapter) Inspect(ctx context.Context) (core.DatasourceInfo, error) {
+	info := core.DatasourceInfo{Type: "calibre", Path: a.dbPath, FTSTable: calibreFTSTable}
+
+	db, err := openReadOnly(ctx, a.dbPath)
+	if err != nil {
+		return info, fmt.Errorf("打开 Calibre 数据库失败: %w", err)
+	}
+	defer db.Close()
+
+	for _, table := range []string{"books", "authors", "books_authors_link", "data"} {
+		exists, err := sqlitecfg.TableExists(db, table)
+		if err != nil {
+			return info, fmt.Errorf("检查 Calibre %s 表失败: %w", table, err)
+		}
+		if !exists {
+			return info, fmt.Errorf("Calibre 数据库缺少 %s 表", table)
+		}
+	}
+	info.Schema = schemaCalibre
+
+	if info.FTSReady, err = sqlitecfg.TableExists(db, calibreFTSTable); err != nil {
+		return info, fmt.Errorf("检查 Calibre FTS 表失败: %w", err)
+	}
+
+	if info.BookCount, err = countBooks(ctx, db); err != nil {
+		return info, err
+	}
+	return info, nil
+}
+
+// openReadOnly 以只读模式打开 SQLite 文件。文件不存在时直接报错，避免 SQLite 自动创建空库。
+func openReadOnly(ctx context.Context, path string) (*sql.DB, error) {
+	if path == "" {
+		return nil, fmt.Errorf("未指定数据库路径")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=5000&mode=ro", filepath.ToSlash(path))
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func countBooks(ctx context.Context, db *sql.DB) (int64, error) {
+	var total int64
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM books").Scan(&total); err != nil {
+		return 0, fmt.Errorf("统计图书数量失败: %w", err)
+	}
+	return total, nil
+}
@@ -21,10 +21,13 @@ import (
 )
 
 type legacyAdapter struct {
-	name   string
-	path   string
-	db     *sql.DB
-	schema legacySchema
+	name       string
+	path       string
+	readOnly   bool
+	tokenizer  string
+	separators []string
+	db         *sql.DB
+	schema     legacySchema
 }
 
 type legacySchema struct {
@@ -38,8 +41,11 @@ type legacySchema struct {
 // NewLegacyAdapter 根据配置创建旧版数据库适配器。
 func NewLegacyAdapter(cfg config.DatasourceConfig) core.Datasource {
 	return &legacyAdapter{
-		name: cfg.Name,
-		path: strings.TrimSpace(cfg.Path),
+		name:       cfg.Name,
+		path:       strings.TrimSpace(cfg.Path),
+		readOnly:   cfg.ReadOnly,
+		tokenizer:  cfg.Tokenizer,
+		separators: parseAuthorSeparators(cfg.Option("authorSeparators", ";,")),
 	}
 }
 
@@ -52,6 +58,9 @@ func (a *legacyAdapter) Init() error {
 	}
 
 	dsn := fmt.Sprintf("file:%s?_busy_timeout=5000", a.path)
+	if a.readOnly {
+		dsn += "&mode=ro"
+	}
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return fmt.Errorf("打开 Legacy 数据库失败: %w", err)
@@ -64,7 +73,9 @@ func (a *legacyAdapter) Init() error {
 		return fmt.Errorf("Legacy 数据库连接测试失败: %w", err)
 	}
 
-	sqlitecfg.ConfigureSQLitePragmas(db)
+	if !a.readOnly {
+		sqlitecfg.ConfigureSQLitePragmas(db)
+	}
 
 	schema, err := detectLegacySchema(db)
 	if err != nil {
@@ -72,8 +83,18 @@ func (a *legacyAdapter) Init() error {
 		return fmt.Errorf("Legacy 表结构识别失败: %w", err)
 	}
 
-	if schema.rebuildFTS {
-		if err := ensureLegacyFTS(db); err != nil {
+	if schema.rebuildFTS && a.readOnly {
+		// 只读模式下无法重建索引，沿用已有的 books_fts，不存在时退回 LIKE 匹配。
+		exists, err := sqlitecfg.TableExists(db, schema.ftsTable)
+		if err != nil {
+			db.Close()
+			return fmt.Errorf("检查 Legacy FTS 表失败: %w", err)
+		}
+		if !exists {
+			schema.ftsTable = ""
+		}
+	} else if schema.rebuildFTS {
+		if err := timedFTSRebuild(a.name, func() error { return ensureLegacyFTS(db, a.tokenizer) }); err != nil {
 			db.Close()
 			return fmt.Errorf("Legacy FTS 初始化失败: %w", err)
 		}
@@ -114,7 +135,7 @@ func (a *legacyAdapter) Search(ctx context.Context, params *search.QueryParams)
 	start := time.Now()
 	rows, err := a.db.QueryContext(ctx, querySQL, queryArgs...)
 	if err != nil {
-		slog.Error("Legacy 查询失败",
+		slog.ErrorContext(ctx, "Legacy 查询失败",
 			slog.String("datasource", a.name),
 			slog.String("sql", querySQL),
 			slog.Any("sql_args", queryArgs),
@@ -126,7 +147,7 @@ func (a *legacyAdapter) Search(ctx context.Context, params *search.QueryParams)
 	}
 	books, err := scanLegacyBooks(rows)
 	if err != nil {
-		slog.Error("Legacy 结果解析失败",
+		slog.ErrorContext(ctx, "Legacy 结果解析失败",
 			slog.String("datasource", a.name),
 			slog.String("sql", querySQL),
 			slog.Any("sql_args", queryArgs),
@@ -139,7 +160,7 @@ func (a *legacyAdapter) Search(ctx context.Context, params *search.QueryParams)
 
 	var total int64
 	if err := a.db.QueryRowContext(ctx, countSQL, countArgs...).Scan(&total); err != nil {
-		slog.Error("Legacy 计数查询失败",
+		slog.ErrorContext(ctx, "Legacy 计数查询失败",
 			slog.String("datasource", a.name),
 			slog.String("sql", countSQL),
 			slog.Any("sql_args", countArgs),
@@ -150,6 +171,23 @@ func (a *legacyAdapter) Search(ctx context.Context, params *search.QueryParams)
 		return nil, 0, fmt.Errorf("Legacy 计数查询失败: %w", err)
 	}
 
+	canonical := a.canonicalBooks(books)
+
+	logQueryDone(ctx, "Legacy", time.Since(start),
+		slog.String("datasource", a.name),
+		slog.String("sql", querySQL),
+		slog.Any("sql_args", queryArgs),
+		slog.String("count_sql", countSQL),
+		slog.Any("count_args", countArgs),
+		slog.Any("request", params),
+		slog.Int("records", len(canonical)),
+	)
+
+	return canonical, total, nil
+}
+
+// canonicalBooks 把 Legacy 记录转换为 CanonicalBook。
+func (a *legacyAdapter) canonicalBooks(books []models.Book) []core.CanonicalBook {
 	canonical := make([]core.CanonicalBook, 0, len(books))
 	for _, book := range books {
 		id := ""
@@ -164,7 +202,7 @@ func (a *legacyAdapter) Search(ctx context.Context, params *search.QueryParams)
 		canonical = append(canonical, core.CanonicalBook{
 			ID:          id,
 			Title:       title,
-			Authors:     splitLegacyAuthors(getString(book.Author)),
+			Authors:     splitLegacyAuthors(getString(book.Author), a.separators),
 			Description: "",
 			Tags:        nil,
 			Publisher:   strings.TrimSpace(getString(book.Publisher)),
@@ -178,19 +216,34 @@ func (a *legacyAdapter) Search(ctx context.Context, params *search.QueryParams)
 			CanDownload: false,
 		})
 	}
+	return canonical
+}
 
-	slog.Info("Legacy 查询完成",
-		slog.String("datasource", a.name),
-		slog.String("sql", querySQL),
-		slog.Any("sql_args", queryArgs),
-		slog.String("count_sql", countSQL),
-		slog.Any("count_args", countArgs),
-		slog.Any("request", params),
-		slog.Duration("elapsed", time.Since(start)),
-		slog.Int("records", len(canonical)),
-	)
+// GetBooks 按主键读取书籍，无法解析为整数的 ID 视为不存在。
+func (a *legacyAdapter) GetBooks(ctx context.Context, ids []string) ([]core.CanonicalBook, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("Legacy 数据源 %s 尚未初始化", a.name)
+	}
+	args := make([]any, 0, len(ids))
+	for _, raw := range ids {
+		if id, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64); err == nil {
+			args = append(args, id)
+		}
+	}
+	if len(args) == 0 {
+		return []core.CanonicalBook{}, nil
+	}
 
-	return canonical, total, nil
+	query := "SELECT b.* FROM books b WHERE b." + a.schema.idColumn + " IN (" + placeholders(len(args)) + ")"
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Legacy 查询失败: %w", err)
+	}
+	books, err := scanLegacyBooks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("Legacy 结果解析失败: %w", err)
+	}
+	return a.canonicalBooks(books), nil
 }
 
 func (a *legacyAdapter) GetBookFile(string) (string, error) {
@@ -201,6 +254,25 @@ func (a *legacyAdapter) GetBookCover(string) (string, error) {
 	return "", errors.New("该数据源不提供封面")
 }
 
+// Ping 检查数据库连接可用且 books 表可读。
+func (a *legacyAdapter) Ping(ctx context.Context) error {
+	if a.db == nil {
+		return fmt.Errorf("Legacy 数据源 %s 尚未初始化", a.name)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := a.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("Legacy 数据库连接不可用: %w", err)
+	}
+	var flag int
+	err := a.db.QueryRowContext(ctx, "SELECT 1 FROM books LIMIT 1").Scan(&flag)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("Legacy books 表不可读: %w", err)
+	}
+	return nil
+}
+
 func (a *legacyAdapter) Close() error {
 	if a.db != nil {
 		err := a.db.Close()
@@ -531,7 +603,8 @@ func tableColumns(db *sql.DB, table string) (map[string]struct{}, error) {
 }
 
 const (
-	legacyFTSCreateSQL = `CREATE VIRTUAL TABLE IF NOT EXISTS books_fts USING fts5(
+	defaultFTSTokenizer = "unicode61"
+	legacyFTSCreateSQL  = `CREATE VIRTUAL TABLE books_fts USING fts5(
 title,
 author,
 publisher,
@@ -539,9 +612,9 @@ publish_date,
 isbn,
 ss_code,
 dxid,
-tokenize='unicode61'
+tokenize='%s'
 )`
-	legacyFTSClearSQL    = `DELETE FROM books_fts`
+	legacyFTSDropSQL     = `DROP TABLE IF EXISTS books_fts`
 	legacyFTSPopulateSQL = `INSERT INTO books_fts(rowid, title, author, publisher, publish_date, isbn, ss_code, dxid)
 SELECT id,
    lower(COALESCE(title, '')),
@@ -554,7 +627,8 @@ SELECT id,
 FROM books`
 )
 
-func ensureLegacyFTS(db *sql.DB) error {
+// ensureLegacyFTS 在事务中删除并重建 books_fts，使分词器配置的变更在下次初始化时生效。
+func ensureLegacyFTS(db *sql.DB, tokenizer string) error {
 	exists, err := sqlitecfg.TableExists(db, "books")
 	if err != nil {
 		return fmt.Errorf("检查 Legacy books 表失败: %w", err)
@@ -562,17 +636,17 @@ func ensureLegacyFTS(db *sql.DB) error {
 	if !exists {
 		return nil
 	}
-	if _, err := db.Exec(legacyFTSCreateSQL); err != nil {
-		return fmt.Errorf("创建 Legacy FTS 表失败: %w", err)
-	}
 	tx, err := db.Begin()
 	if err != nil {
 		return fmt.Errorf("开启 Legacy FTS 事务失败: %w", err)
 	}
 	defer tx.Rollback()
-	if _, err := tx.Exec(legacyFTSClearSQL); err != nil {
+	if _, err := tx.Exec(legacyFTSDropSQL); err != nil {
 		return fmt.Errorf("清理 Legacy FTS 数据失败: %w", err)
 	}
+	if _, err := tx.Exec(fmt.Sprintf(legacyFTSCreateSQL, ftsTokenizer(tokenizer))); err != nil {
+		return fmt.Errorf("创建 Legacy FTS 表失败: %w", err)
+	}
 	if _, err := tx.Exec(legacyFTSPopulateSQL); err != nil {
 		return fmt.Errorf("重建 Legacy FTS 索引失败: %w", err)
 	}
@@ -582,12 +656,11 @@ func ensureLegacyFTS(db *sql.DB) error {
 	return nil
 }
 
-func splitLegacyAuthors(raw string) []string {
+func splitLegacyAuthors(raw string, separators []string) []string {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
 		return nil
 	}
-	separators := []string{";", ","}
 	for _, sep := range separators {
 		if strings.Contains(raw, sep) {
 			parts := strings.Split(raw, sep)
@@ -603,6 +676,25 @@ func splitLegacyAuthors(raw string) []string {
 	return []string{raw}
 }
 
+// parseAuthorSeparators 将 authorSeparators 配置中的每个字符视为一个作者分隔符。
+func parseAuthorSeparators(raw string) []string {
+	separators := make([]string, 0, len(raw))
+	for _, r := range raw {
+		if r == ' ' {
+			continue
+		}
+		separators = append(separators, string(r))
+	}
+	return separators
+}
+
+func ftsTokenizer(tokenizer string) string {
+	if tokenizer == "" {
+		return defaultFTSTokenizer
+	}
+	return tokenizer
+}
+
 func getString(value *string) string {
 	if value == nil {
 		return ""
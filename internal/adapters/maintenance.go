@@ -0,0 +1,115 @@
+// path: internal/adapters/maintenance.go
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ebookdatabase/internal/metrics"
+)
+
+const integrityCheckLimit = 100
+
+// Reindex 以可写方式打开 Legacy 数据库并重建 books_fts。
+// 使用外部维护的 book_search_fts 的真实库结构不支持重建。
+func (a *legacyAdapter) Reindex(ctx context.Context) error {
+	db, err := openWritable(ctx, a.path)
+	if err != nil {
+		return fmt.Errorf("打开 Legacy 数据库失败: %w", err)
+	}
+	defer db.Close()
+
+	schema, err := detectLegacySchema(db)
+	if err != nil {
+		return fmt.Errorf("Legacy 表结构识别失败: %w", err)
+	}
+	if !schema.rebuildFTS {
+		return fmt.Errorf("Legacy 数据源 %s 的全文索引由外部维护，不支持重建", a.name)
+	}
+	return timedFTSRebuild(a.name, func() error { return ensureLegacyFTS(db, a.tokenizer) })
+}
+
+// Reindex 以可写方式打开 Calibre 的 metadata.db 并重建 calibre_books_fts。
+func (a *calibreAdapter) Reindex(ctx context.Context) error {
+	db, err := openWritable(ctx, a.dbPath)
+	if err != nil {
+		return fmt.Errorf("打开 Calibre 数据库失败: %w", err)
+	}
+	defer db.Close()
+	return timedFTSRebuild(a.name, func() error { return ensureCalibreFTS(db, a.tokenizer) })
+}
+
+// CheckIntegrity 以只读方式对 Legacy 数据库执行 integrity_check。
+func (a *legacyAdapter) CheckIntegrity(ctx context.Context) ([]string, error) {
+	return checkIntegrity(ctx, a.path)
+}
+
+// CheckIntegrity 以只读方式对 Calibre 的 metadata.db 执行 integrity_check。
+func (a *calibreAdapter) CheckIntegrity(ctx context.Context) ([]string, error) {
+	return checkIntegrity(ctx, a.dbPath)
+}
+
+func checkIntegrity(ctx context.Context, path string) ([]string, error) {
+	db, err := openReadOnly(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA integrity_check(%d)", integrityCheckLimit))
+	if err != nil {
+		return nil, fmt.Errorf("执行完整性检查失败: %w", err)
+	}
+	defer rows.Close()
+
+	problems := make([]string, 0)
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("读取完整性检查结果失败: %w", err)
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取完整性检查结果失败: %w", err)
+	}
+	return problems, nil
+}
+
+// openWritable 以可写模式打开已存在的 SQLite 文件，文件不存在时直接报错。
+func openWritable(ctx context.Context, path string) (*sql.DB, error) {
+	if path == "" {
+		return nil, fmt.Errorf("未指定数据库路径")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=5000", filepath.ToSlash(path))
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// timedFTSRebuild 执行索引重建并在成功时记录耗时。
+func timedFTSRebuild(name string, rebuild func() error) error {
+	start := time.Now()
+	if err := rebuild(); err != nil {
+		return err
+	}
+	metrics.FTSRebuildDuration.Observe(time.Since(start).Seconds(), name)
+	return nil
+}
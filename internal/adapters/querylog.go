@@ -0,0 +1,49 @@
+// path: internal/adapters/querylog.go
+package adapters
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"ebookdatabase/config"
+)
+
+// queryLogSettings 是所有适配器共享的查询日志配置，热加载时整体替换。
+type queryLogSettings struct {
+	logQueries    bool
+	slowThreshold time.Duration
+}
+
+var queryLog atomic.Pointer[queryLogSettings]
+
+func init() {
+	ConfigureQueryLog(config.QueryLogConfig{})
+}
+
+// ConfigureQueryLog 更新适配器的逐条查询日志开关与慢查询阈值，阈值未配置时使用默认值。
+func ConfigureQueryLog(cfg config.QueryLogConfig) {
+	threshold := cfg.SlowQueryThreshold()
+	if threshold <= 0 {
+		threshold = config.DefaultSlowQueryThreshold
+	}
+	queryLog.Store(&queryLogSettings{logQueries: cfg.LogQueries, slowThreshold: threshold})
+}
+
+// logQueryDone 输出查询完成日志：耗时达到慢查询阈值时以 Warn 级别记录完整 SQL 与参数，
+// 否则仅在开启 logQueries 时以 Info 级别记录，其余情况降为 Debug，避免刷屏。
+func logQueryDone(ctx context.Context, kind string, elapsed time.Duration, attrs ...slog.Attr) {
+	settings := queryLog.Load()
+	attrs = append(attrs, slog.Duration("elapsed", elapsed))
+
+	switch {
+	case elapsed >= settings.slowThreshold:
+		attrs = append(attrs, slog.Duration("threshold", settings.slowThreshold))
+		slog.LogAttrs(ctx, slog.LevelWarn, kind+" 慢查询", attrs...)
+	case settings.logQueries:
+		slog.LogAttrs(ctx, slog.LevelInfo, kind+" 查询完成", attrs...)
+	default:
+		slog.LogAttrs(ctx, slog.LevelDebug, kind+" 查询完成", attrs...)
+	}
+}
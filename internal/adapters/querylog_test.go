@@ -0,0 +1,39 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"ebookdatabase/config"
+)
+
+func TestLogQueryDoneLevels(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	defer slog.SetDefault(previous)
+	defer ConfigureQueryLog(config.QueryLogConfig{})
+
+	ConfigureQueryLog(config.QueryLogConfig{SlowQueryMs: 50})
+	logQueryDone(context.Background(), "Legacy", 10*time.Millisecond, slog.String("sql", "SELECT fast"))
+	if logs.Len() != 0 {
+		t.Fatalf("expected fast query to be logged below Info, got %q", logs.String())
+	}
+
+	logQueryDone(context.Background(), "Legacy", 80*time.Millisecond, slog.String("sql", "SELECT slow"), slog.Any("sql_args", []any{"x"}))
+	if out := logs.String(); !strings.Contains(out, "level=WARN") || !strings.Contains(out, "Legacy 慢查询") ||
+		!strings.Contains(out, "SELECT slow") || !strings.Contains(out, "sql_args=[x]") {
+		t.Fatalf("expected slow query warning with SQL and args, got %q", out)
+	}
+
+	logs.Reset()
+	ConfigureQueryLog(config.QueryLogConfig{LogQueries: true, SlowQueryMs: 50})
+	logQueryDone(context.Background(), "Legacy", 10*time.Millisecond, slog.String("sql", "SELECT fast"))
+	if out := logs.String(); !strings.Contains(out, "level=INFO") || !strings.Contains(out, "Legacy 查询完成") {
+		t.Fatalf("expected Info log when logQueries is enabled, got %q", out)
+	}
+}
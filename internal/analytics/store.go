@@ -0,0 +1,336 @@
+// path: internal/analytics/store.go
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	// maxTermRunes 限制记录的关键字长度，过长的内容截断保存。
+	maxTermRunes = 100
+
+	queueSize     = 1024
+	batchSize     = 64
+	flushInterval = time.Second
+	pruneInterval = 6 * time.Hour
+)
+
+const schemaSQL = `
+CREATE TABLE IF NOT EXISTS search_events (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	occurred_at INTEGER NOT NULL,
+	field       TEXT    NOT NULL,
+	term        TEXT    NOT NULL,
+	fuzzy       INTEGER NOT NULL,
+	results     INTEGER NOT NULL,
+	latency_ms  INTEGER NOT NULL,
+	cached      INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_search_events_occurred_at ON search_events(occurred_at);
+CREATE INDEX IF NOT EXISTS idx_search_events_term ON search_events(field, term);
+`
+
+// Event 是一次搜索的统计信息，不包含客户端 IP、用户等可识别身份的内容。
+type Event struct {
+	Fields  []string
+	Terms   []string
+	Fuzzy   bool
+	Results int64
+	Latency time.Duration
+	Cached  bool
+}
+
+// QueryStat 是同一字段与关键字组合的聚合统计。
+type QueryStat struct {
+	Field        string    `json:"field"`
+	Term         string    `json:"term"`
+	Count        int64     `json:"count"`
+	AvgResults   float64   `json:"avgResults"`
+	AvgLatencyMs float64   `json:"avgLatencyMs"`
+	LastSeen     time.Time `json:"lastSeen"`
+}
+
+// Report 是指定时间范围内的搜索统计报表。
+type Report struct {
+	Since              time.Time   `json:"since"`
+	TotalSearches      int64       `json:"totalSearches"`
+	ZeroResultSearches int64       `json:"zeroResultSearches"`
+	AvgLatencyMs       float64     `json:"avgLatencyMs"`
+	TopQueries         []QueryStat `json:"topQueries"`
+	ZeroResultQueries  []QueryStat `json:"zeroResultQueries"`
+	// Dropped 为写入队列已满而丢弃的记录数（自进程启动起）。
+	Dropped uint64 `json:"dropped"`
+}
+
+// Store 把搜索统计异步写入本地 SQLite。Record 不会阻塞搜索请求，队列满时直接丢弃。
+type Store struct {
+	db        *sql.DB
+	events    chan record
+	flushes   chan chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	retention atomic.Int64
+	dropped   atomic.Uint64
+	now       func() time.Time
+}
+
+// record 是已匿名化、待写入的一条统计。
+type record struct {
+	occurredAt int64
+	field      string
+	term       string
+	fuzzy      bool
+	results    int64
+	latencyMs  int64
+	cached     bool
+}
+
+// Open 打开（必要时创建）统计数据库并启动后台写入协程。retention 为 0 时不清理历史记录。
+func Open(path string, retention time.Duration) (*Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("未指定统计数据库路径")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("创建统计数据库目录失败: %w", err)
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", filepath.ToSlash(path))
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开统计数据库失败: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schemaSQL); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化统计数据库失败: %w", err)
+	}
+
+	s := &Store{
+		db:      db,
+		events:  make(chan record, queueSize),
+		flushes: make(chan chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		now:     time.Now,
+	}
+	s.SetRetention(retention)
+	go s.run()
+	return s, nil
+}
+
+// SetRetention 更新统计记录的保留时长，下次清理时生效。
+func (s *Store) SetRetention(retention time.Duration) {
+	s.retention.Store(int64(retention))
+}
+
+// Record 匿名化并排队写入一条统计：关键字统一为小写并合并空白，时间只精确到小时。
+func (s *Store) Record(event Event) {
+	if s == nil || len(event.Terms) == 0 {
+		return
+	}
+	rec := record{
+		occurredAt: s.now().UTC().Truncate(time.Hour).Unix(),
+		field:      strings.Join(event.Fields, "+"),
+		term:       normalizeTerms(event.Terms),
+		fuzzy:      event.Fuzzy,
+		results:    event.Results,
+		latencyMs:  event.Latency.Milliseconds(),
+		cached:     event.Cached,
+	}
+	if rec.term == "" {
+		return
+	}
+	select {
+	case s.events <- rec:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Flush 等待已排队的统计全部写入，主要用于测试与关闭前。
+func (s *Store) Flush() {
+	if s == nil {
+		return
+	}
+	ack := make(chan struct{})
+	select {
+	case s.flushes <- ack:
+		<-ack
+	case <-s.done:
+	}
+}
+
+// Close 写入剩余统计并关闭数据库。
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+		err = s.db.Close()
+	})
+	return err
+}
+
+func (s *Store) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	pruneTicker := time.NewTicker(pruneInterval)
+	defer pruneTicker.Stop()
+
+	s.prune()
+	batch := make([]record, 0, batchSize)
+	for {
+		select {
+		case rec := <-s.events:
+			batch = append(batch, rec)
+			if len(batch) >= batchSize {
+				batch = s.write(batch)
+			}
+		case <-ticker.C:
+			batch = s.write(batch)
+		case <-pruneTicker.C:
+			s.prune()
+		case ack := <-s.flushes:
+			batch = s.write(s.drain(batch))
+			close(ack)
+		case <-s.stop:
+			s.write(s.drain(batch))
+			return
+		}
+	}
+}
+
+// drain 取出队列中已有的全部记录。
+func (s *Store) drain(batch []record) []record {
+	for {
+		select {
+		case rec := <-s.events:
+			batch = append(batch, rec)
+		default:
+			return batch
+		}
+	}
+}
+
+// write 在单个事务中写入一批记录并返回清空后的切片。写入失败只记录日志，不影响搜索。
+func (s *Store) write(batch []record) []record {
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := s.insert(batch); err != nil {
+		slog.Warn("写入搜索统计失败", slog.Int("records", len(batch)), slog.String("error", err.Error()))
+	}
+	return batch[:0]
+}
+
+func (s *Store) insert(batch []record) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO search_events
+		(occurred_at, field, term, fuzzy, results, latency_ms, cached) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, rec := range batch {
+		if _, err := stmt.Exec(rec.occurredAt, rec.field, rec.term, rec.fuzzy, rec.results, rec.latencyMs, rec.cached); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) prune() {
+	retention := time.Duration(s.retention.Load())
+	if retention <= 0 {
+		return
+	}
+	cutoff := s.now().Add(-retention).Unix()
+	if _, err := s.db.Exec("DELETE FROM search_events WHERE occurred_at < ?", cutoff); err != nil {
+		slog.Warn("清理过期搜索统计失败", slog.String("error", err.Error()))
+	}
+}
+
+// Report 汇总 since 之后的搜索统计，返回最常见的查询与无结果查询各至多 limit 条。
+func (s *Store) Report(ctx context.Context, since time.Time, limit int) (Report, error) {
+	report := Report{Since: since, Dropped: s.dropped.Load()}
+	sinceUnix := since.UTC().Truncate(time.Hour).Unix()
+
+	var avgLatency sql.NullFloat64
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*), COALESCE(SUM(results = 0), 0), AVG(latency_ms)
+		FROM search_events WHERE occurred_at >= ?`, sinceUnix).
+		Scan(&report.TotalSearches, &report.ZeroResultSearches, &avgLatency); err != nil {
+		return report, fmt.Errorf("统计搜索次数失败: %w", err)
+	}
+	report.AvgLatencyMs = avgLatency.Float64
+
+	var err error
+	if report.TopQueries, err = s.queryStats(ctx, "", sinceUnix, limit); err != nil {
+		return report, fmt.Errorf("统计热门查询失败: %w", err)
+	}
+	if report.ZeroResultQueries, err = s.queryStats(ctx, "AND results = 0", sinceUnix, limit); err != nil {
+		return report, fmt.Errorf("统计无结果查询失败: %w", err)
+	}
+	return report, nil
+}
+
+func (s *Store) queryStats(ctx context.Context, filter string, since int64, limit int) ([]QueryStat, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT field, term, COUNT(*), AVG(results), AVG(latency_ms), MAX(occurred_at)
+		FROM search_events WHERE occurred_at >= ? `+filter+`
+		GROUP BY field, term ORDER BY COUNT(*) DESC, MAX(occurred_at) DESC, term LIMIT ?`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make([]QueryStat, 0)
+	for rows.Next() {
+		var stat QueryStat
+		var lastSeen int64
+		if err := rows.Scan(&stat.Field, &stat.Term, &stat.Count, &stat.AvgResults, &stat.AvgLatencyMs, &lastSeen); err != nil {
+			return nil, err
+		}
+		stat.LastSeen = time.Unix(lastSeen, 0).UTC()
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
+
+// normalizeTerms 把关键字统一为小写、合并连续空白并截断，多个关键字以 " | " 连接。
+func normalizeTerms(terms []string) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		normalized := strings.Join(strings.Fields(strings.ToLower(term)), " ")
+		if utf8.RuneCountInString(normalized) > maxTermRunes {
+			normalized = string([]rune(normalized)[:maxTermRunes])
+		}
+		if normalized != "" {
+			parts = append(parts, normalized)
+		}
+	}
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,74 @@
+package analytics
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreReportsTopAndZeroResultQueries(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "nested", "analytics.db"), 0)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer store.Close()
+
+	for i := 0; i < 3; i++ {
+		store.Record(Event{Fields: []string{"title"}, Terms: []string{"  Go   Programming "}, Results: 4, Latency: 20 * time.Millisecond})
+	}
+	store.Record(Event{Fields: []string{"title"}, Terms: []string{"go programming"}, Results: 2, Latency: 40 * time.Millisecond, Cached: true})
+	store.Record(Event{Fields: []string{"author"}, Terms: []string{"Nobody"}, Results: 0, Latency: 10 * time.Millisecond})
+	store.Record(Event{Fields: []string{"title", "author"}, Terms: []string{"Rust", "Ann"}, Results: 0})
+	store.Record(Event{Fields: []string{"title"}, Terms: []string{"   "}})
+	store.Flush()
+
+	report, err := store.Report(context.Background(), time.Now().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatalf("Report returned error: %v", err)
+	}
+	if report.TotalSearches != 6 || report.ZeroResultSearches != 2 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+	if len(report.TopQueries) != 3 {
+		t.Fatalf("expected 3 distinct queries, got %+v", report.TopQueries)
+	}
+	top := report.TopQueries[0]
+	if top.Field != "title" || top.Term != "go programming" || top.Count != 4 || top.AvgResults != 3.5 {
+		t.Fatalf("unexpected top query: %+v", top)
+	}
+	if len(report.ZeroResultQueries) != 2 {
+		t.Fatalf("expected 2 zero-result queries, got %+v", report.ZeroResultQueries)
+	}
+	for _, stat := range report.ZeroResultQueries {
+		if stat.Term != "nobody" && !(stat.Field == "title+author" && stat.Term == "rust | ann") {
+			t.Fatalf("unexpected zero-result query: %+v", stat)
+		}
+	}
+}
+
+func TestStorePrunesRecordsOlderThanRetention(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "analytics.db"), 0)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	store.now = func() time.Time { return now.Add(-72 * time.Hour) }
+	store.Record(Event{Fields: []string{"title"}, Terms: []string{"old"}})
+	store.now = func() time.Time { return now }
+	store.Record(Event{Fields: []string{"title"}, Terms: []string{"new"}})
+	store.Flush()
+
+	store.SetRetention(24 * time.Hour)
+	store.prune()
+
+	report, err := store.Report(context.Background(), now.Add(-30*24*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("Report returned error: %v", err)
+	}
+	if report.TotalSearches != 1 || report.TopQueries[0].Term != "new" {
+		t.Fatalf("expected only the recent record to remain, got %+v", report)
+	}
+}
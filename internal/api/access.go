@@ -0,0 +1,151 @@
+// path: internal/api/access.go
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/config"
+	"ebookdatabase/internal/auth"
+	"ebookdatabase/internal/core"
+)
+
+// accessScope 区分受 auth.accessPolicy 约束的前台接口。
+type accessScope int
+
+const (
+	// accessBrowse 覆盖搜索、数据源列表与封面，仅在 loginRequired 下需要登录。
+	accessBrowse accessScope = iota
+	// accessDownload 覆盖下载，在 loginForDownloads 与 loginRequired 下需要登录。
+	accessDownload
+)
+
+// accessTokenQuery 是 <a>/<img> 等无法附带请求头的场景传递令牌的查询参数，仅对 GET 请求生效。
+const accessTokenQuery = "token"
+
+func (scope accessScope) requiresLogin(cfg config.AuthConfig) bool {
+	if scope == accessDownload {
+		return cfg.DownloadRequiresLogin()
+	}
+	return cfg.BrowseRequiresLogin()
+}
+
+// requireAccess 按当前的访问策略校验前台接口，策略修改后无需重启即可生效。需要登录时任意角色均可访问，
+// 未登录返回 401 并附带 loginRequired 以便前端跳转登录。公开访问时也会识别携带的令牌，
+// 供数据源访问控制判断调用者身份，无效令牌按匿名处理。
+func (s *Server) requireAccess(scope accessScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if requestPrincipal(c) == nil {
+			tokenString := requestCredential(c)
+			if tokenString == "" && c.Request.Method == http.MethodGet {
+				tokenString = strings.TrimSpace(c.Query(accessTokenQuery))
+			}
+			if p, ok := s.authenticateToken(c, tokenString); ok {
+				c.Set(principalKey, p)
+			}
+		}
+
+		if requestPrincipal(c) == nil && scope.requiresLogin(s.currentConfig().Auth) {
+			abortLoginRequired(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+func abortLoginRequired(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "请先登录", "loginRequired": true})
+}
+
+// canAccessSource 按数据源的 access 规则判断调用者能否检索（download 为 false）或下载，p 为空表示匿名访问。
+// search 范围的 API Key 始终不能下载。
+func canAccessSource(p *principal, access *config.DatasourceAccess, download bool) bool {
+	if download && p != nil && p.APIKey != nil && !p.APIKey.Scope.AllowsDownload() {
+		return false
+	}
+	if access == nil || p != nil && p.Role == auth.RoleAdmin {
+		return true
+	}
+	if !ruleAllows(p, access.Search) {
+		return false
+	}
+	return !download || ruleAllows(p, access.Download)
+}
+
+func ruleAllows(p *principal, rule config.AccessRule) bool {
+	if !rule.Restricted() {
+		return true
+	}
+	if p == nil {
+		return false
+	}
+	for _, name := range rule.Roles {
+		if role, err := auth.ParseRole(name); err == nil && p.Role.Allows(role) {
+			return true
+		}
+	}
+	if p.Shared || p.APIKey != nil {
+		return false
+	}
+	for _, username := range rule.Users {
+		if strings.EqualFold(username, p.Username) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchableSources 返回当前调用者可以检索的已注册数据源，顺序与 ListSources 一致。
+func (s *Server) searchableSources(c *gin.Context) []string {
+	p := requestPrincipal(c)
+	names := s.resolveSources()
+	visible := make([]string, 0, len(names))
+	for _, name := range names {
+		dsConfig, _ := s.dbManager.DatasourceConfig(name)
+		if canAccessSource(p, dsConfig.Access, false) {
+			visible = append(visible, name)
+		}
+	}
+	return visible
+}
+
+// authorizeSource 校验调用者对单个数据源的访问权限，失败时写入响应并返回 false。
+// 无权检索的数据源按不存在处理，避免泄露私有书库的名称。
+func (s *Server) authorizeSource(c *gin.Context, name string, download bool) bool {
+	dsConfig, ok := s.dbManager.DatasourceConfig(name)
+	p := requestPrincipal(c)
+	if !ok || !canAccessSource(p, dsConfig.Access, false) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "数据源不存在"})
+		return false
+	}
+	if download && !canAccessSource(p, dsConfig.Access, true) {
+		if p == nil {
+			abortLoginRequired(c)
+		} else {
+			c.JSON(http.StatusForbidden, gin.H{"error": "没有下载该数据源的权限"})
+		}
+		return false
+	}
+	return true
+}
+
+// restrictDownloads 将调用者无权下载的书目标记为不可下载，books 须为独立副本。
+func (s *Server) restrictDownloads(c *gin.Context, books []core.CanonicalBook) {
+	p := requestPrincipal(c)
+	allowed := make(map[string]bool)
+	for i := range books {
+		if !books[i].CanDownload {
+			continue
+		}
+		source := books[i].Source
+		ok, seen := allowed[source]
+		if !seen {
+			dsConfig, _ := s.dbManager.DatasourceConfig(source)
+			ok = canAccessSource(p, dsConfig.Access, true)
+			allowed[source] = ok
+		}
+		books[i].CanDownload = ok
+	}
+}
@@ -0,0 +1,114 @@
+// path: internal/api/admin_analytics.go
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/config"
+	"ebookdatabase/internal/analytics"
+	"ebookdatabase/search"
+)
+
+const (
+	defaultAnalyticsDays  = 7
+	maxAnalyticsDays      = 366
+	defaultAnalyticsLimit = 20
+	maxAnalyticsLimit     = 200
+)
+
+// configureAnalyticsLocked 在配置启用搜索统计时打开 instanceDir 下的 analytics.db 并更新保留天数，
+// 热加载或后台保存启用统计后无需重启。打开失败时只记录错误，下次应用配置时重试；停用统计时保持数据库打开，
+// 仅停止记录与查询。调用方需持有 configMu 写锁。
+func (s *Server) configureAnalyticsLocked(cfg *config.Config) {
+	if s.analytics == nil && cfg.Analytics.IsEnabled() {
+		store, err := analytics.Open(cfg.InstancePath("analytics.db"), cfg.Analytics.Retention())
+		if err != nil {
+			slog.Error("打开搜索统计库失败，暂不记录搜索统计", slog.String("error", err.Error()))
+			return
+		}
+		s.analytics = store
+	}
+	if s.analytics != nil {
+		s.analytics.SetRetention(cfg.Analytics.Retention())
+	}
+}
+
+func (s *Server) analyticsStore() *analytics.Store {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	if s.analytics == nil || !s.config.Analytics.IsEnabled() {
+		return nil
+	}
+	return s.analytics
+}
+
+// recordSearch 记录一次成功的搜索。只统计第一页，翻页不会重复计数。
+func (s *Server) recordSearch(params *search.QueryParams, total int64, elapsed time.Duration, cached bool) {
+	store := s.analyticsStore()
+	if store == nil || params.Page > 1 {
+		return
+	}
+	fuzzy := false
+	for _, value := range params.Fuzzies {
+		if value != nil && *value {
+			fuzzy = true
+			break
+		}
+	}
+	store.Record(analytics.Event{
+		Fields:  params.Fields,
+		Terms:   params.Queries,
+		Fuzzy:   fuzzy,
+		Results: total,
+		Latency: elapsed,
+		Cached:  cached,
+	})
+}
+
+// handleSearchAnalytics 返回最近 days 天（默认 7 天）的热门查询与无结果查询。
+func (s *Server) handleSearchAnalytics(c *gin.Context) {
+	store := s.analyticsStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "搜索统计未启用"})
+		return
+	}
+
+	days, err := boundedQueryInt(c, "days", defaultAnalyticsDays, maxAnalyticsDays)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, err := boundedQueryInt(c, "limit", defaultAnalyticsLimit, maxAnalyticsLimit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	since := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+	report, err := store.Report(c.Request.Context(), since, limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "读取搜索统计失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取搜索统计失败"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// boundedQueryInt 解析 1 到 upper 之间的整数查询参数，缺省时返回 fallback。
+func boundedQueryInt(c *gin.Context, key string, fallback, upper int) (int, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 || value > upper {
+		return 0, fmt.Errorf("参数 %s 必须为 1 到 %d 之间的整数", key, upper)
+	}
+	return value, nil
+}
@@ -0,0 +1,92 @@
+// path: internal/api/admin_apikeys.go
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/internal/auth"
+)
+
+// apiKeyHeader 是脚本传递 API Key 的请求头，也可以使用 Authorization: Bearer。
+const apiKeyHeader = "X-API-Key"
+
+// authenticateAPIKey 校验 API Key，调用者的角色由密钥范围决定。
+func (s *Server) authenticateAPIKey(c *gin.Context, key string) (*principal, bool) {
+	apiKey, err := s.auth.AuthenticateAPIKey(key)
+	if err != nil {
+		if !errors.Is(err, auth.ErrInvalidAPIKey) {
+			slog.ErrorContext(c.Request.Context(), "校验 API Key 失败", slog.String("error", err.Error()))
+		}
+		return nil, false
+	}
+	return &principal{Username: apiKey.Name, Role: apiKey.Scope.Role(), APIKey: &apiKey}, true
+}
+
+func (s *Server) handleListAPIKeys(c *gin.Context) {
+	keys, err := s.auth.ListAPIKeys()
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "读取 API Key 列表失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取 API Key 列表失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"apiKeys": keys, "scopes": auth.APIKeyScopes()})
+}
+
+// handleCreateAPIKey 新建 API Key，完整密钥只在本次响应中返回。
+func (s *Server) handleCreateAPIKey(c *gin.Context) {
+	var payload struct {
+		Name  string `json:"name"`
+		Scope string `json:"scope"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体格式错误"})
+		return
+	}
+	scope, err := auth.ParseAPIKeyScope(payload.Scope)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	createdBy := ""
+	if p := requestPrincipal(c); p != nil {
+		createdBy = p.Username
+	}
+	apiKey, key, err := s.auth.CreateAPIKey(payload.Name, scope, createdBy)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidAPIKeyRequest) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "新建 API Key 失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "新建 API Key 失败"})
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "已新建 API Key", slog.String("id", apiKey.ID),
+		slog.String("name", apiKey.Name), slog.String("scope", string(apiKey.Scope)))
+	s.recordAudit(c, auditAPIKeyCreate, apiKey.ID, gin.H{"name": apiKey.Name, "scope": apiKey.Scope})
+	c.JSON(http.StatusCreated, gin.H{"apiKey": apiKey, "key": key})
+}
+
+func (s *Server) handleRevokeAPIKey(c *gin.Context) {
+	id := c.Param("id")
+	if err := s.auth.RevokeAPIKey(id); err != nil {
+		if errors.Is(err, auth.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "吊销 API Key 失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "吊销 API Key 失败"})
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "已吊销 API Key", slog.String("id", id))
+	if err := s.shelves.DeleteOwner(c.Request.Context(), apiKeySubject(id)); err != nil {
+		slog.ErrorContext(c.Request.Context(), "删除 API Key 书架失败", slog.String("id", id), slog.String("error", err.Error()))
+	}
+	s.recordAudit(c, auditAPIKeyRevoke, id, nil)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,117 @@
+// path: internal/api/admin_audit.go
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/internal/audit"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+	// maxAuditPage 限制 OFFSET 的规模，更早的记录可通过 since 或 action 缩小范围后查询。
+	maxAuditPage = 10000
+)
+
+// 审计动作名称按“对象.操作”组织，查询时可用 "user." 之类的前缀筛选。
+const (
+	auditLoginSuccess      = "login.success"
+	auditLoginFailure      = "login.failure"
+	auditConfigUpdate      = "config.update"
+	auditConfigRollback    = "config.rollback"
+	auditLoggingUpdate     = "logging.update"
+	auditDatasourceCreate  = "datasource.create"
+	auditDatasourceUpdate  = "datasource.update"
+	auditDatasourceDelete  = "datasource.delete"
+	auditDatasourceReindex = "datasource.reindex"
+	auditCacheFlush        = "cache.flush"
+	auditUserCreate        = "user.create"
+	auditUserUpdate        = "user.update"
+	auditUserDelete        = "user.delete"
+	auditAPIKeyCreate      = "apikey.create"
+	auditAPIKeyRevoke      = "apikey.revoke"
+	auditSigningKeyRotate  = "auth.rotate-key"
+)
+
+// recordAudit 以当前调用者的身份追加一条审计记录。写入失败只记录错误日志，不影响已经完成的操作。
+func (s *Server) recordAudit(c *gin.Context, action, target string, details any) {
+	entry := audit.Entry{ClientIP: c.ClientIP(), Action: action, Target: target}
+	if p := requestPrincipal(c); p != nil {
+		entry.Actor, entry.Role = p.Username, string(p.Role)
+		if p.APIKey != nil {
+			entry.APIKeyID = p.APIKey.ID
+		}
+	}
+	s.appendAudit(c, entry, details)
+}
+
+func (s *Server) appendAudit(c *gin.Context, entry audit.Entry, details any) {
+	if err := s.audit.Record(c.Request.Context(), entry, details); err != nil {
+		slog.ErrorContext(c.Request.Context(), "写入审计日志失败",
+			slog.String("action", entry.Action),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// auditChanges 返回 before 与 after 之间的差异，作为审计记录的 details。
+func auditChanges(c *gin.Context, before, after any) any {
+	changes, err := audit.Diff(before, after)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "计算配置差异失败", slog.String("error", err.Error()))
+		return nil
+	}
+	return gin.H{"changes": changes}
+}
+
+// auditValue 返回脱敏后的 value，作为新增或删除对象时审计记录的 details。
+func auditValue(c *gin.Context, key string, value any) any {
+	redacted, err := audit.Redact(value)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "序列化审计详情失败", slog.String("error", err.Error()))
+		return nil
+	}
+	return gin.H{key: redacted}
+}
+
+// handleListAudit 按时间倒序分页返回审计记录，支持 action（以 "." 结尾时按前缀匹配）、actor 与 since（RFC 3339）筛选。
+func (s *Server) handleListAudit(c *gin.Context) {
+	page, err := boundedQueryInt(c, "page", 1, maxAuditPage)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	pageSize, err := boundedQueryInt(c, "pageSize", defaultAuditPageSize, maxAuditPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query := audit.Query{
+		Action:   c.Query("action"),
+		Actor:    c.Query("actor"),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	if raw := strings.TrimSpace(c.Query("since")); raw != "" {
+		since, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "参数 since 必须为 RFC 3339 格式的时间"})
+			return
+		}
+		query.Since = since
+	}
+
+	result, err := s.audit.List(c.Request.Context(), query)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "读取审计日志失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取审计日志失败"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
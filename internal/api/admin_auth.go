@@ -0,0 +1,207 @@
+// path: internal/api/admin_auth.go
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/internal/auth"
+)
+
+// principalKey 是 RequireRole 在 gin.Context 中保存当前用户的键。
+const principalKey = "authPrincipal"
+
+// principal 是通过令牌或 API Key 校验的调用者，角色以用户存储中的当前值为准，降级或停用立即生效。
+// 使用 API Key 时 Username 为密钥名称，角色由密钥范围决定，claims 为空。
+type principal struct {
+	Username string       `json:"username"`
+	Role     auth.Role    `json:"role"`
+	Shared   bool         `json:"shared"`
+	APIKey   *auth.APIKey `json:"apiKey,omitempty"`
+	claims   *auth.Claims
+}
+
+// subject 返回调用者的稳定标识，用于限流与书架归属：用户账户与共享管理员沿用令牌的 subject，API Key 按密钥 ID 区分。
+func (p *principal) subject() string {
+	switch {
+	case p.APIKey != nil:
+		return apiKeySubject(p.APIKey.ID)
+	case p.Shared:
+		return auth.SharedAdminSubject
+	default:
+		return auth.UserSubject(p.Username)
+	}
+}
+
+func apiKeySubject(id string) string {
+	return "key:" + id
+}
+
+func requestPrincipal(c *gin.Context) *principal {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil
+	}
+	p, _ := value.(*principal)
+	return p
+}
+
+// RequireRole 校验 Bearer 令牌或 API Key 并要求调用者至少具备 required 角色：未登录返回 401，权限不足返回 403。
+func (s *Server) RequireRole(required auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := requestPrincipal(c)
+		if p == nil {
+			var ok bool
+			if p, ok = s.authenticate(c); !ok {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+				return
+			}
+			c.Set(principalKey, p)
+		}
+		if !p.Role.Allows(required) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "权限不足"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func (s *Server) authenticate(c *gin.Context) (*principal, bool) {
+	return s.authenticateToken(c, requestCredential(c))
+}
+
+// requestCredential 依次从 Authorization: Bearer 与 X-API-Key 请求头中读取凭证。
+func requestCredential(c *gin.Context) string {
+	if tokenString, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); found {
+		return strings.TrimSpace(tokenString)
+	}
+	return strings.TrimSpace(c.GetHeader(apiKeyHeader))
+}
+
+func (s *Server) authenticateToken(c *gin.Context, tokenString string) (*principal, bool) {
+	if tokenString == "" {
+		return nil, false
+	}
+	if auth.IsAPIKey(tokenString) {
+		p, ok := s.authenticateAPIKey(c, tokenString)
+		// metrics 范围的 API Key 只用于 /metrics，在其他接口上按无效凭证处理。
+		if !ok || p.APIKey.Scope == auth.ScopeMetrics {
+			return nil, false
+		}
+		return p, true
+	}
+
+	claims, err := s.auth.ParseToken(tokenString)
+	if err != nil {
+		return nil, false
+	}
+
+	if username, ok := auth.UsernameFromSubject(claims.Subject); ok {
+		user, err := s.auth.GetUser(username)
+		if err != nil {
+			if !errors.Is(err, auth.ErrUserNotFound) {
+				slog.ErrorContext(c.Request.Context(), "读取用户失败", slog.String("error", err.Error()))
+			}
+			return nil, false
+		}
+		// 删除后同名重建、修改密码、停用后再启用的用户，此前签发的令牌代次不一致而失效。
+		if user.Disabled || claims.Generation != auth.UserTokenGeneration(user) {
+			return nil, false
+		}
+		return &principal{Username: user.Username, Role: user.Role, claims: claims}, true
+	}
+
+	// 使用 adminPassword 签发的令牌在密码被修改或清空后失效。
+	if password := s.currentConfig().AdminPassword; claims.Subject == auth.SharedAdminSubject && password != "" &&
+		claims.Generation == s.auth.SharedAdminGeneration() {
+		return &principal{Username: auth.SharedAdminSubject, Role: auth.RoleAdmin, Shared: true, claims: claims}, true
+	}
+	return nil, false
+}
+
+// credentialGeneration 返回 subject 当前的凭证代次，签发令牌时写入，校验令牌时比对。
+func (s *Server) credentialGeneration(subject string) (string, error) {
+	username, ok := auth.UsernameFromSubject(subject)
+	if !ok {
+		return s.auth.SharedAdminGeneration(), nil
+	}
+	user, err := s.auth.GetUser(username)
+	if err != nil {
+		return "", err
+	}
+	return auth.UserTokenGeneration(user), nil
+}
+
+// issueToken 按当前配置的有效期为 subject 签发携带其凭证代次的令牌。
+func (s *Server) issueToken(subject string) (string, *auth.Claims, error) {
+	generation, err := s.credentialGeneration(subject)
+	if err != nil {
+		return "", nil, err
+	}
+	return s.auth.IssueToken(subject, generation, s.currentConfig().Auth.TokenTTL())
+}
+
+// handleMe 返回当前登录者的用户名与角色。
+func (s *Server) handleMe(c *gin.Context) {
+	c.JSON(http.StatusOK, requestPrincipal(c))
+}
+
+// handleLogout 吊销当前请求使用的令牌，之后该令牌无法再访问后台接口。
+func (s *Server) handleLogout(c *gin.Context) {
+	p := requestPrincipal(c)
+	if p == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+	if p.claims == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "API Key 需要通过后台吊销"})
+		return
+	}
+	if err := s.auth.Revoke(p.claims.ID, p.claims.ExpiresAt.Time); err != nil {
+		slog.ErrorContext(c.Request.Context(), "吊销令牌失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "退出登录失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revoked": true})
+}
+
+// handleRotateSigningKey 生成新的签名密钥，此前签发的所有令牌立即失效，并为调用者签发新令牌。
+func (s *Server) handleRotateSigningKey(c *gin.Context) {
+	keyID, createdAt, err := s.auth.RotateSigningKey()
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "轮换签名密钥失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "轮换签名密钥失败"})
+		return
+	}
+
+	slog.WarnContext(c.Request.Context(), "签名密钥已轮换，旧令牌全部失效", slog.String("key_id", keyID))
+	s.recordAudit(c, auditSigningKeyRotate, keyID, nil)
+
+	// 通过 API Key 调用时不受轮换影响，无需签发新令牌。
+	p := requestPrincipal(c)
+	if p != nil && p.claims == nil {
+		c.JSON(http.StatusOK, gin.H{"keyId": keyID, "createdAt": createdAt})
+		return
+	}
+	subject := auth.SharedAdminSubject
+	if p != nil {
+		subject = p.claims.Subject
+	}
+	signed, claims, err := s.issueToken(subject)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "生成 JWT 失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成凭证失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keyId":     keyID,
+		"createdAt": createdAt,
+		"token":     signed,
+		"expiresAt": claims.ExpiresAt.Time,
+	})
+}
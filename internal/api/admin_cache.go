@@ -0,0 +1,22 @@
+// path: internal/api/admin_cache.go
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleCacheStats 返回搜索缓存的规模与命中、未命中、淘汰和过期计数。
+func (s *Server) handleCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, s.cache.Stats())
+}
+
+// handleFlushCache 清空搜索缓存，用于在服务外部修改或重建数据库后立即生效。
+func (s *Server) handleFlushCache(c *gin.Context) {
+	flushed := s.cache.Purge()
+	slog.InfoContext(c.Request.Context(), "搜索缓存已清空", slog.Int("entries", flushed))
+	s.recordAudit(c, auditCacheFlush, "", gin.H{"entries": flushed})
+	c.JSON(http.StatusOK, gin.H{"flushed": flushed})
+}
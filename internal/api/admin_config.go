@@ -0,0 +1,122 @@
+// path: internal/api/admin_config.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/config"
+)
+
+// errDatasourceInit 表示新配置中的数据源无法初始化，此时设置文件与运行状态均未改变。
+var errDatasourceInit = errors.New("数据源初始化失败")
+
+func (s *Server) handleListConfigVersions(c *gin.Context) {
+	versions, err := config.ListVersions(s.configPath)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "读取配置历史失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取配置历史失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": versions})
+}
+
+func (s *Server) handleRollbackConfig(c *gin.Context) {
+	var payload struct {
+		Version string `json:"version"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil || strings.TrimSpace(payload.Version) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少要回滚的版本"})
+		return
+	}
+
+	data, err := config.ReadVersion(s.configPath, strings.TrimSpace(payload.Version))
+	if err != nil {
+		if errors.Is(err, config.ErrVersionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取历史版本失败"})
+		return
+	}
+
+	data, err = hashVersionPassword(data)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("历史版本无法解析: %s", err.Error())})
+		return
+	}
+	cfg, err := config.ParseConfig(data)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("历史版本无法解析: %s", err.Error())})
+		return
+	}
+
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	previous, _ := s.readSettings()
+	if err := s.applySettings(data, cfg); err != nil {
+		s.respondApplyError(c, err)
+		return
+	}
+	s.recordAudit(c, auditConfigRollback, strings.TrimSpace(payload.Version), auditChanges(c, previous, data))
+
+	latest, err := s.readSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	config.RedactSecrets(latest)
+	c.JSON(http.StatusOK, latest)
+}
+
+// hashVersionPassword 把历史版本中明文的 adminPassword 转为 bcrypt 哈希，避免回滚时把旧的明文密码写回设置文件。
+// 不含明文密码时原样返回 data。
+func hashVersionPassword(data []byte) ([]byte, error) {
+	var settings map[string]any
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	if password, _ := settings["adminPassword"].(string); password == "" || config.IsBcryptHash(password) {
+		return data, nil
+	}
+	if err := config.HashAdminPassword(settings); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(settings, "", "  ")
+}
+
+// applySettings 先按新配置增量调整数据源，成功后再写入设置文件并切换运行配置。
+// 写入失败时会按旧配置恢复数据源。调用方需持有 settingsMu。
+func (s *Server) applySettings(data []byte, cfg *config.Config) error {
+	previous := s.currentConfig()
+
+	if err := s.dbManager.ApplyDatasources(cfg.Datasources); err != nil {
+		slog.Error("重新初始化数据源失败", slog.String("error", err.Error()))
+		return fmt.Errorf("%w: %w", errDatasourceInit, err)
+	}
+
+	if err := s.writeSettings(data); err != nil {
+		if restoreErr := s.dbManager.ApplyDatasources(previous.Datasources); restoreErr != nil {
+			slog.Error("恢复数据源失败", slog.String("error", restoreErr.Error()))
+		}
+		return err
+	}
+
+	s.setConfig(cfg)
+	return nil
+}
+
+func (s *Server) respondApplyError(c *gin.Context, err error) {
+	if errors.Is(err, errDatasourceInit) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "写入配置文件失败"})
+}
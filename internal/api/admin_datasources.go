@@ -0,0 +1,335 @@
+// path: internal/api/admin_datasources.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/config"
+	"ebookdatabase/internal/auth"
+	"ebookdatabase/internal/infra"
+)
+
+const (
+	datasourceTestTimeout = 15 * time.Second
+	// datasourceReindexTimeout 与命令行 reindex 一致，大型书库重建索引可能需要较长时间。
+	datasourceReindexTimeout = 30 * time.Minute
+)
+
+// datasourceView 是后台接口返回的单个数据源信息：配置本身与运行时状态。
+type datasourceView struct {
+	config.DatasourceConfig
+	Registered bool                `json:"registered"`
+	Health     *infra.HealthStatus `json:"health,omitempty"`
+}
+
+// handleListDatasources 列出调用者可以检索的数据源，librarian 看不到 access 规则不允许其检索的私有数据源。
+func (s *Server) handleListDatasources(c *gin.Context) {
+	p := requestPrincipal(c)
+	items := s.currentConfig().Datasources
+	views := make([]datasourceView, 0, len(items))
+	for _, item := range items {
+		if canAccessSource(p, item.Access, false) {
+			views = append(views, s.datasourceView(item))
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"datasources": views})
+}
+
+func (s *Server) handleGetDatasource(c *gin.Context) {
+	item, ok := s.findManagedDatasource(c, c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "数据源不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, s.datasourceView(item))
+}
+
+func (s *Server) handleCreateDatasource(c *gin.Context) {
+	var payload config.DatasourceConfig
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体格式错误"})
+		return
+	}
+
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	name := strings.TrimSpace(payload.Name)
+	if _, exists := findDatasource(s.currentConfig().Datasources, name); exists {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("数据源 %s 已存在", name)})
+		return
+	}
+
+	items := append(append([]config.DatasourceConfig(nil), s.currentConfig().Datasources...), payload)
+	data, cfg, err := s.prepareDatasourceSettings(items)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	normalized, _ := findDatasource(cfg.Datasources, name)
+	if normalized.Access != nil && !canManageAccess(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errAccessAdminOnly.Error()})
+		return
+	}
+
+	if err := s.dbManager.AddSource(normalized); err != nil {
+		slog.ErrorContext(c.Request.Context(), "新增数据源失败", slog.String("datasource", name), slog.String("error", err.Error()))
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.writeSettings(data); err != nil {
+		_ = s.dbManager.RemoveSource(name)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "写入配置文件失败"})
+		return
+	}
+
+	s.setConfig(cfg)
+	s.recordAudit(c, auditDatasourceCreate, name, auditValue(c, "datasource", normalized))
+	c.JSON(http.StatusCreated, s.datasourceView(normalized))
+}
+
+func (s *Server) handleUpdateDatasource(c *gin.Context) {
+	name := c.Param("name")
+
+	var payload config.DatasourceConfig
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体格式错误"})
+		return
+	}
+	if trimmed := strings.TrimSpace(payload.Name); trimmed != "" && trimmed != name {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持修改数据源名称"})
+		return
+	}
+	payload.Name = name
+
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	previous, exists := s.findManagedDatasource(c, name)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "数据源不存在"})
+		return
+	}
+
+	items := make([]config.DatasourceConfig, 0, len(s.currentConfig().Datasources))
+	for _, item := range s.currentConfig().Datasources {
+		if item.Name == name {
+			item = payload
+		}
+		items = append(items, item)
+	}
+	data, cfg, err := s.prepareDatasourceSettings(items)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	normalized, _ := findDatasource(cfg.Datasources, name)
+	if !reflect.DeepEqual(normalized.Access, previous.Access) && !canManageAccess(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errAccessAdminOnly.Error()})
+		return
+	}
+
+	if err := s.dbManager.ReplaceSource(normalized); err != nil {
+		slog.ErrorContext(c.Request.Context(), "替换数据源失败", slog.String("datasource", name), slog.String("error", err.Error()))
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.writeSettings(data); err != nil {
+		if restoreErr := s.dbManager.ReplaceSource(previous); restoreErr != nil {
+			slog.ErrorContext(c.Request.Context(), "恢复数据源失败", slog.String("datasource", name), slog.String("error", restoreErr.Error()))
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "写入配置文件失败"})
+		return
+	}
+
+	s.setConfig(cfg)
+	s.recordAudit(c, auditDatasourceUpdate, name, auditChanges(c, previous, normalized))
+	c.JSON(http.StatusOK, s.datasourceView(normalized))
+}
+
+func (s *Server) handleDeleteDatasource(c *gin.Context) {
+	name := c.Param("name")
+
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	previous, exists := s.findManagedDatasource(c, name)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "数据源不存在"})
+		return
+	}
+
+	items := make([]config.DatasourceConfig, 0, len(s.currentConfig().Datasources))
+	for _, item := range s.currentConfig().Datasources {
+		if item.Name != name {
+			items = append(items, item)
+		}
+	}
+	data, cfg, err := s.prepareDatasourceSettings(items)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.writeSettings(data); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "写入配置文件失败"})
+		return
+	}
+	s.setConfig(cfg)
+
+	if _, registered := s.dbManager.GetDatasource(name); registered {
+		if err := s.dbManager.RemoveSource(name); err != nil {
+			slog.ErrorContext(c.Request.Context(), "移除数据源失败", slog.String("datasource", previous.Name), slog.String("error", err.Error()))
+		}
+	}
+
+	s.recordAudit(c, auditDatasourceDelete, name, auditValue(c, "datasource", previous))
+	c.Status(http.StatusNoContent)
+}
+
+// handleTestDatasource 检查已配置的数据源；handleTestDatasourceConfig 检查请求体中尚未保存的配置。
+func (s *Server) handleTestDatasource(c *gin.Context) {
+	item, ok := s.findManagedDatasource(c, c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "数据源不存在"})
+		return
+	}
+	s.respondDatasourceTest(c, item)
+}
+
+func (s *Server) handleTestDatasourceConfig(c *gin.Context) {
+	var payload config.DatasourceConfig
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体格式错误"})
+		return
+	}
+	s.respondDatasourceTest(c, payload)
+}
+
+// handleReindexDatasource 重建已配置数据源的全文索引，完成后该数据源的搜索缓存随代数递增而失效。
+func (s *Server) handleReindexDatasource(c *gin.Context) {
+	item, ok := s.findManagedDatasource(c, c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "数据源不存在"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), datasourceReindexTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := s.dbManager.ReindexSource(ctx, item); err != nil {
+		if errors.Is(err, infra.ErrReadOnlySource) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "重建索引失败", slog.String("datasource", item.Name), slog.String("error", err.Error()))
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	elapsed := time.Since(start).Milliseconds()
+
+	slog.InfoContext(c.Request.Context(), "已重建索引", slog.String("datasource", item.Name), slog.Int64("elapsed_ms", elapsed))
+	s.recordAudit(c, auditDatasourceReindex, item.Name, gin.H{"elapsedMs": elapsed})
+	c.JSON(http.StatusOK, gin.H{"name": item.Name, "elapsedMs": elapsed})
+}
+
+// errAccessAdminOnly 表示 librarian 试图设置或修改数据源的 access 规则。
+var errAccessAdminOnly = errors.New("只有管理员可以修改数据源的访问控制")
+
+// findManagedDatasource 在当前配置中查找调用者可以管理的数据源。access 规则不允许调用者检索的数据源按不存在处理，
+// 避免 librarian 通过后台接口查看、修改或删除私有数据源。
+func (s *Server) findManagedDatasource(c *gin.Context, name string) (config.DatasourceConfig, bool) {
+	item, ok := findDatasource(s.currentConfig().Datasources, name)
+	if !ok || !canAccessSource(requestPrincipal(c), item.Access, false) {
+		return config.DatasourceConfig{}, false
+	}
+	return item, true
+}
+
+// canManageAccess 返回调用者能否设置数据源的 access 规则，只有 admin 可以，避免 librarian 为自己放开私有数据源。
+func canManageAccess(c *gin.Context) bool {
+	p := requestPrincipal(c)
+	return p != nil && p.Role == auth.RoleAdmin
+}
+
+func (s *Server) respondDatasourceTest(c *gin.Context, item config.DatasourceConfig) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), datasourceTestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	info, err := s.dbManager.InspectSource(ctx, item)
+	elapsed := time.Since(start).Milliseconds()
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"ok":        false,
+			"error":     err.Error(),
+			"elapsedMs": elapsed,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":        true,
+		"info":      info,
+		"elapsedMs": elapsed,
+	})
+}
+
+// prepareDatasourceSettings 将新的数据源列表写回当前设置文件内容并按配置规则校验，
+// 返回待写入的文件内容与解析后的配置，校验失败时不会产生任何副作用。
+func (s *Server) prepareDatasourceSettings(items []config.DatasourceConfig) ([]byte, *config.Config, error) {
+	payload, err := s.readSettings()
+	if err != nil {
+		return nil, nil, err
+	}
+	payload["datasources"] = items
+
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+
+	cfg, err := config.ParseConfig(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, cfg, nil
+}
+
+func (s *Server) datasourceView(item config.DatasourceConfig) datasourceView {
+	view := datasourceView{DatasourceConfig: item}
+	if _, ok := s.dbManager.GetDatasource(item.Name); ok {
+		view.Registered = true
+	}
+	for _, status := range s.dbManager.HealthStatuses() {
+		if status.Name == item.Name {
+			health := status
+			view.Health = &health
+			break
+		}
+	}
+	return view
+}
+
+func findDatasource(items []config.DatasourceConfig, name string) (config.DatasourceConfig, bool) {
+	name = strings.TrimSpace(name)
+	for _, item := range items {
+		if item.Name == name {
+			return item, true
+		}
+	}
+	return config.DatasourceConfig{}, false
+}
@@ -0,0 +1,73 @@
+// path: internal/api/admin_logging.go
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/config"
+	"ebookdatabase/logger"
+)
+
+// loggingView 是后台日志设置接口的响应：active 表示当前进程的日志是否由该配置控制。
+type loggingView struct {
+	Logging config.LoggingConfig `json:"logging"`
+	Active  bool                 `json:"active"`
+}
+
+func (s *Server) handleGetLogging(c *gin.Context) {
+	c.JSON(http.StatusOK, loggingView{Logging: s.currentConfig().Logging, Active: logger.Initialized()})
+}
+
+// handleUpdateLogging 校验并保存新的日志配置，保存成功后立即重建 logger，无需重启。
+func (s *Server) handleUpdateLogging(c *gin.Context) {
+	var payload config.LoggingConfig
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体格式错误"})
+		return
+	}
+	normalized, err := config.NormalizeLogging(payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	settings, err := s.readSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	settings["logging"] = normalized
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "序列化配置失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存配置失败"})
+		return
+	}
+	cfg, err := config.ValidateConfig(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous := s.currentConfig().Logging
+	if err := s.applySettings(data, cfg); err != nil {
+		s.respondApplyError(c, err)
+		return
+	}
+	s.recordAudit(c, auditLoggingUpdate, "", auditChanges(c, previous, cfg.Logging))
+
+	slog.InfoContext(c.Request.Context(), "日志配置已更新",
+		slog.String("level", cfg.Logging.Level),
+		slog.String("format", cfg.Logging.Format),
+		slog.String("file", cfg.Logging.File),
+	)
+	c.JSON(http.StatusOK, loggingView{Logging: cfg.Logging, Active: logger.Initialized()})
+}
@@ -0,0 +1,131 @@
+// path: internal/api/admin_users.go
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/internal/audit"
+	"ebookdatabase/internal/auth"
+)
+
+func (s *Server) handleListUsers(c *gin.Context) {
+	users, err := s.auth.ListUsers()
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "读取用户列表失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取用户列表失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users, "roles": auth.Roles()})
+}
+
+func (s *Server) handleGetUser(c *gin.Context) {
+	user, err := s.auth.GetUser(c.Param("username"))
+	if err != nil {
+		s.respondUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (s *Server) handleCreateUser(c *gin.Context) {
+	var payload struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Role     string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体格式错误"})
+		return
+	}
+	role, err := auth.ParseRole(payload.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := s.auth.CreateUser(payload.Username, payload.Password, role)
+	if err != nil {
+		s.respondUserError(c, err)
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "已新增用户", slog.String("username", user.Username), slog.String("role", string(user.Role)))
+	s.recordAudit(c, auditUserCreate, user.Username, gin.H{"role": user.Role})
+	c.JSON(http.StatusCreated, user)
+}
+
+// handleUpdateUser 按请求体中出现的字段修改密码、角色或停用状态。
+func (s *Server) handleUpdateUser(c *gin.Context) {
+	var payload struct {
+		Password *string `json:"password"`
+		Role     *string `json:"role"`
+		Disabled *bool   `json:"disabled"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体格式错误"})
+		return
+	}
+
+	update := auth.UserUpdate{Password: payload.Password, Disabled: payload.Disabled}
+	if payload.Role != nil {
+		role, err := auth.ParseRole(*payload.Role)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		update.Role = &role
+	}
+
+	previous, err := s.auth.GetUser(c.Param("username"))
+	if err != nil {
+		s.respondUserError(c, err)
+		return
+	}
+	user, err := s.auth.UpdateUser(previous.Username, update)
+	if err != nil {
+		s.respondUserError(c, err)
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "已修改用户", slog.String("username", user.Username),
+		slog.String("role", string(user.Role)), slog.Bool("disabled", user.Disabled))
+	changes, err := audit.Diff(
+		gin.H{"role": previous.Role, "disabled": previous.Disabled},
+		gin.H{"role": user.Role, "disabled": user.Disabled},
+	)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "计算用户变更失败", slog.String("error", err.Error()))
+	}
+	s.recordAudit(c, auditUserUpdate, user.Username, gin.H{"changes": changes, "passwordChanged": payload.Password != nil})
+	c.JSON(http.StatusOK, user)
+}
+
+func (s *Server) handleDeleteUser(c *gin.Context) {
+	username := c.Param("username")
+	if err := s.auth.DeleteUser(username); err != nil {
+		s.respondUserError(c, err)
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "已删除用户", slog.String("username", username))
+	if err := s.shelves.DeleteOwner(c.Request.Context(), auth.UserSubject(username)); err != nil {
+		slog.ErrorContext(c.Request.Context(), "删除用户书架失败", slog.String("username", username), slog.String("error", err.Error()))
+	}
+	s.recordAudit(c, auditUserDelete, username, nil)
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) respondUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrUserExists), errors.Is(err, auth.ErrLastAdmin):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrInvalidUser):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), "用户操作失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "用户操作失败"})
+	}
+}
@@ -0,0 +1,172 @@
+// path: internal/api/config_reload.go
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"ebookdatabase/config"
+	"ebookdatabase/internal/adapters"
+	"ebookdatabase/logger"
+)
+
+// currentConfig 返回当前生效的配置。返回值在替换后不会再被修改，调用方只读使用即可。
+func (s *Server) currentConfig() *config.Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
+}
+
+// setConfig 整体替换运行配置以及由其派生的 CORS 规则、缓存容量、登录与搜索限流以及日志设置。
+func (s *Server) setConfig(cfg *config.Config) {
+	cors := corsMiddleware(cfg, s.listenAddr)
+	previous := s.currentConfig()
+	if previous != nil && !reflect.DeepEqual(previous.Logging, cfg.Logging) {
+		applyLogging(cfg.Logging)
+	}
+	s.syncAdminPassword(previous, cfg)
+
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	s.config = cfg
+	s.cors = cors
+	s.cache.Configure(cfg.SearchCache)
+	s.logins.Configure(cfg.Auth.LoginThrottle)
+	s.searchLimiter.Configure(cfg.RateLimit)
+	adapters.ConfigureQueryLog(cfg.QueryLog)
+	s.configureAnalyticsLocked(cfg)
+}
+
+// syncAdminPassword 在 adminPassword 被修改后使此前以共享管理员身份签发的令牌失效。
+// 明文密码保存时转为同一密码的哈希不算修改。
+func (s *Server) syncAdminPassword(previous, cfg *config.Config) {
+	unchanged := previous != nil && sameAdminPassword(previous.AdminPassword, cfg.AdminPassword)
+	if err := s.auth.SyncSharedAdminPassword(cfg.AdminPassword, unchanged); err != nil {
+		slog.Error("记录管理员密码变更失败", slog.String("error", err.Error()))
+	}
+}
+
+// sameAdminPassword 判断设置文件中 adminPassword 的新旧值是否为同一密码：值相同，或旧值为明文且新值是它的哈希。
+func sameAdminPassword(previous, current string) bool {
+	if previous == current {
+		return true
+	}
+	return previous != "" && !config.IsBcryptHash(previous) && config.IsBcryptHash(current) &&
+		bcrypt.CompareHashAndPassword([]byte(current), []byte(previous)) == nil
+}
+
+// applyLogging 按新的 logging 配置重建全局 logger。只有 serve 通过 logger.InitLogger 接管了日志时才会生效，
+// 失败时保留原有 logger。
+func applyLogging(cfg config.LoggingConfig) {
+	opts, err := logger.OptionsFromConfig(cfg)
+	if err == nil {
+		_, err = logger.Reconfigure(opts)
+	}
+	if err != nil {
+		slog.Error("应用日志配置失败，继续使用原有日志设置", slog.String("error", err.Error()))
+	}
+}
+
+// dynamicCORS 每次请求时读取当前的 CORS 处理器，使 corsAllowedOrigins 的修改无需重启即可生效。
+func (s *Server) dynamicCORS(c *gin.Context) {
+	s.configMu.RLock()
+	handler := s.cors
+	s.configMu.RUnlock()
+	handler(c)
+}
+
+// ApplyConfig 将新配置应用到运行中的服务：数据源按差异增量调整，全部成功后再切换配置。
+// 任一数据源初始化失败时返回错误，运行中的配置与数据源保持不变。
+func (s *Server) ApplyConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	changes := config.Diff(s.currentConfig(), cfg)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if err := s.dbManager.ApplyDatasources(cfg.Datasources); err != nil {
+		return fmt.Errorf("%w: %w", errDatasourceInit, err)
+	}
+	s.setConfig(cfg)
+
+	slog.Info("配置已热加载", slog.Any("changes", changes))
+	return nil
+}
+
+// StartConfigWatcher 开始监视设置文件，文件被手动修改后自动校验并应用新配置。
+// 校验或应用失败时记录错误并继续使用当前配置。
+func (s *Server) StartConfigWatcher(interval time.Duration) error {
+	if s.configPath == "" {
+		return fmt.Errorf("未指定配置文件路径")
+	}
+
+	watcher := config.NewWatcher(s.configPath, interval)
+	if err := watcher.Start(s.reloadConfig); err != nil {
+		return fmt.Errorf("启动配置监视失败: %w", err)
+	}
+
+	s.configMu.Lock()
+	previous := s.watcher
+	s.watcher = watcher
+	s.configMu.Unlock()
+
+	if previous != nil {
+		previous.Stop()
+	}
+	return nil
+}
+
+func (s *Server) reloadConfig(data []byte) {
+	cfg, err := config.ValidateConfig(data)
+	if err != nil {
+		slog.Error("配置文件校验失败，继续使用当前配置", slog.String("path", s.configPath), slog.String("error", err.Error()))
+		return
+	}
+	if err := s.ApplyConfig(cfg); err != nil {
+		slog.Error("应用新配置失败，继续使用当前配置", slog.String("path", s.configPath), slog.String("error", err.Error()))
+	}
+}
+
+// Close 停止配置监视与缓存清理等后台任务并关闭认证、审计、书架与搜索统计数据库，数据源由 DBManager 的所有者负责关闭。
+func (s *Server) Close() {
+	s.cache.StopJanitor()
+	s.closeOnce.Do(func() {
+		if err := s.auth.Close(); err != nil {
+			slog.Error("关闭认证数据库失败", slog.String("error", err.Error()))
+		}
+		if err := s.audit.Close(); err != nil {
+			slog.Error("关闭审计数据库失败", slog.String("error", err.Error()))
+		}
+		if err := s.shelves.Close(); err != nil {
+			slog.Error("关闭书架数据库失败", slog.String("error", err.Error()))
+		}
+
+		s.configMu.Lock()
+		store := s.analytics
+		s.analytics = nil
+		s.configMu.Unlock()
+		if err := store.Close(); err != nil {
+			slog.Error("关闭搜索统计库失败", slog.String("error", err.Error()))
+		}
+	})
+
+	s.configMu.Lock()
+	watcher := s.watcher
+	s.watcher = nil
+	s.configMu.Unlock()
+
+	if watcher != nil {
+		watcher.Stop()
+	}
+}
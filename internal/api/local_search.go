@@ -0,0 +1,75 @@
+// path: internal/api/local_search.go
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"ebookdatabase/config"
+	"ebookdatabase/internal/adapters"
+	"ebookdatabase/internal/core"
+	"ebookdatabase/internal/infra"
+)
+
+// LocalSearcher 供命令行等本机调用方直接搜索：参数校验、数据源扇出、归并与跳过规则与 /api/v1/search 相同，
+// 但调用方视为可信，不经过登录、数据源访问控制与限流，也不打开认证、审计与书架数据库。
+type LocalSearcher struct {
+	server *Server
+}
+
+// SearchResult 是一次本地搜索的结果，JSON 格式与 /api/v1/search 的成功响应一致。
+type SearchResult struct {
+	Books          []core.CanonicalBook `json:"books"`
+	TotalPages     int                  `json:"totalPages"`
+	TotalRecords   int64                `json:"totalRecords"`
+	SearchTimeMs   int64                `json:"searchTimeMs"`
+	SkippedSources []skippedSource      `json:"skippedSources"`
+}
+
+// NewLocalSearcher 基于已初始化的数据源创建本地搜索器，数据源仍由 manager 的所有者关闭。
+func NewLocalSearcher(cfg *config.Config, manager *infra.DBManager) (*LocalSearcher, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("配置不能为空")
+	}
+	if manager == nil {
+		return nil, fmt.Errorf("数据库管理器不能为空")
+	}
+	adapters.ConfigureQueryLog(cfg.QueryLog)
+	return &LocalSearcher{server: &Server{
+		dbManager: manager,
+		cache:     newSearchCache(cfg.SearchCache),
+		config:    cfg,
+	}}, nil
+}
+
+// Search 按 /api/v1/search 的查询参数格式执行搜索，values 中的 sources[] 可从全部已初始化的数据源中选择。
+// 所有数据源均失败时返回错误。
+func (l *LocalSearcher) Search(ctx context.Context, values url.Values) (*SearchResult, error) {
+	s := l.server
+	params, err := s.parseQueryParams(values)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := selectRequestedSources(s.resolveSources(), values)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("没有可用的数据源")
+	}
+
+	start := time.Now()
+	outcome := s.fanOutSearch(ctx, params, sources, buildSearchCacheKey(params, s.cacheSourceKeys(sources)))
+	if outcome.succeeded == 0 {
+		return nil, fmt.Errorf("所有数据源均不可用")
+	}
+	return &SearchResult{
+		Books:          outcome.books,
+		TotalPages:     computeTotalPages(outcome.total, params.PageSize),
+		TotalRecords:   outcome.total,
+		SearchTimeMs:   time.Since(start).Milliseconds(),
+		SkippedSources: outcome.skipped,
+	}, nil
+}
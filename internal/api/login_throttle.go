@@ -0,0 +1,239 @@
+// path: internal/api/login_throttle.go
+package api
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/config"
+	"ebookdatabase/internal/audit"
+)
+
+const (
+	// globalLoginWindow 是全局失败次数的统计窗口。
+	globalLoginWindow = time.Minute
+	// loginThrottleSweepSize 是触发清理过期记录的 IP 数量。
+	loginThrottleSweepSize = 4096
+	// loginFreeFailures 是无需等待即可重试的连续失败次数，避免一次输错就被延迟。
+	loginFreeFailures = 1
+)
+
+// loginAttempts 记录单个 IP 在保留期内的连续失败，以及已通过 Allow 但尚未得出结果的尝试数。
+// accounts 按登录账号拆分失败次数，某个账号登录成功时只清除该账号的部分。
+type loginAttempts struct {
+	failures    int
+	accounts    map[string]int
+	pending     int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// loginThrottle 按 IP 与全局两个维度限制登录失败后的重试：每次失败后需等待的时间指数增长，
+// 连续失败达到上限后临时锁定。被限制的请求不会校验密码，也不计入失败次数。
+// 进行中的尝试按可能失败预先占用额度，避免并发请求在任何失败被记录之前一起通过检查。
+type loginThrottle struct {
+	mu  sync.Mutex
+	cfg config.LoginThrottleConfig
+	now func() time.Time
+
+	attempts map[string]*loginAttempts
+
+	globalWindowStart time.Time
+	globalFailures    int
+	globalPending     int
+}
+
+func newLoginThrottle(cfg config.LoginThrottleConfig) *loginThrottle {
+	return &loginThrottle{
+		cfg:      cfg,
+		now:      time.Now,
+		attempts: make(map[string]*loginAttempts),
+	}
+}
+
+// Configure 替换限制参数，已有的失败记录保留并按新参数计算等待时间。
+func (t *loginThrottle) Configure(cfg config.LoginThrottleConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cfg = cfg
+}
+
+// Allow 返回 ip 当前能否尝试登录，不能时同时返回需要等待的时间。允许时为本次尝试占用一份额度，
+// 调用方随后必须调用 Success、Failure 或 Release 之一归还。
+func (t *loginThrottle) Allow(ip string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.cfg.IsEnabled() {
+		return 0, true
+	}
+	now := t.now()
+
+	t.rollGlobalWindow(now)
+	if t.globalFailures+t.globalPending >= t.cfg.GlobalMaxFailuresPerMinute {
+		return max(t.globalWindowStart.Add(globalLoginWindow).Sub(now), time.Second), false
+	}
+
+	entry := t.entry(ip, now)
+	if entry != nil {
+		if now.Before(entry.lockedUntil) {
+			return entry.lockedUntil.Sub(now), false
+		}
+		if next := entry.lastFailure.Add(t.delay(entry.failures)); now.Before(next) {
+			return next.Sub(now), false
+		}
+		// 进行中的尝试全部失败后本次就需要等待或会触发锁定时，等它们出结果再试。
+		if projected := entry.failures + entry.pending; entry.pending > 0 &&
+			(projected >= t.cfg.MaxAttempts || t.delay(projected) > 0) {
+			return max(t.delay(projected), time.Second), false
+		}
+	} else {
+		entry = t.newEntry(ip, now)
+	}
+	entry.pending++
+	t.globalPending++
+	return 0, true
+}
+
+// Failure 记录 ip 针对 account 的一次失败并归还 Allow 占用的额度，返回该 IP 的连续失败次数以及本次失败是否触发了锁定。
+func (t *loginThrottle) Failure(ip, account string) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+
+	t.rollGlobalWindow(now)
+	t.globalFailures++
+	t.globalPending = max(t.globalPending-1, 0)
+
+	entry := t.entry(ip, now)
+	if entry == nil {
+		entry = t.newEntry(ip, now)
+	}
+	entry.failures++
+	entry.accounts[strings.ToLower(account)]++
+	entry.pending = max(entry.pending-1, 0)
+	entry.lastFailure = now
+
+	locked := false
+	if t.cfg.IsEnabled() && entry.failures >= t.cfg.MaxAttempts {
+		entry.lockedUntil = now.Add(t.cfg.Lockout())
+		locked = true
+	}
+	return entry.failures, locked
+}
+
+// Success 清除 ip 针对 account 的失败记录并归还 Allow 占用的额度。针对其他账号的失败与锁定保留，
+// 避免持有任一有效账号的人在猜测其他账号密码的间隙登录一次来重置退避。
+func (t *loginThrottle) Success(ip, account string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.globalPending = max(t.globalPending-1, 0)
+	entry, ok := t.attempts[ip]
+	if !ok {
+		return
+	}
+	account = strings.ToLower(account)
+	entry.failures -= entry.accounts[account]
+	delete(entry.accounts, account)
+	entry.pending = max(entry.pending-1, 0)
+	if entry.pending == 0 && entry.failures == 0 {
+		delete(t.attempts, ip)
+	}
+}
+
+// Release 归还 Allow 占用的额度而不记录结果，用于因服务端错误未能校验凭证的尝试。
+func (t *loginThrottle) Release(ip string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.globalPending = max(t.globalPending-1, 0)
+	entry, ok := t.attempts[ip]
+	if !ok {
+		return
+	}
+	entry.pending = max(entry.pending-1, 0)
+	if entry.pending == 0 && entry.failures == 0 {
+		delete(t.attempts, ip)
+	}
+}
+
+// newEntry 为 ip 新建失败记录，记录过多时先清理过期项。调用方需持有 mu。
+func (t *loginThrottle) newEntry(ip string, now time.Time) *loginAttempts {
+	if len(t.attempts) >= loginThrottleSweepSize {
+		t.sweep(now)
+	}
+	entry := &loginAttempts{accounts: make(map[string]int)}
+	t.attempts[ip] = entry
+	return entry
+}
+
+// entry 返回 ip 仍然有效的失败记录：锁定结束或距上次失败超过锁定时长的记录会被丢弃。调用方需持有 mu。
+func (t *loginThrottle) entry(ip string, now time.Time) *loginAttempts {
+	entry, ok := t.attempts[ip]
+	if !ok {
+		return nil
+	}
+	if t.expired(entry, now) {
+		delete(t.attempts, ip)
+		return nil
+	}
+	return entry
+}
+
+func (t *loginThrottle) expired(entry *loginAttempts, now time.Time) bool {
+	if entry.pending > 0 {
+		return false
+	}
+	if !entry.lockedUntil.IsZero() {
+		return !now.Before(entry.lockedUntil)
+	}
+	return now.Sub(entry.lastFailure) >= t.cfg.Lockout()
+}
+
+// delay 返回第 failures 次失败后的等待时间：超过 loginFreeFailures 后从 BaseDelay 起逐次翻倍，不超过 MaxDelay。
+func (t *loginThrottle) delay(failures int) time.Duration {
+	if failures <= loginFreeFailures {
+		return 0
+	}
+	delay := t.cfg.BaseDelay()
+	for i := loginFreeFailures + 1; i < failures && delay < t.cfg.MaxDelay(); i++ {
+		delay *= 2
+	}
+	return min(delay, t.cfg.MaxDelay())
+}
+
+func (t *loginThrottle) rollGlobalWindow(now time.Time) {
+	if now.Sub(t.globalWindowStart) >= globalLoginWindow {
+		t.globalWindowStart = now
+		t.globalFailures = 0
+	}
+}
+
+func (t *loginThrottle) sweep(now time.Time) {
+	for ip, entry := range t.attempts {
+		if t.expired(entry, now) {
+			delete(t.attempts, ip)
+		}
+	}
+}
+
+// recordLoginFailure 记录针对 account（登录主体）的失败尝试并写入日志与审计日志，触发锁定时额外记录一条警告。
+func (s *Server) recordLoginFailure(c *gin.Context, ip, username, account string) {
+	failures, locked := s.logins.Failure(ip, account)
+	s.appendAudit(c, audit.Entry{Actor: username, ClientIP: ip, Action: auditLoginFailure},
+		gin.H{"failures": failures, "locked": locked})
+	ctx := c.Request.Context()
+	slog.WarnContext(ctx, "登录失败",
+		slog.String("client_ip", ip),
+		slog.String("username", username),
+		slog.Int("failures", failures),
+	)
+	if locked {
+		slog.WarnContext(ctx, "登录失败次数过多，已临时锁定该 IP",
+			slog.String("client_ip", ip),
+			slog.Duration("lockout", s.currentConfig().Auth.LoginThrottle.Lockout()),
+		)
+	}
+}
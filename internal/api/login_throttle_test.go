@@ -0,0 +1,199 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"ebookdatabase/config"
+)
+
+func newTestLoginThrottle(t *testing.T, raw string) (*loginThrottle, *time.Time) {
+	t.Helper()
+	cfg, err := config.ParseConfig([]byte(raw))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+	throttle := newLoginThrottle(cfg.Auth.LoginThrottle)
+	now := time.Unix(1_700_000_000, 0)
+	throttle.now = func() time.Time { return now }
+	return throttle, &now
+}
+
+func TestLoginThrottleBacksOffAndLocksOut(t *testing.T) {
+	throttle, now := newTestLoginThrottle(t, `{"auth": {"loginThrottle": {"maxAttempts": 4, "baseDelayMs": 1000, "maxDelayMs": 3000, "lockoutMinutes": 10}}}`)
+	const ip = "198.51.100.7"
+
+	throttle.Failure(ip, "admin")
+	if _, ok := throttle.Allow(ip); !ok {
+		t.Fatalf("expected the first failure to allow an immediate retry")
+	}
+
+	throttle.Failure(ip, "admin")
+	if wait, ok := throttle.Allow(ip); ok || wait != time.Second {
+		t.Fatalf("expected 1s backoff after the second failure, got %v %v", wait, ok)
+	}
+	if _, ok := throttle.Allow("203.0.113.9"); !ok {
+		t.Fatalf("expected other IPs to be unaffected")
+	}
+
+	*now = now.Add(time.Second)
+	throttle.Failure(ip, "admin")
+	if wait, ok := throttle.Allow(ip); ok || wait != 2*time.Second {
+		t.Fatalf("expected doubled backoff, got %v %v", wait, ok)
+	}
+
+	*now = now.Add(2 * time.Second)
+	if failures, locked := throttle.Failure(ip, "admin"); failures != 4 || !locked {
+		t.Fatalf("expected lockout on the fourth failure, got %d %v", failures, locked)
+	}
+	if wait, ok := throttle.Allow(ip); ok || wait != 10*time.Minute {
+		t.Fatalf("expected 10 minute lockout, got %v %v", wait, ok)
+	}
+
+	*now = now.Add(10 * time.Minute)
+	if _, ok := throttle.Allow(ip); !ok {
+		t.Fatalf("expected lockout to expire")
+	}
+	throttle.Failure(ip, "admin")
+	if _, ok := throttle.Allow(ip); !ok {
+		t.Fatalf("expected failures to be forgotten after the lockout")
+	}
+
+	throttle.Failure(ip, "admin")
+	throttle.Success(ip, "admin")
+	if _, ok := throttle.Allow(ip); !ok {
+		t.Fatalf("expected success to clear failures")
+	}
+}
+
+func TestLoginThrottleSuccessKeepsOtherAccountFailures(t *testing.T) {
+	throttle, now := newTestLoginThrottle(t, `{"auth": {"loginThrottle": {"maxAttempts": 3, "baseDelayMs": 1000, "lockoutMinutes": 10}}}`)
+	const ip = "198.51.100.7"
+
+	for range 2 {
+		if _, ok := throttle.Allow(ip); !ok {
+			t.Fatalf("expected guess against the shared admin to be allowed")
+		}
+		throttle.Failure(ip, "admin")
+	}
+	*now = now.Add(time.Second)
+	if _, ok := throttle.Allow(ip); !ok {
+		t.Fatalf("expected login after the backoff to be allowed")
+	}
+	throttle.Success(ip, "user:Bob")
+
+	if _, ok := throttle.Allow(ip); !ok {
+		t.Fatalf("expected the next guess to be allowed")
+	}
+	if failures, locked := throttle.Failure(ip, "admin"); failures != 3 || !locked {
+		t.Fatalf("expected another account's success to keep earlier failures, got %d %v", failures, locked)
+	}
+	if _, ok := throttle.Allow(ip); ok {
+		t.Fatalf("expected the IP to stay locked")
+	}
+}
+
+func TestLoginThrottleGlobalLimitAndDisable(t *testing.T) {
+	throttle, now := newTestLoginThrottle(t, `{"auth": {"loginThrottle": {"globalMaxFailuresPerMinute": 3}}}`)
+	for _, ip := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"} {
+		throttle.Failure(ip, "admin")
+	}
+	if wait, ok := throttle.Allow("198.51.100.4"); ok || wait != time.Minute {
+		t.Fatalf("expected global limit to block new IPs, got %v %v", wait, ok)
+	}
+	*now = now.Add(time.Minute)
+	if _, ok := throttle.Allow("198.51.100.4"); !ok {
+		t.Fatalf("expected global window to reset")
+	}
+
+	disabled := false
+	throttle.Configure(config.LoginThrottleConfig{Enabled: &disabled})
+	for range 10 {
+		throttle.Failure("198.51.100.1", "admin")
+	}
+	if _, ok := throttle.Allow("198.51.100.1"); !ok {
+		t.Fatalf("expected disabled throttle to allow every attempt")
+	}
+}
+
+func TestLoginThrottleReservesConcurrentAttempts(t *testing.T) {
+	throttle, _ := newTestLoginThrottle(t, `{"auth": {"loginThrottle": {"maxAttempts": 4, "baseDelayMs": 1000}}}`)
+	const ip = "198.51.100.7"
+
+	for i := range 2 {
+		if _, ok := throttle.Allow(ip); !ok {
+			t.Fatalf("expected attempt %d to be allowed", i+1)
+		}
+	}
+	if _, ok := throttle.Allow(ip); ok {
+		t.Fatalf("expected in-flight attempts to use up the budget before any failure is recorded")
+	}
+	if _, ok := throttle.Allow("203.0.113.9"); !ok {
+		t.Fatalf("expected other IPs to be unaffected")
+	}
+
+	throttle.Release(ip)
+	throttle.Release(ip)
+	if _, ok := throttle.Allow(ip); !ok {
+		t.Fatalf("expected released attempts to return the budget")
+	}
+	if failures, _ := throttle.Failure(ip, "admin"); failures != 1 {
+		t.Fatalf("expected failure to consume the reservation, got %d failures", failures)
+	}
+	if _, ok := throttle.Allow(ip); !ok {
+		t.Fatalf("expected a retry after the first failure")
+	}
+	if _, ok := throttle.Allow(ip); ok {
+		t.Fatalf("expected a second concurrent retry to wait for the first result")
+	}
+}
+
+func TestLoginRejectsThrottledAttemptsWithRetryAfter(t *testing.T) {
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+	now := time.Unix(1_700_000_000, 0)
+	server.logins.now = func() time.Time { return now }
+
+	for range 2 {
+		if resp := performRequest(server, http.MethodPost, "/api/v1/login", `{"password":"wrong"}`, nil); resp.Code != http.StatusUnauthorized {
+			t.Fatalf("expected wrong password to be rejected, got %d", resp.Code)
+		}
+	}
+	throttled := performRequest(server, http.MethodPost, "/api/v1/login", `{"password":"secret"}`, nil)
+	if throttled.Code != http.StatusTooManyRequests || throttled.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected throttled login with Retry-After, got %d %v", throttled.Code, throttled.Header())
+	}
+
+	now = now.Add(time.Second)
+	if resp := performRequest(server, http.MethodPost, "/api/v1/login", `{"password":"secret"}`, nil); resp.Code != http.StatusOK {
+		t.Fatalf("expected login after backoff, got %d %s", resp.Code, resp.Body.String())
+	}
+}
+
+func TestLoginThrottleUsesTrustedProxiesOnly(t *testing.T) {
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	spoofed := func(srv *Server, ip string) int {
+		return performRequest(srv, http.MethodPost, "/api/v1/login", `{"password":"wrong"}`, map[string]string{"X-Forwarded-For": ip}).Code
+	}
+	codes := []int{spoofed(server, "10.0.0.1"), spoofed(server, "10.0.0.2"), spoofed(server, "10.0.0.3")}
+	if codes[0] != http.StatusUnauthorized || codes[1] != http.StatusUnauthorized || codes[2] != http.StatusTooManyRequests {
+		t.Fatalf("expected forged X-Forwarded-For to be ignored, got %v", codes)
+	}
+
+	// httptest 请求的对端地址为 192.0.2.1，配置为可信代理后按 X-Forwarded-For 区分客户端。
+	cfg := *server.currentConfig()
+	cfg.HTTP.TrustedProxies = []string{"192.0.2.0/24"}
+	proxied, err := NewServer(&cfg, server.dbManager, server.configPath, "")
+	if err != nil {
+		t.Fatalf("NewServer returned error: %v", err)
+	}
+	defer proxied.Close()
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		if code := spoofed(proxied, ip); code != http.StatusUnauthorized {
+			t.Fatalf("expected forwarded client %s to have its own budget, got %d", ip, code)
+		}
+	}
+}
@@ -2,17 +2,67 @@
 package api
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/logger"
+)
+
+const (
+	// requestIDHeader 是请求 ID 的请求头与响应头名称。
+	requestIDHeader = "X-Request-ID"
+	// maxRequestIDLength 限制客户端传入的请求 ID 长度，超长或含非法字符时改为服务端生成。
+	maxRequestIDLength = 128
 )
 
+// requestIDMiddleware 沿用客户端或反向代理传入的 X-Request-ID，缺失或不合法时生成新的 ID，
+// 写回响应头并放入请求 context，后续经 slog.*Context 记录的日志都会带上 request_id。
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// accessLogMiddleware 在请求结束后记录一条访问日志，5xx 记为 Error，其余记为 Info。
+func accessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(c.Request.Context(), level, "HTTP 请求",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+			slog.Duration("elapsed", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+		)
+	}
+}
+
 func panicRecoveryMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if r := recover(); r != nil {
-				slog.Error("panic recovered",
+				slog.ErrorContext(c.Request.Context(), "panic recovered",
 					slog.Any("error", r),
 					slog.String("path", c.FullPath()),
 					slog.String("method", c.Request.Method),
@@ -23,3 +73,24 @@ func panicRecoveryMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
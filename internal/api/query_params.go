@@ -4,6 +4,7 @@ package api
 import (
 	"fmt"
 	"math"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -13,24 +14,29 @@ import (
 )
 
 func (s *Server) buildQueryParams(c *gin.Context) (*search.QueryParams, error) {
-	fields := normalizeValues(firstNonEmpty(c.QueryArray("fields[]"), c.QueryArray("fields")))
-	queries := normalizeValues(firstNonEmpty(c.QueryArray("queries[]"), c.QueryArray("queries")))
-	logics := normalizeValues(firstNonEmpty(c.QueryArray("logics[]"), c.QueryArray("logics")))
+	return s.parseQueryParams(c.Request.URL.Query())
+}
+
+// parseQueryParams 按搜索接口的查询参数格式解析并校验 values，HTTP 接口与本地搜索共用。
+func (s *Server) parseQueryParams(values url.Values) (*search.QueryParams, error) {
+	fields := normalizeValues(firstNonEmpty(values["fields[]"], values["fields"]))
+	queries := normalizeValues(firstNonEmpty(values["queries[]"], values["queries"]))
+	logics := normalizeValues(firstNonEmpty(values["logics[]"], values["logics"]))
 
 	if len(fields) == 0 {
-		if field := c.Query("field"); field != "" {
+		if field := values.Get("field"); field != "" {
 			fields = []string{strings.TrimSpace(field)}
 		}
 	}
 
 	if len(queries) == 0 {
-		if query := c.Query("query"); query != "" {
+		if query := values.Get("query"); query != "" {
 			queries = []string{strings.TrimSpace(query)}
 		}
 	}
 
 	if len(fields) == 0 {
-		fields = []string{strings.TrimSpace(s.config.DefaultSearchField)}
+		fields = []string{strings.TrimSpace(s.currentConfig().DefaultSearchField)}
 	}
 
 	if len(fields) == 0 || len(queries) == 0 {
@@ -56,9 +62,9 @@ func (s *Server) buildQueryParams(c *gin.Context) (*search.QueryParams, error) {
 		}
 	}
 
-	fuzzyRaw := normalizeValues(firstNonEmpty(c.QueryArray("fuzzies[]"), c.QueryArray("fuzzies")))
+	fuzzyRaw := normalizeValues(firstNonEmpty(values["fuzzies[]"], values["fuzzies"]))
 	if len(fuzzyRaw) == 0 {
-		if value := c.Query("fuzzy"); value != "" {
+		if value := values.Get("fuzzy"); value != "" {
 			fuzzyRaw = []string{strings.TrimSpace(value)}
 		}
 	}
@@ -73,10 +79,10 @@ func (s *Server) buildQueryParams(c *gin.Context) (*search.QueryParams, error) {
 		}
 	}
 
-	page := parsePositiveInt(c.Query("page"), 1)
-	pageSize := parsePositiveInt(firstNonBlank(c.Query("pageSize"), c.Query("page_size")), s.config.PageSize)
+	page := parsePositiveInt(values.Get("page"), 1)
+	pageSize := parsePositiveInt(firstNonBlank(values.Get("pageSize"), values.Get("page_size")), s.currentConfig().PageSize)
 	if pageSize <= 0 {
-		pageSize = s.config.PageSize
+		pageSize = s.currentConfig().PageSize
 	}
 
 	if page <= 0 {
@@ -156,6 +162,9 @@ func normalizeValues(values []string) []string {
 	return normalized
 }
 
+// buildSearchCacheKey 以本次实际查询的数据源列表与查询参数拼接缓存键，
+// 因此通过 sources[] 限定数据源的请求不会命中全量搜索的缓存。
+// sources 中的每一项应带上数据源代次（见 cacheSourceKeys），数据源被替换或重建索引后旧缓存自然失效。
 func buildSearchCacheKey(params *search.QueryParams, sources []string) string {
 	if params == nil {
 		return strings.Join(sources, ",")
@@ -0,0 +1,198 @@
+// path: internal/api/ratelimit.go
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/config"
+)
+
+const (
+	// rateLimitSweepSize 是触发清理空闲令牌桶的调用者数量。
+	rateLimitSweepSize = 4096
+	// throttleChunkBytes 是限速下载每次写出的最大字节数。
+	throttleChunkBytes = 32 << 10
+)
+
+// rateLimitKey 返回限流使用的调用者标识：登录用户、API Key、共享管理员，未登录时使用客户端 IP。
+// 客户端 IP 仅在连接来自 http.trustedProxies 时才取自转发请求头，否则为连接对端地址，匿名调用者无法伪造。
+func rateLimitKey(c *gin.Context) string {
+	if p := requestPrincipal(c); p != nil {
+		return p.subject()
+	}
+	return "ip:" + c.ClientIP()
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter 为每个调用者维护一个令牌桶，参数可在运行中替换。
+type rateLimiter struct {
+	mu      sync.Mutex
+	cfg     config.RateLimitConfig
+	now     func() time.Time
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(cfg config.RateLimitConfig) *rateLimiter {
+	return &rateLimiter{cfg: cfg, now: time.Now, buckets: make(map[string]*tokenBucket)}
+}
+
+// Configure 替换限流参数，已有令牌桶按新的容量截断。
+func (l *rateLimiter) Configure(cfg config.RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+}
+
+// Allow 从 key 的令牌桶中取出一个令牌，桶为空时返回距下一个令牌补充的时间。
+func (l *rateLimiter) Allow(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.cfg.IsEnabled() {
+		return 0, true
+	}
+
+	now := l.now()
+	capacity := float64(l.cfg.SearchBurst)
+	perSecond := float64(l.cfg.SearchPerMinute) / 60
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= rateLimitSweepSize {
+			l.sweep(now, capacity, perSecond)
+		}
+		bucket = &tokenBucket{tokens: capacity, last: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = min(capacity, bucket.tokens+now.Sub(bucket.last).Seconds()*perSecond)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second)), false
+	}
+	bucket.tokens--
+	return 0, true
+}
+
+// sweep 丢弃已经补满的令牌桶，它们与新建的桶没有区别。调用方需持有 mu。
+func (l *rateLimiter) sweep(now time.Time, capacity, perSecond float64) {
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*perSecond >= capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// downloadSlots 统计每个调用者正在进行的下载数。
+type downloadSlots struct {
+	mu     sync.Mutex
+	active map[string]int
+}
+
+func newDownloadSlots() *downloadSlots {
+	return &downloadSlots{active: make(map[string]int)}
+}
+
+// Acquire 在 key 正在进行的下载少于 limit 时占用一个名额，limit 小于等于 0 表示不限制。
+func (d *downloadSlots) Acquire(key string, limit int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if limit > 0 && d.active[key] >= limit {
+		return false
+	}
+	d.active[key]++
+	return true
+}
+
+// Release 归还 Acquire 占用的名额。
+func (d *downloadSlots) Release(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.active[key] <= 1 {
+		delete(d.active, key)
+		return
+	}
+	d.active[key]--
+}
+
+// limitSearchRate 按调用者限制搜索频率，需放在 requireAccess 之后以便识别登录用户。
+func (s *Server) limitSearchRate(c *gin.Context) {
+	if wait, ok := s.searchLimiter.Allow(rateLimitKey(c)); !ok {
+		retryAfter := int((wait + time.Second - 1) / time.Second)
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "搜索过于频繁，请稍后再试", "retryAfterSeconds": retryAfter})
+		return
+	}
+	c.Next()
+}
+
+// limitDownloads 限制调用者同时进行的下载数，并在配置了 downloadBytesPerSecond 时对响应限速。
+func (s *Server) limitDownloads(c *gin.Context) {
+	cfg := s.currentConfig().RateLimit
+	limit := 0
+	if cfg.IsEnabled() {
+		limit = cfg.MaxConcurrentDownloads
+	}
+
+	key := rateLimitKey(c)
+	if !s.downloads.Acquire(key, limit) {
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "同时进行的下载过多，请等待当前下载完成"})
+		return
+	}
+	defer s.downloads.Release(key)
+
+	if cfg.DownloadBytesPerSecond > 0 {
+		c.Writer = &throttledWriter{
+			ResponseWriter: c.Writer,
+			ctx:            c.Request.Context(),
+			bytesPerSecond: cfg.DownloadBytesPerSecond,
+			start:          time.Now(),
+		}
+	}
+	c.Next()
+}
+
+// throttledWriter 按 bytesPerSecond 分块写出响应，客户端断开时立即停止等待。
+type throttledWriter struct {
+	gin.ResponseWriter
+	ctx            context.Context
+	bytesPerSecond int64
+	start          time.Time
+	sent           int64
+}
+
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	chunkSize := int(min(w.bytesPerSecond, throttleChunkBytes))
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), chunkSize)]
+		n, err := w.ResponseWriter.Write(chunk)
+		written += n
+		w.sent += int64(n)
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+
+		due := time.Duration(float64(w.sent) / float64(w.bytesPerSecond) * float64(time.Second))
+		if wait := due - time.Since(w.start); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-w.ctx.Done():
+				timer.Stop()
+				return written, w.ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/config"
+)
+
+func TestRateLimiterRefillsTokens(t *testing.T) {
+	cfg, err := config.ParseConfig([]byte(`{"rateLimit": {"searchPerMinute": 60, "searchBurst": 2}}`))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+	limiter := newRateLimiter(cfg.RateLimit)
+	now := time.Unix(1_700_000_000, 0)
+	limiter.now = func() time.Time { return now }
+
+	for i := range 2 {
+		if _, ok := limiter.Allow("ip:a"); !ok {
+			t.Fatalf("expected burst request %d to pass", i+1)
+		}
+	}
+	if wait, ok := limiter.Allow("ip:a"); ok || wait != time.Second {
+		t.Fatalf("expected empty bucket to wait 1s, got %v %v", wait, ok)
+	}
+	if _, ok := limiter.Allow("ip:b"); !ok {
+		t.Fatalf("expected other callers to have their own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := limiter.Allow("ip:a"); !ok {
+		t.Fatalf("expected a token to be refilled after 1s")
+	}
+	if _, ok := limiter.Allow("ip:a"); ok {
+		t.Fatalf("expected only one token to be refilled")
+	}
+
+	disabled := false
+	limiter.Configure(config.RateLimitConfig{Enabled: &disabled})
+	if _, ok := limiter.Allow("ip:a"); !ok {
+		t.Fatalf("expected disabled limiter to allow requests")
+	}
+}
+
+func TestDownloadSlotsLimitConcurrency(t *testing.T) {
+	slots := newDownloadSlots()
+	if !slots.Acquire("user:a", 2) || !slots.Acquire("user:a", 2) {
+		t.Fatalf("expected two concurrent downloads to be allowed")
+	}
+	if slots.Acquire("user:a", 2) {
+		t.Fatalf("expected third concurrent download to be rejected")
+	}
+	if !slots.Acquire("user:b", 2) {
+		t.Fatalf("expected other callers to be unaffected")
+	}
+	slots.Release("user:a")
+	if !slots.Acquire("user:a", 2) {
+		t.Fatalf("expected released slot to be reusable")
+	}
+	if !slots.Acquire("user:a", 0) {
+		t.Fatalf("expected zero limit to disable the check")
+	}
+}
+
+func TestThrottledWriterLimitsBandwidth(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	writer := &throttledWriter{ResponseWriter: c.Writer, ctx: context.Background(), bytesPerSecond: 40 << 10, start: time.Now()}
+
+	payload := strings.Repeat("x", 20<<10)
+	start := time.Now()
+	if n, err := writer.Write([]byte(payload)); err != nil || n != len(payload) {
+		t.Fatalf("Write returned %d, %v", n, err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("expected 20 KiB at 40 KiB/s to take about 500ms, took %v", elapsed)
+	}
+	if recorder.Body.Len() != len(payload) {
+		t.Fatalf("expected full payload to be written, got %d bytes", recorder.Body.Len())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	canceled := &throttledWriter{ResponseWriter: c.Writer, ctx: ctx, bytesPerSecond: 1024, start: time.Now()}
+	if _, err := canceled.Write([]byte(payload)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled request to stop writing, got %v", err)
+	}
+}
+
+func TestSearchRateLimitPerCaller(t *testing.T) {
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+	headers := map[string]string{"Authorization": "Bearer " + loginToken(t, server)}
+
+	cfg := *server.currentConfig()
+	cfg.RateLimit.SearchPerMinute = 1
+	cfg.RateLimit.SearchBurst = 2
+	server.setConfig(&cfg)
+
+	path := "/api/v1/search?field=title&query=Go%20Systems"
+	for range 2 {
+		if resp := performRequest(server, http.MethodGet, path, "", nil); resp.Code != http.StatusOK {
+			t.Fatalf("expected burst search to pass, got %d", resp.Code)
+		}
+	}
+	limited := performRequest(server, http.MethodGet, path, "", nil)
+	if limited.Code != http.StatusTooManyRequests || limited.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected rate-limited search, got %d %v", limited.Code, limited.Header())
+	}
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		if resp := performRequest(server, http.MethodGet, path, "", map[string]string{"X-Forwarded-For": ip}); resp.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected forged X-Forwarded-For %s to share the client bucket, got %d", ip, resp.Code)
+		}
+	}
+	if resp := performRequest(server, http.MethodGet, path, "", headers); resp.Code != http.StatusOK {
+		t.Fatalf("expected logged-in caller to have a separate bucket, got %d", resp.Code)
+	}
+}
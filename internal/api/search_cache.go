@@ -2,50 +2,110 @@
 package api
 
 import (
+	"container/list"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unsafe"
 
+	"ebookdatabase/config"
 	"ebookdatabase/internal/core"
 )
 
+const (
+	minJanitorInterval = time.Second
+	maxJanitorInterval = time.Minute
+)
+
 type cacheEntry struct {
+	key       string
 	books     []core.CanonicalBook
 	total     int64
 	expiresAt time.Time
+	size      int64
 }
 
+// searchCache 是按条目数与估算字节数双重限制的 LRU 缓存，后台清理协程定期移除过期条目。
 type searchCache struct {
-	ttl   time.Duration
-	mu    sync.RWMutex
-	items map[string]cacheEntry
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	order      *list.List
+	items      map[string]*list.Element
+	now        func() time.Time
+
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+
+	stopJanitor chan struct{}
+	janitorDone chan struct{}
+}
+
+// searchCacheStats 是缓存的运行指标快照。
+type searchCacheStats struct {
+	Entries     int    `json:"entries"`
+	Bytes       int64  `json:"bytes"`
+	MaxEntries  int    `json:"maxEntries"`
+	MaxBytes    int64  `json:"maxBytes"`
+	TTLSeconds  int64  `json:"ttlSeconds"`
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
 }
 
-func newSearchCache(ttl time.Duration) *searchCache {
-	if ttl <= 0 {
-		ttl = 5 * time.Minute
+func newSearchCache(cfg config.SearchCacheConfig) *searchCache {
+	c := &searchCache{
+		order: list.New(),
+		items: make(map[string]*list.Element),
+		now:   time.Now,
 	}
-	return &searchCache{
-		ttl:   ttl,
-		items: make(map[string]cacheEntry),
+	c.Configure(cfg)
+	return c
+}
+
+// Configure 更新缓存的有效期与容量，容量缩小时立即淘汰最久未使用的条目。
+func (c *searchCache) Configure(cfg config.SearchCacheConfig) {
+	if c == nil {
+		return
 	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ttl = cfg.TTL()
+	if c.ttl <= 0 {
+		c.ttl = 5 * time.Minute
+	}
+	c.maxEntries = cfg.MaxEntries
+	c.maxBytes = cfg.MaxBytes
+	c.evictLocked()
 }
 
 func (c *searchCache) Get(key string) ([]core.CanonicalBook, int64, bool) {
 	if c == nil {
 		return nil, 0, false
 	}
-	c.mu.RLock()
-	entry, ok := c.items[key]
-	c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
 	if !ok {
+		c.misses.Add(1)
 		return nil, 0, false
 	}
-	if time.Now().After(entry.expiresAt) {
-		c.mu.Lock()
-		delete(c.items, key)
-		c.mu.Unlock()
+	entry := elem.Value.(*cacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.removeLocked(elem)
+		c.expirations.Add(1)
+		c.misses.Add(1)
 		return nil, 0, false
 	}
+	c.order.MoveToFront(elem)
+	c.hits.Add(1)
 	return cloneBooks(entry.books), entry.total, true
 }
 
@@ -53,14 +113,169 @@ func (c *searchCache) Set(key string, books []core.CanonicalBook, total int64) {
 	if c == nil {
 		return
 	}
-	entry := cacheEntry{
-		books:     cloneBooks(books),
-		total:     total,
-		expiresAt: time.Now().Add(c.ttl),
+	entry := &cacheEntry{
+		key:   key,
+		books: cloneBooks(books),
+		total: total,
+	}
+	entry.size = estimateEntrySize(entry)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.removeLocked(elem)
+	}
+	// 单个结果超过字节上限时不缓存，避免把其他条目全部挤出。
+	if c.maxBytes > 0 && entry.size > c.maxBytes {
+		return
+	}
+	entry.expiresAt = c.now().Add(c.ttl)
+	c.items[key] = c.order.PushFront(entry)
+	c.bytes += entry.size
+	c.evictLocked()
+}
+
+// Purge 清空所有缓存条目并返回被清除的数量。
+func (c *searchCache) Purge() int {
+	if c == nil {
+		return 0
 	}
 	c.mu.Lock()
-	c.items[key] = entry
+	defer c.mu.Unlock()
+	count := len(c.items)
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+	c.bytes = 0
+	return count
+}
+
+// Stats 返回当前缓存规模与累计命中、未命中、淘汰和过期次数。
+func (c *searchCache) Stats() searchCacheStats {
+	if c == nil {
+		return searchCacheStats{}
+	}
+	c.mu.Lock()
+	stats := searchCacheStats{
+		Entries:    len(c.items),
+		Bytes:      c.bytes,
+		MaxEntries: c.maxEntries,
+		MaxBytes:   c.maxBytes,
+		TTLSeconds: int64(c.ttl / time.Second),
+	}
 	c.mu.Unlock()
+
+	stats.Hits = c.hits.Load()
+	stats.Misses = c.misses.Load()
+	stats.Evictions = c.evictions.Load()
+	stats.Expirations = c.expirations.Load()
+	return stats
+}
+
+// StartJanitor 启动后台清理协程，按有效期的一半（限制在 1 秒到 1 分钟之间）定期移除过期条目。
+func (c *searchCache) StartJanitor() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	if c.stopJanitor != nil {
+		c.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	c.stopJanitor, c.janitorDone = stop, done
+	c.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		timer := time.NewTimer(c.janitorInterval())
+		defer timer.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-timer.C:
+				c.removeExpired()
+				timer.Reset(c.janitorInterval())
+			}
+		}
+	}()
+}
+
+// StopJanitor 停止后台清理协程并等待其退出。
+func (c *searchCache) StopJanitor() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	stop, done := c.stopJanitor, c.janitorDone
+	c.stopJanitor, c.janitorDone = nil, nil
+	c.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (c *searchCache) janitorInterval() time.Duration {
+	c.mu.Lock()
+	interval := c.ttl / 2
+	c.mu.Unlock()
+	return min(max(interval, minJanitorInterval), maxJanitorInterval)
+}
+
+// removeExpired 移除所有已过期的条目并返回移除数量。
+func (c *searchCache) removeExpired() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	removed := 0
+	for elem := c.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if now.After(elem.Value.(*cacheEntry).expiresAt) {
+			c.removeLocked(elem)
+			removed++
+		}
+		elem = prev
+	}
+	c.expirations.Add(uint64(removed))
+	return removed
+}
+
+// evictLocked 淘汰最久未使用的条目直到满足条目数与字节数上限，调用方需持有 mu。
+func (c *searchCache) evictLocked() {
+	for c.order.Len() > 0 &&
+		((c.maxEntries > 0 && c.order.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes)) {
+		c.removeLocked(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+func (c *searchCache) removeLocked(elem *list.Element) {
+	entry := c.order.Remove(elem).(*cacheEntry)
+	delete(c.items, entry.key)
+	c.bytes -= entry.size
+}
+
+// estimateEntrySize 粗略估算条目占用的内存：结构体本身加上所有字符串内容，足以用于容量控制。
+func estimateEntrySize(entry *cacheEntry) int64 {
+	size := int64(unsafe.Sizeof(*entry)) + int64(len(entry.key))
+	for _, book := range entry.books {
+		size += int64(unsafe.Sizeof(book))
+		size += int64(len(book.ID) + len(book.Title) + len(book.Description) + len(book.Publisher) +
+			len(book.PublishDate) + len(book.ISBN) + len(book.SSCode) + len(book.DXID) + len(book.Source))
+		for _, author := range book.Authors {
+			size += int64(unsafe.Sizeof(author)) + int64(len(author))
+		}
+		for _, tag := range book.Tags {
+			size += int64(unsafe.Sizeof(tag)) + int64(len(tag))
+		}
+	}
+	return size
 }
 
 func cloneBooks(src []core.CanonicalBook) []core.CanonicalBook {
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ebookdatabase/config"
+	"ebookdatabase/internal/core"
+)
+
+func TestSearchCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newSearchCache(config.SearchCacheConfig{TTLSeconds: 60, MaxEntries: 2, MaxBytes: 1 << 20})
+	books := []core.CanonicalBook{{ID: "1", Title: "Go"}}
+
+	cache.Set("a", books, 1)
+	cache.Set("b", books, 1)
+	if _, _, ok := cache.Get("a"); !ok {
+		t.Fatalf("expected a to be cached")
+	}
+	cache.Set("c", books, 1)
+
+	if _, _, ok := cache.Get("b"); ok {
+		t.Fatalf("expected least recently used entry b to be evicted")
+	}
+	if _, _, ok := cache.Get("a"); !ok {
+		t.Fatalf("expected recently used entry a to survive")
+	}
+
+	stats := cache.Stats()
+	if stats.Entries != 2 || stats.Evictions != 1 || stats.Hits != 2 || stats.Misses != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestSearchCacheRespectsByteLimit(t *testing.T) {
+	large := []core.CanonicalBook{{ID: "1", Title: strings.Repeat("x", 4096)}}
+	limit := estimateEntrySize(&cacheEntry{key: "a", books: large}) + 16
+	cache := newSearchCache(config.SearchCacheConfig{TTLSeconds: 60, MaxEntries: 100, MaxBytes: limit})
+
+	cache.Set("a", large, 1)
+	cache.Set("b", large, 1)
+	stats := cache.Stats()
+	if stats.Entries != 1 || stats.Bytes > limit {
+		t.Fatalf("expected byte limit to keep a single entry, got %+v", stats)
+	}
+	if _, _, ok := cache.Get("b"); !ok {
+		t.Fatalf("expected newest entry to be kept")
+	}
+
+	cache.Set("huge", []core.CanonicalBook{{Title: strings.Repeat("y", int(limit))}}, 1)
+	if _, _, ok := cache.Get("huge"); ok {
+		t.Fatalf("expected entry larger than the byte limit not to be cached")
+	}
+}
+
+func TestSearchCacheExpiresEntriesAndShrinks(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newSearchCache(config.SearchCacheConfig{TTLSeconds: 10, MaxEntries: 10, MaxBytes: 1 << 20})
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", nil, 0)
+	cache.Set("b", nil, 0)
+	now = now.Add(11 * time.Second)
+	cache.Set("c", nil, 0)
+
+	if removed := cache.removeExpired(); removed != 2 {
+		t.Fatalf("expected janitor to remove 2 expired entries, got %d", removed)
+	}
+	if stats := cache.Stats(); stats.Entries != 1 || stats.Expirations != 2 {
+		t.Fatalf("unexpected stats after expiry: %+v", stats)
+	}
+
+	cache.Set("d", nil, 0)
+	cache.Configure(config.SearchCacheConfig{TTLSeconds: 10, MaxEntries: 1, MaxBytes: 1 << 20})
+	if _, _, ok := cache.Get("d"); !ok {
+		t.Fatalf("expected most recent entry to survive shrinking")
+	}
+	if stats := cache.Stats(); stats.Entries != 1 || stats.MaxEntries != 1 {
+		t.Fatalf("unexpected stats after shrinking: %+v", stats)
+	}
+}
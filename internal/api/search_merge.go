@@ -103,3 +103,22 @@ func parseBookID(raw string) (int64, bool) {
 	}
 	return id, true
 }
+
+// mergeBooksByPriority 按数据源优先级分层归并：优先级较高的数据源结果整体排在前面，
+// 同一优先级内仍按 ID 倒序归并。groups 与 priorities 需按优先级降序一一对应。
+func mergeBooksByPriority(groups [][]core.CanonicalBook, priorities []int) []core.CanonicalBook {
+	if len(priorities) != len(groups) {
+		return mergeBooksByIDDesc(groups)
+	}
+
+	merged := make([]core.CanonicalBook, 0)
+	start := 0
+	for i := 1; i <= len(groups); i++ {
+		if i < len(groups) && priorities[i] == priorities[start] {
+			continue
+		}
+		merged = append(merged, mergeBooksByIDDesc(groups[start:i])...)
+		start = i
+	}
+	return merged
+}
@@ -0,0 +1,96 @@
+// path: internal/api/search_sources.go
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/config"
+	"ebookdatabase/search"
+)
+
+const (
+	skipReasonCircuitOpen = "circuit_open"
+	skipReasonTimeout     = "timeout"
+	skipReasonError       = "error"
+	skipReasonUnavailable = "unavailable"
+)
+
+// skippedSource 描述一次搜索中未能返回结果的数据源及原因。
+type skippedSource struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	Error  string `json:"error,omitempty"`
+}
+
+func sortSkippedSources(items []skippedSource) {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+}
+
+// resolveRequestedSources 解析 sources[] 参数并与调用者可检索的数据源比对，无权访问的数据源视为未知。
+// 未指定时返回全部可检索的数据源；返回顺序始终与 ListSources 一致，保证缓存键稳定。
+func (s *Server) resolveRequestedSources(c *gin.Context) ([]string, error) {
+	return selectRequestedSources(s.searchableSources(c), c.Request.URL.Query())
+}
+
+// selectRequestedSources 从 available 中挑出 values 的 sources[] 参数指定的数据源，不在 available 中的名称视为未知。
+func selectRequestedSources(available []string, values url.Values) ([]string, error) {
+	requested := normalizeValues(firstNonEmpty(values["sources[]"], values["sources"]))
+	if len(requested) == 0 {
+		return available, nil
+	}
+
+	known := make(map[string]struct{}, len(available))
+	for _, name := range available {
+		known[name] = struct{}{}
+	}
+
+	selected := make(map[string]struct{}, len(requested))
+	for _, name := range requested {
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("未知的数据源: %s", name)
+		}
+		selected[name] = struct{}{}
+	}
+
+	names := make([]string, 0, len(selected))
+	for _, name := range available {
+		if _, ok := selected[name]; ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// applySourceDefaults 在请求未指定 fuzzy 时套用数据源的 defaultFuzzy 配置，返回的参数为独立副本。
+func applySourceDefaults(params *search.QueryParams, dsConfig config.DatasourceConfig) *search.QueryParams {
+	if params == nil || dsConfig.DefaultFuzzy == nil {
+		return params
+	}
+
+	cloned := *params
+	cloned.Fuzzies = make([]*bool, len(params.Fields))
+	for i := range cloned.Fuzzies {
+		if i < len(params.Fuzzies) && params.Fuzzies[i] != nil {
+			cloned.Fuzzies[i] = params.Fuzzies[i]
+			continue
+		}
+		cloned.Fuzzies[i] = dsConfig.DefaultFuzzy
+	}
+	return &cloned
+}
+
+// cacheSourceKeys 为每个数据源附加 DBManager 中的实例代次，形如 "name#3"，用作缓存键的一部分。
+func (s *Server) cacheSourceKeys(sources []string) []string {
+	keys := make([]string, len(sources))
+	for i, name := range sources {
+		keys[i] = name + "#" + strconv.FormatUint(s.dbManager.Generation(name), 10)
+	}
+	return keys
+}
@@ -8,104 +8,289 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 
 	"ebookdatabase/config"
+	"ebookdatabase/internal/analytics"
+	"ebookdatabase/internal/audit"
+	"ebookdatabase/internal/auth"
 	"ebookdatabase/internal/core"
 	"ebookdatabase/internal/infra"
+	"ebookdatabase/internal/metrics"
+	"ebookdatabase/internal/shelves"
+	"ebookdatabase/search"
 	"ebookdatabase/utils"
 )
 
+// statusClientClosedRequest 沿用 nginx 的 499 表示客户端在结果返回前已断开。
+const statusClientClosedRequest = 499
+
 // Server 负责注册 Gin 路由并处理 API 请求。
 type Server struct {
 	engine     *gin.Engine
 	dbManager  *infra.DBManager
-	config     *config.Config
 	configPath string
-	jwtSecret  []byte
 	cache      *searchCache
+	searches   flightGroup[searchOutcome]
 	listenAddr string
+
+	httpServer *http.Server
+	// activeSearches 统计正在执行的数据源扇出，关闭时等待其归零。
+	activeSearches atomic.Int64
+
+	// configMu 保护运行中的配置及由其派生的状态，热加载与后台保存会整体替换它们。
+	configMu sync.RWMutex
+	config   *config.Config
+	cors     gin.HandlerFunc
+
+	watcher *config.Watcher
+
+	// auth 保存 JWT 签名密钥与吊销列表，随 Server 创建、在 Close 时关闭。
+	auth      *auth.Store
+	closeOnce sync.Once
+	// audit 只追加地记录后台操作与登录结果，与 auth 一同打开和关闭。
+	audit *audit.Store
+	// shelves 保存用户书架，与 auth 一同打开和关闭。
+	shelves *shelves.Store
+
+	// logins 限制登录失败后的重试频率。
+	logins *loginThrottle
+	// searchLimiter 与 downloads 按调用者限制搜索频率与同时下载数。
+	searchLimiter *rateLimiter
+	downloads     *downloadSlots
+
+	// analytics 在配置启用搜索统计时打开，为空时不记录搜索统计。
+	analytics *analytics.Store
+
+	// settingsMu 串行化所有写入设置文件的后台操作。
+	settingsMu sync.Mutex
 }
 
 // NewServer 根据配置构建 Server 并注册所有路由。
-func NewServer(cfg *config.Config, manager *infra.DBManager, configPath, listenAddr string, cacheTTL time.Duration) (*Server, error) {
+func NewServer(cfg *config.Config, manager *infra.DBManager, configPath, listenAddr string) (*Server, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("配置不能为空")
 	}
 	if manager == nil {
 		return nil, fmt.Errorf("数据库管理器不能为空")
 	}
+	authStore, err := auth.Open(cfg.InstancePath("auth.db"))
+	if err != nil {
+		return nil, fmt.Errorf("打开认证数据库失败: %w", err)
+	}
+	auditStore, err := audit.Open(cfg.InstancePath("audit.db"))
+	if err != nil {
+		authStore.Close()
+		return nil, fmt.Errorf("打开审计数据库失败: %w", err)
+	}
+	shelfStore, err := shelves.Open(cfg.InstancePath("shelves.db"))
+	if err != nil {
+		authStore.Close()
+		auditStore.Close()
+		return nil, fmt.Errorf("打开书架数据库失败: %w", err)
+	}
+
 	srv := &Server{
+		auth:       authStore,
+		audit:      auditStore,
+		shelves:    shelfStore,
 		dbManager:  manager,
-		config:     cfg,
 		configPath: configPath,
-		jwtSecret:  []byte(cfg.AdminPassword),
-		cache:      newSearchCache(cacheTTL),
+		cache:      newSearchCache(cfg.SearchCache),
+		logins:     newLoginThrottle(cfg.Auth.LoginThrottle),
 		listenAddr: listenAddr,
+
+		searchLimiter: newRateLimiter(cfg.RateLimit),
+		downloads:     newDownloadSlots(),
 	}
+	srv.setConfig(cfg)
+	srv.cache.StartJanitor()
+	srv.registerCacheMetrics()
 
+	// 只信任配置的反向代理转发的 X-Forwarded-For，否则客户端可以伪造 IP 绕过登录限制与限流并篡改审计记录。
 	engine := gin.New()
+	if err := engine.SetTrustedProxies(cfg.HTTP.TrustedProxies); err != nil {
+		srv.Close()
+		return nil, fmt.Errorf("配置可信代理失败: %w", err)
+	}
+	engine.Use(requestIDMiddleware())
+	engine.Use(accessLogMiddleware())
+	engine.Use(metricsMiddleware())
 	engine.Use(panicRecoveryMiddleware())
-	engine.Use(corsMiddleware(cfg, listenAddr))
+	engine.Use(srv.dynamicCORS)
 
 	engine.Static("/assets", "./frontend/dist/assets")
 	engine.StaticFile("/github-mark.svg", "./frontend/dist/github-mark.svg")
 	engine.StaticFile("/gitee-svgrepo-com.svg", "./frontend/dist/gitee-svgrepo-com.svg")
 	engine.StaticFile("/settings-icon.svg", "./frontend/dist/settings-icon.svg")
 	engine.StaticFile("/setting_logo.svg", "./frontend/dist/setting_logo.svg")
+	// 指标标签包含全部数据源名称与访问情况，仅限 admin 读取；Prometheus 应使用只能读取指标的 metrics 范围 API Key 抓取。
+	engine.GET("/metrics", srv.requireMetricsAccess, gin.WrapH(metrics.Default.Handler()))
 	engine.GET("/", srv.serveSPAIndex)
 	engine.NoRoute(srv.serveSPAIndex)
 
+	// 搜索、封面与下载是否需要登录由 auth.accessPolicy 决定，设置、健康检查与登录始终公开。
 	apiV1 := engine.Group("/api/v1")
 	{
-		apiV1.GET("/search", srv.handleSearch)
-		apiV1.GET("/available-dbs", srv.handleGetDatasources)
+		apiV1.GET("/search", srv.requireAccess(accessBrowse), srv.limitSearchRate, srv.handleSearch)
+		apiV1.GET("/available-dbs", srv.requireAccess(accessBrowse), srv.handleGetDatasources)
 		apiV1.GET("/settings", srv.handleGetSettings)
 		apiV1.GET("/health", srv.handleHealth)
 		apiV1.POST("/login", srv.handleLogin)
+		apiV1.POST("/logout", srv.RequireRole(auth.RoleGuest), srv.handleLogout)
+		apiV1.GET("/me", srv.RequireRole(auth.RoleGuest), srv.handleMe)
 		apiV1.GET("/qr-code-url", srv.handleGetQRCodeURL)
-		apiV1.GET("/download", srv.handleDownload)
-		apiV1.GET("/cover", srv.handleCover)
+		apiV1.GET("/download", srv.requireAccess(accessDownload), srv.limitDownloads, srv.handleDownload)
+		apiV1.GET("/cover", srv.requireAccess(accessBrowse), srv.handleCover)
 	}
 
+	// 书架属于调用者本人，任意角色登录后均可使用。
+	shelf := apiV1.Group("/shelves", srv.RequireRole(auth.RoleGuest))
+	{
+		shelf.GET("", srv.handleListShelves)
+		shelf.POST("", srv.handleCreateShelf)
+		shelf.GET("/:id", srv.handleGetShelf)
+		shelf.PATCH("/:id", srv.handleUpdateShelf)
+		shelf.DELETE("/:id", srv.handleDeleteShelf)
+		shelf.POST("/:id/books", srv.handleAddShelfEntry)
+		shelf.PATCH("/:id/books", srv.handleUpdateShelfEntry)
+		shelf.DELETE("/:id/books", srv.handleRemoveShelfEntry)
+	}
+
+	// 后台接口至少需要 librarian 角色，修改全局配置、日志、密钥、用户与 API Key 以及查看审计日志的接口仅限 admin。
 	admin := apiV1.Group("/admin")
-	admin.Use(srv.AuthMiddleware())
+	admin.Use(srv.RequireRole(auth.RoleLibrarian))
 	{
-		admin.GET("/config", srv.handleGetFullConfig)
-		admin.POST("/config", srv.handleSetFullConfig)
+		admin.GET("/datasources", srv.handleListDatasources)
+		admin.POST("/datasources", srv.handleCreateDatasource)
+		admin.POST("/datasources/test", srv.handleTestDatasourceConfig)
+		admin.GET("/datasources/:name", srv.handleGetDatasource)
+		admin.PUT("/datasources/:name", srv.handleUpdateDatasource)
+		admin.DELETE("/datasources/:name", srv.handleDeleteDatasource)
+		admin.POST("/datasources/:name/test", srv.handleTestDatasource)
+		admin.POST("/datasources/:name/reindex", srv.handleReindexDatasource)
+
+		admin.GET("/cache", srv.handleCacheStats)
+		admin.POST("/cache/flush", srv.handleFlushCache)
+
+		admin.GET("/analytics/search", srv.handleSearchAnalytics)
+	}
+
+	adminOnly := admin.Group("", srv.RequireRole(auth.RoleAdmin))
+	{
+		adminOnly.GET("/config", srv.handleGetFullConfig)
+		adminOnly.POST("/config", srv.handleSetFullConfig)
+		adminOnly.GET("/config/versions", srv.handleListConfigVersions)
+		adminOnly.POST("/config/rollback", srv.handleRollbackConfig)
+
+		adminOnly.GET("/logging", srv.handleGetLogging)
+		adminOnly.PUT("/logging", srv.handleUpdateLogging)
+
+		adminOnly.POST("/auth/rotate-key", srv.handleRotateSigningKey)
+
+		adminOnly.GET("/api-keys", srv.handleListAPIKeys)
+		adminOnly.POST("/api-keys", srv.handleCreateAPIKey)
+		adminOnly.DELETE("/api-keys/:id", srv.handleRevokeAPIKey)
+
+		adminOnly.GET("/users", srv.handleListUsers)
+		adminOnly.POST("/users", srv.handleCreateUser)
+		adminOnly.GET("/users/:username", srv.handleGetUser)
+		adminOnly.PATCH("/users/:username", srv.handleUpdateUser)
+		adminOnly.DELETE("/users/:username", srv.handleDeleteUser)
+
+		adminOnly.GET("/audit", srv.handleListAudit)
 	}
 
 	srv.engine = engine
+	srv.httpServer = newHTTPServer(engine, cfg.HTTP)
 	return srv, nil
 }
 
 func (s *Server) handleHealth(c *gin.Context) {
+	statuses := s.dbManager.HealthStatuses()
+	status := "ok"
+	for _, item := range statuses {
+		if !item.Healthy {
+			status = "degraded"
+			break
+		}
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"status":      "ok",
+		"status":      status,
 		"datasources": len(s.resolveSources()),
+		"sources":     statuses,
 	})
 }
 
-// Run 启动 HTTP 服务。
+// Run 监听 listenAddr 并阻塞到服务关闭，由 Shutdown 触发的正常关闭返回 nil。
 func (s *Server) Run() error {
+	ln, err := net.Listen("tcp", s.listenAddr)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %w", s.listenAddr, err)
+	}
+	return s.Serve(ln)
+}
+
+// Serve 在指定监听器上提供 HTTP 服务，便于测试使用随机端口。
+func (s *Server) Serve(ln net.Listener) error {
 	if s.engine == nil {
 		return fmt.Errorf("Gin 引擎尚未初始化")
 	}
-	if err := s.engine.Run(s.listenAddr); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		return fmt.Errorf("Gin 服务启动失败: %w", err)
+	if err := s.httpServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("HTTP 服务异常退出: %w", err)
+	}
+	return nil
+}
+
+// Shutdown 停止接受新连接，等待进行中的请求与后台搜索完成后停止配置监视等后台任务。
+// ctx 到期时强制关闭剩余连接并返回 ctx 的错误；数据源仍由 DBManager 的所有者在之后关闭。
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
+		_ = s.httpServer.Close()
+	}
+	if waitErr := s.waitBackgroundSearches(ctx); err == nil {
+		err = waitErr
+	}
+	s.Close()
+	return err
+}
+
+// waitBackgroundSearches 等待与请求解耦的数据源扇出结束，避免关闭数据源时仍有查询在执行。
+func (s *Server) waitBackgroundSearches(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for s.activeSearches.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
 	}
 	return nil
 }
 
+func newHTTPServer(handler http.Handler, cfg config.HTTPConfig) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout(),
+		ReadTimeout:       cfg.ReadTimeout(),
+		WriteTimeout:      cfg.WriteTimeout(),
+		IdleTimeout:       cfg.IdleTimeout(),
+	}
+}
+
 // Engine 暴露内部的 Gin 引擎，便于测试。
 func (s *Server) Engine() *gin.Engine {
 	return s.engine
@@ -115,7 +300,8 @@ func corsMiddleware(appConfig *config.Config, listenAddr string) gin.HandlerFunc
 	cfg := cors.DefaultConfig()
 	cfg.AllowCredentials = true
 	cfg.AddAllowMethods("PUT", "DELETE", "OPTIONS")
-	cfg.AddAllowHeaders("Authorization", "Content-Type", "X-Requested-With")
+	cfg.AddAllowHeaders("Authorization", "Content-Type", "X-Requested-With", requestIDHeader, apiKeyHeader)
+	cfg.AddExposeHeaders(requestIDHeader)
 	cfg.AllowOrigins = allowedOrigins(appConfig, listenAddr)
 	return cors.New(cfg)
 }
@@ -159,31 +345,41 @@ func (s *Server) serveSPAIndex(c *gin.Context) {
 }
 
 func (s *Server) handleGetDatasources(c *gin.Context) {
-	names := s.dbManager.ListSources()
+	names := s.searchableSources(c)
+	details := make([]gin.H, 0, len(names))
+	for _, name := range names {
+		dsConfig, _ := s.dbManager.DatasourceConfig(name)
+		details = append(details, gin.H{
+			"name":     name,
+			"label":    dsConfig.DisplayLabel(),
+			"type":     dsConfig.Type,
+			"priority": dsConfig.Priority,
+			"readOnly": dsConfig.ReadOnly,
+		})
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"available_dbs": names,
+		"datasources":   details,
 	})
 }
 
 func (s *Server) handleGetSettings(c *gin.Context) {
+	cfg := s.currentConfig()
 	c.JSON(http.StatusOK, gin.H{
-		"pageSize":           s.config.PageSize,
-		"defaultSearchField": s.config.DefaultSearchField,
-		"resultDisplayMode":  s.config.ResultDisplayMode,
-		"resultDensity":      s.config.ResultDensity,
-		"showCovers":         s.config.ShowCovers,
-		"showIdentifiers":    s.config.ShowIdentifiers,
+		"pageSize":           cfg.PageSize,
+		"defaultSearchField": cfg.DefaultSearchField,
+		"resultDisplayMode":  cfg.ResultDisplayMode,
+		"resultDensity":      cfg.ResultDensity,
+		"showCovers":         cfg.ShowCovers,
+		"showIdentifiers":    cfg.ShowIdentifiers,
+		"accessPolicy":       cfg.Auth.AccessPolicy,
 	})
 }
 
+// handleLogin 校验凭证并签发令牌：带 username 时校验用户账户，否则使用设置文件中的 adminPassword 以管理员身份登录。
 func (s *Server) handleLogin(c *gin.Context) {
-	if len(s.jwtSecret) == 0 {
-		slog.Error("管理员密码未配置或为空")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "管理员密码未配置"})
-		return
-	}
-
 	var payload struct {
+		Username string `json:"username"`
 		Password string `json:"password"`
 	}
 	if err := c.ShouldBindJSON(&payload); err != nil {
@@ -196,36 +392,82 @@ func (s *Server) handleLogin(c *gin.Context) {
 		return
 	}
 
-	if !s.verifyPassword(payload.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "密码错误"})
+	ip := c.ClientIP()
+	if wait, ok := s.logins.Allow(ip); !ok {
+		retryAfter := int((wait + time.Second - 1) / time.Second)
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "登录尝试过于频繁，请稍后再试", "retryAfterSeconds": retryAfter})
 		return
 	}
 
-	claims := jwt.MapClaims{
-		"sub": "admin",
-		"iat": time.Now().Unix(),
-		"exp": time.Now().Add(24 * time.Hour).Unix(),
+	cfg := s.currentConfig()
+	username := strings.TrimSpace(payload.Username)
+	// account 区分登录限速中的账号，失败与成功使用同一个值。
+	account := auth.SharedAdminSubject
+	if username != "" {
+		account = auth.UserSubject(username)
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString(s.jwtSecret)
+	var (
+		subject string
+		actor   = auth.SharedAdminSubject
+		role    = auth.RoleAdmin
+	)
+	if username != "" {
+		user, err := s.auth.Authenticate(username, payload.Password)
+		if err != nil {
+			if !errors.Is(err, auth.ErrInvalidCredentials) {
+				s.logins.Release(ip)
+				slog.ErrorContext(c.Request.Context(), "校验用户失败", slog.String("error", err.Error()))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "登录失败"})
+				return
+			}
+			s.recordLoginFailure(c, ip, username, account)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误"})
+			return
+		}
+		subject, actor, role = auth.UserSubject(user.Username), user.Username, user.Role
+	} else {
+		if cfg.AdminPassword == "" {
+			s.logins.Release(ip)
+			slog.ErrorContext(c.Request.Context(), "管理员密码未配置或为空")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "管理员密码未配置"})
+			return
+		}
+		if !s.verifyPassword(payload.Password) {
+			s.recordLoginFailure(c, ip, auth.SharedAdminSubject, account)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "密码错误"})
+			return
+		}
+		subject = auth.SharedAdminSubject
+	}
+
+	signed, claims, err := s.issueToken(subject)
 	if err != nil {
-		slog.Error("生成 JWT 失败", slog.String("error", err.Error()))
+		s.logins.Release(ip)
+		slog.ErrorContext(c.Request.Context(), "生成 JWT 失败", slog.String("error", err.Error()))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成凭证失败"})
 		return
 	}
+	// 令牌签发成功后才算登录成功，避免审计日志记录调用者并未拿到凭证的登录。
+	s.logins.Success(ip, account)
+	s.appendAudit(c, audit.Entry{Actor: actor, Role: string(role), ClientIP: ip, Action: auditLoginSuccess}, nil)
 
-	c.JSON(http.StatusOK, gin.H{"token": signed})
+	c.JSON(http.StatusOK, gin.H{"token": signed, "expiresAt": claims.ExpiresAt.Time, "role": role})
 }
 
+// handleGetFullConfig 返回设置文件内容，adminPassword 等敏感字段以 config.SecretUnchanged 代替。
 func (s *Server) handleGetFullConfig(c *gin.Context) {
 	payload, err := s.readSettings()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	config.RedactSecrets(payload)
 	c.JSON(http.StatusOK, payload)
 }
 
+// handleSetFullConfig 保存完整设置：敏感字段提交 config.SecretUnchanged 时沿用已保存的值，
+// 明文的 adminPassword（包括沿用的旧值）一律以 bcrypt 哈希写入设置文件。
 func (s *Server) handleSetFullConfig(c *gin.Context) {
 	var payload map[string]any
 	if err := c.ShouldBindJSON(&payload); err != nil {
@@ -233,48 +475,54 @@ func (s *Server) handleSetFullConfig(c *gin.Context) {
 		return
 	}
 
-	bytes, err := json.MarshalIndent(payload, "", "  ")
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	previous, err := s.readSettings()
 	if err != nil {
-		slog.Error("序列化配置失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	config.RestoreSecrets(payload, previous)
+	config.KeepAdminPasswordHash(payload, previous)
+	if err := config.HashAdminPassword(payload); err != nil {
+		slog.ErrorContext(c.Request.Context(), "生成管理员密码哈希失败", slog.String("error", err.Error()))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存配置失败"})
 		return
 	}
 
-	if err := os.WriteFile(s.configPath, bytes, 0o644); err != nil {
-		slog.Error("写入配置失败", slog.String("error", err.Error()))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "写入配置文件失败"})
+	bytes, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "序列化配置失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存配置失败"})
 		return
 	}
 
-	cfg, err := config.LoadConfig(s.configPath)
+	cfg, err := config.ValidateConfig(bytes)
 	if err != nil {
-		slog.Error("重新加载配置失败", slog.String("error", err.Error()))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "配置刷新失败"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := s.dbManager.InitFromConfig(cfg); err != nil {
-		slog.Error("重新初始化数据源失败", slog.String("error", err.Error()))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "数据源初始化失败"})
+	if err := s.applySettings(bytes, cfg); err != nil {
+		s.respondApplyError(c, err)
 		return
 	}
-
-	s.config = cfg
-	s.jwtSecret = []byte(cfg.AdminPassword)
+	s.recordAudit(c, auditConfigUpdate, "", auditChanges(c, previous, bytes))
 
 	latest, err := s.readSettings()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-
+	config.RedactSecrets(latest)
 	c.JSON(http.StatusOK, latest)
 }
 
 func (s *Server) handleGetQRCodeURL(c *gin.Context) {
 	ip, err := utils.GetLocalIP()
 	if err != nil {
-		slog.Error("获取本地 IP 失败", slog.String("error", err.Error()))
+		slog.ErrorContext(c.Request.Context(), "获取本地 IP 失败", slog.String("error", err.Error()))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取本地 IP"})
 		return
 	}
@@ -283,48 +531,13 @@ func (s *Server) handleGetQRCodeURL(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"url": url})
 }
 
-func (s *Server) AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		secret := s.jwtSecret
-		if len(secret) == 0 {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
-			return
-		}
-
-		header := c.GetHeader("Authorization")
-		if header == "" || !strings.HasPrefix(header, "Bearer ") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
-			return
-		}
-
-		tokenString := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
-		if tokenString == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
-			return
-		}
-
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %T", token.Method)
-			}
-			return secret, nil
-		})
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
-			return
-		}
-
-		c.Next()
-	}
-}
-
 func (s *Server) verifyPassword(candidate string) bool {
-	stored := s.config.AdminPassword
+	stored := s.currentConfig().AdminPassword
 	if stored == "" {
 		return false
 	}
 
-	if isBcryptHash(stored) {
+	if config.IsBcryptHash(stored) {
 		if err := bcrypt.CompareHashAndPassword([]byte(stored), []byte(candidate)); err != nil {
 			return false
 		}
@@ -338,10 +551,6 @@ func (s *Server) verifyPassword(candidate string) bool {
 	return subtle.ConstantTimeCompare([]byte(candidate), []byte(stored)) == 1
 }
 
-func isBcryptHash(value string) bool {
-	return strings.HasPrefix(value, "$2a$") || strings.HasPrefix(value, "$2b$") || strings.HasPrefix(value, "$2y$")
-}
-
 func (s *Server) readSettings() (map[string]any, error) {
 	data, err := os.ReadFile(s.configPath)
 	if err != nil {
@@ -358,6 +567,15 @@ func (s *Server) readSettings() (map[string]any, error) {
 	return payload, nil
 }
 
+// writeSettings 原子写入设置文件，并把被覆盖的内容归档为历史版本以便回滚。
+func (s *Server) writeSettings(data []byte) error {
+	if err := config.SaveVersioned(s.configPath, data, s.currentConfig().SettingsHistoryLimit); err != nil {
+		slog.Error("写入配置失败", slog.String("error", err.Error()))
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	return nil
+}
+
 func (s *Server) resolveSources() []string {
 	return s.dbManager.ListSources()
 }
@@ -370,6 +588,9 @@ func (s *Server) handleDownload(c *gin.Context) {
 		return
 	}
 
+	if !s.authorizeSource(c, source, true) {
+		return
+	}
 	datasource, ok := s.dbManager.GetDatasource(source)
 	if !ok {
 		c.JSON(http.StatusNotFound, gin.H{"error": "数据源不存在"})
@@ -383,6 +604,7 @@ func (s *Server) handleDownload(c *gin.Context) {
 	}
 
 	c.File(path)
+	recordFileServed(c, "download", source)
 }
 
 func (s *Server) handleCover(c *gin.Context) {
@@ -393,6 +615,9 @@ func (s *Server) handleCover(c *gin.Context) {
 		return
 	}
 
+	if !s.authorizeSource(c, source, false) {
+		return
+	}
 	datasource, ok := s.dbManager.GetDatasource(source)
 	if !ok {
 		c.JSON(http.StatusNotFound, gin.H{"error": "数据源不存在"})
@@ -406,6 +631,7 @@ func (s *Server) handleCover(c *gin.Context) {
 	}
 
 	c.File(path)
+	recordFileServed(c, "cover", source)
 }
 
 func (s *Server) handleSearch(c *gin.Context) {
@@ -417,7 +643,11 @@ func (s *Server) handleSearch(c *gin.Context) {
 
 	pageSize := params.PageSize
 
-	sources := s.resolveSources()
+	sources, err := s.resolveRequestedSources(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if len(sources) == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "没有可用的数据源"})
 		return
@@ -425,22 +655,67 @@ func (s *Server) handleSearch(c *gin.Context) {
 
 	start := time.Now()
 
-	cacheKey := ""
-	if s.cache != nil {
-		cacheKey = buildSearchCacheKey(params, sources)
-		if books, total, ok := s.cache.Get(cacheKey); ok {
-			elapsed := time.Since(start).Milliseconds()
-			c.JSON(http.StatusOK, gin.H{
-				"books":        books,
-				"totalPages":   computeTotalPages(total, pageSize),
-				"totalRecords": total,
-				"searchTimeMs": elapsed,
-			})
-			return
-		}
+	cacheKey := buildSearchCacheKey(params, s.cacheSourceKeys(sources))
+	if books, total, ok := s.cache.Get(cacheKey); ok {
+		s.restrictDownloads(c, books)
+		s.recordSearch(params, total, time.Since(start), true)
+		elapsed := time.Since(start).Milliseconds()
+		c.JSON(http.StatusOK, gin.H{
+			"books":          books,
+			"totalPages":     computeTotalPages(total, pageSize),
+			"totalRecords":   total,
+			"searchTimeMs":   elapsed,
+			"skippedSources": []skippedSource{},
+		})
+		return
+	}
+
+	// 相同缓存键的并发请求共享一次数据源扇出，单个请求取消不会影响其他等待者。
+	outcome, err := s.searches.Do(c.Request.Context(), cacheKey, func(ctx context.Context) (searchOutcome, error) {
+		s.activeSearches.Add(1)
+		defer s.activeSearches.Add(-1)
+		return s.fanOutSearch(ctx, params, sources, cacheKey), nil
+	})
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "搜索请求已取消", slog.String("error", err.Error()))
+		c.JSON(statusClientClosedRequest, gin.H{"error": "请求已取消"})
+		return
+	}
+
+	if outcome.succeeded == 0 {
+		slog.ErrorContext(c.Request.Context(), "搜索失败，所有数据源均不可用", slog.Any("skippedSources", outcome.skipped))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":          "所有数据源均不可用",
+			"skippedSources": outcome.skipped,
+		})
+		return
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	books := cloneBooks(outcome.books)
+	s.restrictDownloads(c, books)
+	s.recordSearch(params, outcome.total, time.Since(start), false)
+	elapsed := time.Since(start).Milliseconds()
+
+	c.JSON(http.StatusOK, gin.H{
+		"books":          books,
+		"totalPages":     computeTotalPages(outcome.total, pageSize),
+		"totalRecords":   outcome.total,
+		"searchTimeMs":   elapsed,
+		"skippedSources": outcome.skipped,
+	})
+}
+
+// searchOutcome 是一次数据源扇出的结果，可能被多个合并的请求共享，使用方不得修改其中的切片。
+type searchOutcome struct {
+	books     []core.CanonicalBook
+	total     int64
+	skipped   []skippedSource
+	succeeded int
+}
+
+// fanOutSearch 并发查询所有数据源并按优先级归并结果。部分数据源被跳过时结果不完整，不写入缓存。
+func (s *Server) fanOutSearch(parent context.Context, params *search.QueryParams, sources []string, cacheKey string) searchOutcome {
+	ctx, cancel := context.WithTimeout(parent, 10*time.Second)
 	defer cancel()
 
 	type searchResult struct {
@@ -448,24 +723,43 @@ func (s *Server) handleSearch(c *gin.Context) {
 		total int64
 		err   error
 		order int
+		name  string
 	}
 
 	results := make(chan searchResult, len(sources))
 	var wg sync.WaitGroup
+	skipped := make([]skippedSource, 0)
+	priorities := make([]int, len(sources))
 
 	for idx, name := range sources {
+		// 被跳过的数据源也要带上优先级，否则会把同一优先级的相邻数据源分隔成两组。
+		dsConfig, _ := s.dbManager.DatasourceConfig(name)
+		priorities[idx] = dsConfig.Priority
+
 		datasource, ok := s.dbManager.GetDatasource(name)
 		if !ok {
-			results <- searchResult{err: fmt.Errorf("数据源 %s 未初始化", name)}
+			skipped = append(skipped, skippedSource{Name: name, Reason: skipReasonUnavailable, Error: "数据源未初始化"})
+			continue
+		}
+		if !s.dbManager.Allow(name) {
+			skipped = append(skipped, skippedSource{Name: name, Reason: skipReasonCircuitOpen})
 			continue
 		}
 
 		wg.Add(1)
-		go func(order int, dsName string, src core.Datasource) {
+		go func(order int, dsName string, src core.Datasource, dsConfig config.DatasourceConfig) {
 			defer wg.Done()
-			books, total, err := src.Search(ctx, params)
+			srcCtx := ctx
+			if timeout := dsConfig.QueryTimeout(); timeout > 0 {
+				var srcCancel context.CancelFunc
+				srcCtx, srcCancel = context.WithTimeout(ctx, timeout)
+				defer srcCancel()
+			}
+			queryStart := time.Now()
+			books, total, err := src.Search(srcCtx, applySourceDefaults(params, dsConfig))
+			metrics.DatasourceQueryDuration.Observe(time.Since(queryStart).Seconds(), dsName)
 			if err != nil {
-				results <- searchResult{err: fmt.Errorf("数据源 %s 搜索失败: %w", dsName, err)}
+				results <- searchResult{err: err, order: order, name: dsName}
 				return
 			}
 
@@ -477,54 +771,59 @@ func (s *Server) handleSearch(c *gin.Context) {
 				}
 			}
 
-			results <- searchResult{books: normalized, total: total, order: order}
-		}(idx, name, datasource)
+			results <- searchResult{books: normalized, total: total, order: order, name: dsName}
+		}(idx, name, datasource, dsConfig)
 	}
 
 	wg.Wait()
 	close(results)
 
 	combinedBySource := make([][]core.CanonicalBook, len(sources))
-	var (
-		totalRecords int64
-		errs         []error
-	)
+	var outcome searchOutcome
 
 	for res := range results {
 		if res.err != nil {
-			errs = append(errs, res.err)
+			reason := skipReasonError
+			if errors.Is(res.err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				reason = skipReasonTimeout
+			}
+			// 所有等待者都已取消时失败源于取消本身，不计入熔断，但要让出半开状态下的试探名额。
+			if parent.Err() == nil {
+				s.dbManager.ReportFailure(res.name, res.err)
+			} else {
+				s.dbManager.ReportCancelled(res.name)
+			}
+			slog.WarnContext(ctx, "数据源搜索失败，已跳过",
+				slog.String("datasource", res.name),
+				slog.String("reason", reason),
+				slog.String("error", res.err.Error()),
+			)
+			skipped = append(skipped, skippedSource{Name: res.name, Reason: reason, Error: res.err.Error()})
 			continue
 		}
-		totalRecords += res.total
-		if res.order >= 0 && res.order < len(combinedBySource) {
-			combinedBySource[res.order] = res.books
-		} else {
-			combinedBySource = append(combinedBySource, res.books)
-		}
+		s.dbManager.ReportSuccess(res.name)
+		metrics.DatasourceResults.Add(float64(len(res.books)), res.name)
+		outcome.succeeded++
+		outcome.total += res.total
+		combinedBySource[res.order] = res.books
 	}
 
-	if len(errs) > 0 {
-		combinedErr := errors.Join(errs...)
-		slog.Error("搜索失败", slog.String("error", combinedErr.Error()))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": combinedErr.Error()})
-		return
+	sortSkippedSources(skipped)
+	for _, item := range skipped {
+		metrics.DatasourceQueryErrors.Inc(item.Name, item.Reason)
 	}
-
-	pageItems := mergeBooksByIDDesc(combinedBySource)
-	if totalRecords < int64(len(pageItems)) {
-		totalRecords = int64(len(pageItems))
+	outcome.skipped = skipped
+	if outcome.succeeded == 0 {
+		return outcome
 	}
 
-	elapsed := time.Since(start).Milliseconds()
-
-	c.JSON(http.StatusOK, gin.H{
-		"books":        pageItems,
-		"totalPages":   computeTotalPages(totalRecords, pageSize),
-		"totalRecords": totalRecords,
-		"searchTimeMs": elapsed,
-	})
+	outcome.books = mergeBooksByPriority(combinedBySource, priorities)
+	if outcome.total < int64(len(outcome.books)) {
+		outcome.total = int64(len(outcome.books))
+	}
 
-	if s.cache != nil && cacheKey != "" {
-		s.cache.Set(cacheKey, pageItems, totalRecords)
+	if len(skipped) == 0 {
+		s.cache.Set(cacheKey, outcome.books, outcome.total)
 	}
+	return outcome
 }
@@ -1,20 +1,35 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	_ "modernc.org/sqlite"
 
 	"ebookdatabase/config"
+	"ebookdatabase/internal/analytics"
+	"ebookdatabase/internal/auth"
+	"ebookdatabase/internal/core"
 	"ebookdatabase/internal/infra"
+	"ebookdatabase/logger"
+	"ebookdatabase/search"
 )
 
 func TestPublicAPIAndAdminAuth(t *testing.T) {
@@ -180,7 +195,7 @@ func TestPublicAPIAndAdminAuth(t *testing.T) {
   "adminPassword": "secret",
   "corsAllowedOrigins": ["http://localhost:5173"],
   "datasources": [
-    {"name": "legacy", "type": "legacy_db", "path": "` + filepath.ToSlash(server.config.Datasources[0].Path) + `"}
+    {"name": "legacy", "type": "legacy_db", "path": "` + filepath.ToSlash(server.currentConfig().Datasources[0].Path) + `"}
   ]
 }`
 	saveConfig := performRequest(server, http.MethodPost, "/api/v1/admin/config", updatedConfig, map[string]string{
@@ -276,6 +291,7 @@ func TestSearchSupportsMergedLegacySchema(t *testing.T) {
   "pageSize": 5,
   "defaultSearchField": "title",
   "adminPassword": "secret",
+  "instanceDir": "` + filepath.ToSlash(filepath.Join(dir, "instance")) + `",
   "datasources": [
     {"name": "merged", "type": "legacy_db", "path": "` + filepath.ToSlash(dbPath) + `"}
   ]
@@ -295,7 +311,7 @@ func TestSearchSupportsMergedLegacySchema(t *testing.T) {
 	}
 	defer manager.Close()
 
-	server, err := NewServer(cfg, manager, configPath, ":10223", time.Minute)
+	server, err := NewServer(cfg, manager, configPath, ":10223")
 	if err != nil {
 		t.Fatalf("NewServer returned error: %v", err)
 	}
@@ -320,6 +336,1222 @@ func TestSearchSupportsMergedLegacySchema(t *testing.T) {
 	}
 }
 
+func TestSearchSkipsFailingDatasource(t *testing.T) {
+	server, paths, cleanup := newMultiSourceTestServer(t, "healthy", "broken")
+	defer cleanup()
+	brokenPath := paths["broken"]
+
+	db, err := sql.Open("sqlite", brokenPath)
+	if err != nil {
+		t.Fatalf("failed to open broken db: %v", err)
+	}
+	if _, err := db.Exec("DROP TABLE books"); err != nil {
+		t.Fatalf("failed to drop books table: %v", err)
+	}
+	_ = db.Close()
+
+	type searchPayload struct {
+		Books          []map[string]any `json:"books"`
+		TotalRecords   int              `json:"totalRecords"`
+		SkippedSources []struct {
+			Name   string `json:"name"`
+			Reason string `json:"reason"`
+		} `json:"skippedSources"`
+	}
+
+	for attempt := 0; attempt < 4; attempt++ {
+		search := performRequest(server, http.MethodGet, "/api/v1/search?field=title&query=Go%20Systems", "", nil)
+		if search.Code != http.StatusOK {
+			t.Fatalf("attempt %d: search status = %d, body = %s", attempt, search.Code, search.Body.String())
+		}
+		var payload searchPayload
+		if err := json.Unmarshal(search.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("failed to decode search response: %v", err)
+		}
+		if payload.TotalRecords != 1 || len(payload.Books) != 1 || payload.Books[0]["source"] != "healthy" {
+			t.Fatalf("attempt %d: unexpected books: %+v", attempt, payload)
+		}
+		if len(payload.SkippedSources) != 1 || payload.SkippedSources[0].Name != "broken" {
+			t.Fatalf("attempt %d: unexpected skipped sources: %+v", attempt, payload.SkippedSources)
+		}
+		wantReason := "error"
+		if attempt >= 3 {
+			wantReason = "circuit_open"
+		}
+		if payload.SkippedSources[0].Reason != wantReason {
+			t.Fatalf("attempt %d: skipped reason = %q, want %q", attempt, payload.SkippedSources[0].Reason, wantReason)
+		}
+	}
+
+	health := performRequest(server, http.MethodGet, "/api/v1/health", "", nil)
+	var healthPayload struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(health.Body.Bytes(), &healthPayload); err != nil {
+		t.Fatalf("failed to decode health response: %v", err)
+	}
+	if healthPayload.Status != "degraded" {
+		t.Fatalf("expected degraded health, got %+v", healthPayload)
+	}
+}
+
+func TestSearchMergesSamePriorityAroundSkippedSource(t *testing.T) {
+	server, paths, cleanup := newMultiSourceTestServer(t, "alpha", "beta", "gamma")
+	defer cleanup()
+
+	extra := map[string]string{
+		"alpha": `INSERT INTO books (id, title) VALUES (3, 'Go Patterns')`,
+		"gamma": `UPDATE books SET id = 2 WHERE id = 1`,
+	}
+	for name, statement := range extra {
+		db, err := sql.Open("sqlite", paths[name])
+		if err != nil {
+			t.Fatalf("failed to open %s db: %v", name, err)
+		}
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("failed to update %s db: %v", name, err)
+		}
+		_ = db.Close()
+	}
+
+	cfg := *server.currentConfig()
+	cfg.Datasources = append([]config.DatasourceConfig(nil), cfg.Datasources...)
+	for i := range cfg.Datasources {
+		cfg.Datasources[i].Priority = 10
+	}
+	if err := server.ApplyConfig(&cfg); err != nil {
+		t.Fatalf("ApplyConfig returned error: %v", err)
+	}
+	for range 10 {
+		if !server.dbManager.Allow("beta") {
+			break
+		}
+		server.dbManager.ReportFailure("beta", errors.New("boom"))
+	}
+
+	resp := performRequest(server, http.MethodGet, "/api/v1/search?field=title&query=Go&fuzzy=true", "", nil)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("search status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	var payload struct {
+		Books []struct {
+			ID     string `json:"id"`
+			Source string `json:"source"`
+		} `json:"books"`
+		SkippedSources []struct {
+			Name   string `json:"name"`
+			Reason string `json:"reason"`
+		} `json:"skippedSources"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("failed to decode search response: %v", err)
+	}
+	if len(payload.SkippedSources) != 1 || payload.SkippedSources[0].Name != "beta" || payload.SkippedSources[0].Reason != "circuit_open" {
+		t.Fatalf("unexpected skipped sources: %+v", payload.SkippedSources)
+	}
+	var order []string
+	for _, book := range payload.Books {
+		order = append(order, book.Source+"/"+book.ID)
+	}
+	if got := strings.Join(order, ","); got != "alpha/3,gamma/2,alpha/1" {
+		t.Fatalf("expected same-priority sources to be merged by id, got %s", got)
+	}
+}
+
+func TestCancelledSearchReleasesCircuitBreakerTrial(t *testing.T) {
+	server, _, cleanup := newMultiSourceTestServer(t, "alpha", "beta")
+	defer cleanup()
+
+	now := time.Now()
+	server.dbManager.SetClock(func() time.Time { return now })
+	for range 10 {
+		if !server.dbManager.Allow("beta") {
+			break
+		}
+		server.dbManager.ReportFailure("beta", errors.New("boom"))
+	}
+	now = now.Add(time.Hour)
+
+	params, err := server.parseQueryParams(url.Values{"field": {"title"}, "query": {"Go Systems"}})
+	if err != nil {
+		t.Fatalf("parseQueryParams returned error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	outcome := server.fanOutSearch(ctx, params, []string{"beta"}, "cancelled")
+	if outcome.succeeded != 0 || len(outcome.skipped) != 1 || outcome.skipped[0].Reason == skipReasonCircuitOpen {
+		t.Fatalf("expected the cancelled trial to reach beta and fail, got %+v", outcome)
+	}
+
+	resp := performRequest(server, http.MethodGet, "/api/v1/search?field=title&query=Go%20Systems&sources[]=beta", "", nil)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected beta to be queried after the cancelled trial, got %d: %s", resp.Code, resp.Body.String())
+	}
+	if status := server.dbManager.HealthStatuses(); len(status) != 2 || status[1].Name != "beta" || !status[1].Healthy {
+		t.Fatalf("expected successful trial to close the breaker, got %+v", status)
+	}
+}
+
+func TestSearchRestrictsToRequestedSources(t *testing.T) {
+	server, _, cleanup := newMultiSourceTestServer(t, "alpha", "beta")
+	defer cleanup()
+
+	all := performRequest(server, http.MethodGet, "/api/v1/search?field=title&query=Go%20Systems", "", nil)
+	if all.Code != http.StatusOK {
+		t.Fatalf("search all status = %d, body = %s", all.Code, all.Body.String())
+	}
+	var allPayload struct {
+		TotalRecords int `json:"totalRecords"`
+	}
+	if err := json.Unmarshal(all.Body.Bytes(), &allPayload); err != nil {
+		t.Fatalf("failed to decode search response: %v", err)
+	}
+	if allPayload.TotalRecords != 2 {
+		t.Fatalf("expected results from both sources, got %+v", allPayload)
+	}
+
+	selected := performRequest(server, http.MethodGet, "/api/v1/search?field=title&query=Go%20Systems&sources[]=beta", "", nil)
+	if selected.Code != http.StatusOK {
+		t.Fatalf("search selected status = %d, body = %s", selected.Code, selected.Body.String())
+	}
+	var selectedPayload struct {
+		Books        []map[string]any `json:"books"`
+		TotalRecords int              `json:"totalRecords"`
+	}
+	if err := json.Unmarshal(selected.Body.Bytes(), &selectedPayload); err != nil {
+		t.Fatalf("failed to decode search response: %v", err)
+	}
+	if selectedPayload.TotalRecords != 1 || len(selectedPayload.Books) != 1 || selectedPayload.Books[0]["source"] != "beta" {
+		t.Fatalf("expected only beta results, got %+v", selectedPayload)
+	}
+
+	unknown := performRequest(server, http.MethodGet, "/api/v1/search?field=title&query=Go&sources[]=missing", "", nil)
+	if unknown.Code != http.StatusBadRequest {
+		t.Fatalf("unknown source status = %d, body = %s", unknown.Code, unknown.Body.String())
+	}
+}
+
+func TestBuildSearchCacheKeyIncludesSources(t *testing.T) {
+	params := &search.QueryParams{Fields: []string{"title"}, Queries: []string{"Go"}, Page: 1, PageSize: 5}
+	if buildSearchCacheKey(params, []string{"alpha", "beta"}) == buildSearchCacheKey(params, []string{"beta"}) {
+		t.Fatalf("expected cache key to differ by source selection")
+	}
+}
+
+func TestSearchCacheFollowsDatasourceGenerationAndFlush(t *testing.T) {
+	server, paths, cleanup := newMultiSourceTestServer(t, "alpha")
+	defer cleanup()
+	headers := map[string]string{"Authorization": "Bearer " + loginToken(t, server)}
+
+	countResults := func(query string) int {
+		t.Helper()
+		resp := performRequest(server, http.MethodGet, "/api/v1/search?field=title&"+query, "", nil)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("search status = %d, body = %s", resp.Code, resp.Body.String())
+		}
+		var payload struct {
+			TotalRecords int `json:"totalRecords"`
+		}
+		if err := json.Unmarshal(resp.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("failed to decode search response: %v", err)
+		}
+		return payload.TotalRecords
+	}
+	insertBook := func(id int, title string) {
+		t.Helper()
+		db, err := sql.Open("sqlite", paths["alpha"])
+		if err != nil {
+			t.Fatalf("failed to open db: %v", err)
+		}
+		defer db.Close()
+		if _, err := db.Exec(`INSERT INTO books (id, title, author) VALUES (?, ?, 'Carol')`, id, title); err != nil {
+			t.Fatalf("failed to insert book: %v", err)
+		}
+	}
+
+	if got := countResults("query=Go&fuzzy=true"); got != 1 {
+		t.Fatalf("expected 1 result, got %d", got)
+	}
+	insertBook(2, "Go Rewritten")
+	if got := countResults("query=Go&fuzzy=true"); got != 1 {
+		t.Fatalf("expected cached result before reindex, got %d", got)
+	}
+
+	dsConfig, _ := server.dbManager.DatasourceConfig("alpha")
+	before := server.dbManager.Generation("alpha")
+	if err := server.dbManager.ReindexSource(context.Background(), dsConfig); err != nil {
+		t.Fatalf("ReindexSource returned error: %v", err)
+	}
+	if server.dbManager.Generation("alpha") == before {
+		t.Fatalf("expected reindex to bump datasource generation")
+	}
+	if got := countResults("query=Go&fuzzy=true"); got != 2 {
+		t.Fatalf("expected fresh results after reindex, got %d", got)
+	}
+
+	if got := countResults("query=Go%20Again"); got != 0 {
+		t.Fatalf("expected no exact match yet, got %d", got)
+	}
+	insertBook(3, "Go Again")
+	if got := countResults("query=Go%20Again"); got != 0 {
+		t.Fatalf("expected cached empty result, got %d", got)
+	}
+
+	if resp := performRequest(server, http.MethodPost, "/api/v1/admin/cache/flush", "", nil); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected flush without token to be rejected, got %d", resp.Code)
+	}
+	flush := performRequest(server, http.MethodPost, "/api/v1/admin/cache/flush", "", headers)
+	if flush.Code != http.StatusOK || !strings.Contains(flush.Body.String(), `"flushed"`) {
+		t.Fatalf("unexpected flush response: %d %s", flush.Code, flush.Body.String())
+	}
+	if got := countResults("query=Go%20Again"); got != 1 {
+		t.Fatalf("expected fresh result after flush, got %d", got)
+	}
+}
+
+func TestMetricsEndpointExposesSearchAndHTTPMetrics(t *testing.T) {
+	server, _, cleanup := newMultiSourceTestServer(t, "metered")
+	defer cleanup()
+
+	for i := 0; i < 2; i++ {
+		if resp := performRequest(server, http.MethodGet, "/api/v1/search?field=title&query=Go%20Systems", "", nil); resp.Code != http.StatusOK {
+			t.Fatalf("search status = %d", resp.Code)
+		}
+	}
+
+	if resp := performRequest(server, http.MethodGet, "/metrics", "", nil); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected metrics to require admin credentials, got %d", resp.Code)
+	}
+	if resp := performRequest(server, http.MethodGet, "/metrics", "", map[string]string{"Authorization": "Bearer " + loginToken(t, server)}); resp.Code != http.StatusOK {
+		t.Fatalf("expected admin to read metrics, got %d", resp.Code)
+	}
+	performRequest(server, "BREW", "/api/v1/search", "", nil)
+
+	_, key, err := server.auth.CreateAPIKey("prometheus", auth.ScopeMetrics, "admin")
+	if err != nil {
+		t.Fatalf("CreateAPIKey returned error: %v", err)
+	}
+	scraper := map[string]string{"Authorization": "Bearer " + key}
+	for _, path := range []string{"/api/v1/me", "/api/v1/admin/config"} {
+		if resp := performRequest(server, http.MethodGet, path, "", scraper); resp.Code != http.StatusUnauthorized {
+			t.Fatalf("expected metrics key to be rejected on %s, got %d", path, resp.Code)
+		}
+	}
+	resp := performRequest(server, http.MethodGet, "/metrics", "", scraper)
+	if resp.Code != http.StatusOK || !strings.HasPrefix(resp.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("unexpected metrics response: %d %s", resp.Code, resp.Header().Get("Content-Type"))
+	}
+	body := resp.Body.String()
+	if strings.Contains(body, `method="BREW"`) {
+		t.Fatalf("expected non-standard methods to be folded into OTHER, got:\n%s", body)
+	}
+	for _, want := range []string{
+		`ebookdb_http_requests_total{method="GET",route="/api/v1/search",status="200"}`,
+		`ebookdb_http_requests_total{method="OTHER",route="unmatched",status="404"}`,
+		`ebookdb_datasource_query_duration_seconds_count{datasource="metered"} 1`,
+		`ebookdb_datasource_results_total{datasource="metered"} 1`,
+		`ebookdb_fts_rebuild_duration_seconds_count{datasource="metered"} 1`,
+		"ebookdb_search_cache_hit_ratio 0.5",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestRequestIDPropagatesToAccessAndAdapterLogs(t *testing.T) {
+	server, _, cleanup := newMultiSourceTestServer(t, "traced")
+	defer cleanup()
+
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(logger.NewContextHandler(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	defer slog.SetDefault(previous)
+
+	resp := performRequest(server, http.MethodGet, "/api/v1/search?field=title&query=Go%20Systems", "", map[string]string{"X-Request-ID": "trace-123"})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("search status = %d", resp.Code)
+	}
+	if got := resp.Header().Get("X-Request-ID"); got != "trace-123" {
+		t.Fatalf("expected request ID to be echoed, got %q", got)
+	}
+
+	seen := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("failed to decode log line %q: %v", line, err)
+		}
+		if entry["request_id"] == "trace-123" {
+			seen[entry["msg"].(string)] = true
+		}
+	}
+	for _, msg := range []string{"Legacy 查询完成", "HTTP 请求"} {
+		if !seen[msg] {
+			t.Fatalf("expected %q log line with request_id, got:\n%s", msg, logs.String())
+		}
+	}
+
+	generated := performRequest(server, http.MethodGet, "/api/v1/health", "", map[string]string{"X-Request-ID": "bad id\twith spaces"})
+	if id := generated.Header().Get("X-Request-ID"); id == "" || strings.Contains(id, " ") {
+		t.Fatalf("expected invalid request ID to be replaced, got %q", id)
+	}
+}
+
+func TestSearchAnalyticsReportsTopAndZeroResultQueries(t *testing.T) {
+	base, _, cleanup := newMultiSourceTestServer(t, "stats")
+	defer cleanup()
+
+	// 以停用统计的配置启动，确认运行中启用后无需重启即可记录。
+	enabled, off := *base.currentConfig(), false
+	stopped := enabled
+	stopped.Analytics.Enabled = &off
+	server, err := NewServer(&stopped, base.dbManager, base.configPath, ":10223")
+	if err != nil {
+		t.Fatalf("NewServer returned error: %v", err)
+	}
+	defer server.Close()
+	headers := map[string]string{"Authorization": "Bearer " + loginToken(t, server)}
+
+	disabled := performRequest(server, http.MethodGet, "/api/v1/admin/analytics/search", "", headers)
+	if disabled.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while analytics is disabled, got %d", disabled.Code)
+	}
+	if err := server.ApplyConfig(&enabled); err != nil {
+		t.Fatalf("ApplyConfig returned error: %v", err)
+	}
+	store := server.analyticsStore()
+	if store == nil {
+		t.Fatalf("expected enabling analytics to open the store")
+	}
+
+	for _, path := range []string{
+		"/api/v1/search?field=title&query=Go%20Systems",
+		"/api/v1/search?field=title&query=Go%20Systems",
+		"/api/v1/search?field=title&query=Go%20Systems&page=2",
+		"/api/v1/search?field=title&query=Missing",
+	} {
+		if resp := performRequest(server, http.MethodGet, path, "", nil); resp.Code != http.StatusOK {
+			t.Fatalf("search %s status = %d", path, resp.Code)
+		}
+	}
+	store.Flush()
+
+	resp := performRequest(server, http.MethodGet, "/api/v1/admin/analytics/search?days=1&limit=5", "", headers)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("analytics status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	var report analytics.Report
+	if err := json.Unmarshal(resp.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if report.TotalSearches != 3 || report.ZeroResultSearches != 1 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+	if len(report.TopQueries) == 0 || report.TopQueries[0].Term != "go systems" || report.TopQueries[0].Count != 2 {
+		t.Fatalf("unexpected top queries: %+v", report.TopQueries)
+	}
+	if len(report.ZeroResultQueries) != 1 || report.ZeroResultQueries[0].Term != "missing" {
+		t.Fatalf("unexpected zero-result queries: %+v", report.ZeroResultQueries)
+	}
+
+	if bad := performRequest(server, http.MethodGet, "/api/v1/admin/analytics/search?days=0", "", headers); bad.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid days, got %d", bad.Code)
+	}
+}
+
+func TestAdminLoggingUpdatesRunningLogger(t *testing.T) {
+	server, _, cleanup := newMultiSourceTestServer(t, "logs")
+	defer cleanup()
+	headers := map[string]string{"Authorization": "Bearer " + loginToken(t, server)}
+
+	previous := slog.Default()
+	defer slog.SetDefault(previous)
+	logDir := t.TempDir()
+	initial := logger.DefaultOptions()
+	initial.Filename = filepath.Join(logDir, "before.log")
+	initial.Writers = []io.Writer{io.Discard}
+	if _, err := logger.InitLogger(initial); err != nil {
+		t.Fatalf("InitLogger returned error: %v", err)
+	}
+
+	current := performRequest(server, http.MethodGet, "/api/v1/admin/logging", "", headers)
+	if current.Code != http.StatusOK || !strings.Contains(current.Body.String(), `"level":"info"`) || !strings.Contains(current.Body.String(), `"active":true`) {
+		t.Fatalf("unexpected logging view: %d %s", current.Code, current.Body.String())
+	}
+
+	invalid := performRequest(server, http.MethodPut, "/api/v1/admin/logging", `{"level": "verbose"}`, headers)
+	if invalid.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid level, got %d", invalid.Code)
+	}
+
+	target := filepath.Join(logDir, "after.log")
+	body := `{"level": "debug", "format": "text", "file": "` + filepath.ToSlash(target) + `", "stdout": false}`
+	updated := performRequest(server, http.MethodPut, "/api/v1/admin/logging", body, headers)
+	if updated.Code != http.StatusOK {
+		t.Fatalf("update status = %d, body = %s", updated.Code, updated.Body.String())
+	}
+	if got := server.currentConfig().Logging; got.Level != "debug" || got.Format != "text" || got.StdoutEnabled() {
+		t.Fatalf("running config not updated: %+v", got)
+	}
+
+	slog.Debug("调试日志已开启")
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("expected new log file to be written: %v", err)
+	}
+	if !strings.Contains(string(data), "level=DEBUG") || !strings.Contains(string(data), "调试日志已开启") {
+		t.Fatalf("expected text debug log in new file, got %q", data)
+	}
+
+	saved, err := os.ReadFile(server.configPath)
+	if err != nil {
+		t.Fatalf("failed to read settings: %v", err)
+	}
+	if !strings.Contains(string(saved), `"level": "debug"`) {
+		t.Fatalf("expected logging to be persisted, got %s", saved)
+	}
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	server, _, cleanup := newMultiSourceTestServer(t, "drain")
+	defer cleanup()
+
+	started := make(chan struct{})
+	server.Engine().GET("/slow", func(c *gin.Context) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.Serve(ln) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		results <- result{body: string(body), err: err}
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+
+	res := <-results
+	if res.err != nil || res.body != "done" {
+		t.Fatalf("expected in-flight request to complete, got body=%q err=%v", res.body, res.err)
+	}
+	if err := <-serveErr; err != nil {
+		t.Fatalf("Serve returned error after shutdown: %v", err)
+	}
+	if _, err := http.Get("http://" + ln.Addr().String() + "/api/v1/health"); err == nil {
+		t.Fatalf("expected new connections to be refused after shutdown")
+	}
+}
+
+func TestLogoutRevokesTokenAndKeyRotationInvalidatesOthers(t *testing.T) {
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "admin", "exp": time.Now().Add(time.Hour).Unix()})
+	forgedToken, _ := forged.SignedString([]byte("secret"))
+	if resp := performRequest(server, http.MethodGet, "/api/v1/admin/config", "", map[string]string{"Authorization": "Bearer " + forgedToken}); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected token signed with the admin password to be rejected, got %d", resp.Code)
+	}
+
+	first := loginToken(t, server)
+	second := loginToken(t, server)
+	auth := func(token string) map[string]string { return map[string]string{"Authorization": "Bearer " + token} }
+
+	if resp := performRequest(server, http.MethodPost, "/api/v1/logout", "", auth(first)); resp.Code != http.StatusOK {
+		t.Fatalf("logout status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if resp := performRequest(server, http.MethodGet, "/api/v1/admin/config", "", auth(first)); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected revoked token to be rejected, got %d", resp.Code)
+	}
+	if resp := performRequest(server, http.MethodGet, "/api/v1/admin/config", "", auth(second)); resp.Code != http.StatusOK {
+		t.Fatalf("expected other token to stay valid, got %d", resp.Code)
+	}
+
+	rotate := performRequest(server, http.MethodPost, "/api/v1/admin/auth/rotate-key", "", auth(second))
+	if rotate.Code != http.StatusOK {
+		t.Fatalf("rotate status = %d, body = %s", rotate.Code, rotate.Body.String())
+	}
+	var rotated struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(rotate.Body.Bytes(), &rotated); err != nil || rotated.Token == "" {
+		t.Fatalf("expected rotation to return a fresh token: %v %s", err, rotate.Body.String())
+	}
+	if resp := performRequest(server, http.MethodGet, "/api/v1/admin/config", "", auth(second)); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected token signed with the old key to be rejected, got %d", resp.Code)
+	}
+	if resp := performRequest(server, http.MethodGet, "/api/v1/admin/config", "", auth(rotated.Token)); resp.Code != http.StatusOK {
+		t.Fatalf("expected rotated token to be accepted, got %d", resp.Code)
+	}
+}
+
+func TestUserAccountsAndRoleChecks(t *testing.T) {
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+	adminHeaders := map[string]string{"Authorization": "Bearer " + loginToken(t, server)}
+
+	for _, body := range []string{
+		`{"username": "libby", "password": "librarian-pass", "role": "librarian"}`,
+		`{"username": "rita", "password": "reader-pass", "role": "reader"}`,
+	} {
+		if resp := performRequest(server, http.MethodPost, "/api/v1/admin/users", body, adminHeaders); resp.Code != http.StatusCreated {
+			t.Fatalf("create user status = %d, body = %s", resp.Code, resp.Body.String())
+		}
+	}
+	if resp := performRequest(server, http.MethodPost, "/api/v1/admin/users", `{"username": "libby", "password": "whatever-pass", "role": "reader"}`, adminHeaders); resp.Code != http.StatusConflict {
+		t.Fatalf("expected duplicate user to conflict, got %d", resp.Code)
+	}
+	if resp := performRequest(server, http.MethodPost, "/api/v1/admin/users", `{"username": "x", "password": "whatever-pass", "role": "owner"}`, adminHeaders); resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown role to be rejected, got %d", resp.Code)
+	}
+
+	userToken := func(username, password string) string {
+		resp := performRequest(server, http.MethodPost, "/api/v1/login", `{"username": "`+username+`", "password": "`+password+`"}`, nil)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("login %s status = %d, body = %s", username, resp.Code, resp.Body.String())
+		}
+		var payload struct {
+			Token string `json:"token"`
+		}
+		_ = json.Unmarshal(resp.Body.Bytes(), &payload)
+		return payload.Token
+	}
+	if resp := performRequest(server, http.MethodPost, "/api/v1/login", `{"username": "libby", "password": "wrong-pass"}`, nil); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected wrong password to be rejected, got %d", resp.Code)
+	}
+
+	librarian := map[string]string{"Authorization": "Bearer " + userToken("libby", "librarian-pass")}
+	reader := map[string]string{"Authorization": "Bearer " + userToken("rita", "reader-pass")}
+
+	me := performRequest(server, http.MethodGet, "/api/v1/me", "", librarian)
+	if me.Code != http.StatusOK || !strings.Contains(me.Body.String(), `"role":"librarian"`) {
+		t.Fatalf("unexpected /me response: %d %s", me.Code, me.Body.String())
+	}
+
+	checks := []struct {
+		headers map[string]string
+		method  string
+		path    string
+		want    int
+	}{
+		{librarian, http.MethodGet, "/api/v1/admin/datasources", http.StatusOK},
+		{librarian, http.MethodGet, "/api/v1/admin/config", http.StatusForbidden},
+		{librarian, http.MethodGet, "/api/v1/admin/users", http.StatusForbidden},
+		{reader, http.MethodGet, "/api/v1/admin/datasources", http.StatusForbidden},
+		{reader, http.MethodGet, "/api/v1/me", http.StatusOK},
+		{nil, http.MethodGet, "/api/v1/admin/datasources", http.StatusUnauthorized},
+	}
+	for _, check := range checks {
+		if resp := performRequest(server, check.method, check.path, "", check.headers); resp.Code != check.want {
+			t.Fatalf("%s %s = %d, want %d", check.method, check.path, resp.Code, check.want)
+		}
+	}
+
+	demote := performRequest(server, http.MethodPatch, "/api/v1/admin/users/libby", `{"role": "reader"}`, adminHeaders)
+	if demote.Code != http.StatusOK {
+		t.Fatalf("demote status = %d, body = %s", demote.Code, demote.Body.String())
+	}
+	if resp := performRequest(server, http.MethodGet, "/api/v1/admin/datasources", "", librarian); resp.Code != http.StatusForbidden {
+		t.Fatalf("expected demotion to apply to existing tokens, got %d", resp.Code)
+	}
+
+	if resp := performRequest(server, http.MethodDelete, "/api/v1/admin/users/rita", "", adminHeaders); resp.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d", resp.Code)
+	}
+	if resp := performRequest(server, http.MethodGet, "/api/v1/me", "", reader); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected deleted user's token to be rejected, got %d", resp.Code)
+	}
+
+	if resp := performRequest(server, http.MethodPost, "/api/v1/admin/users", `{"username": "rita", "password": "reader-pass", "role": "admin"}`, adminHeaders); resp.Code != http.StatusCreated {
+		t.Fatalf("recreate user status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if resp := performRequest(server, http.MethodGet, "/api/v1/me", "", reader); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected deleted user's token to stay rejected after the username is reused, got %d", resp.Code)
+	}
+
+	update := func(body string) {
+		t.Helper()
+		if resp := performRequest(server, http.MethodPatch, "/api/v1/admin/users/libby", body, adminHeaders); resp.Code != http.StatusOK {
+			t.Fatalf("update %s status = %d, body = %s", body, resp.Code, resp.Body.String())
+		}
+	}
+	before := map[string]string{"Authorization": "Bearer " + userToken("libby", "librarian-pass")}
+	update(`{"password": "another-pass"}`)
+	if resp := performRequest(server, http.MethodGet, "/api/v1/me", "", before); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected password change to invalidate earlier tokens, got %d", resp.Code)
+	}
+	before = map[string]string{"Authorization": "Bearer " + userToken("libby", "another-pass")}
+	update(`{"disabled": true}`)
+	update(`{"disabled": false}`)
+	if resp := performRequest(server, http.MethodGet, "/api/v1/me", "", before); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected disabling and re-enabling to invalidate earlier tokens, got %d", resp.Code)
+	}
+	after := map[string]string{"Authorization": "Bearer " + userToken("libby", "another-pass")}
+	if resp := performRequest(server, http.MethodGet, "/api/v1/me", "", after); resp.Code != http.StatusOK {
+		t.Fatalf("expected a fresh login to work, got %d", resp.Code)
+	}
+}
+
+func TestAccessPolicyGuardsPublicEndpoints(t *testing.T) {
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+	token := loginToken(t, server)
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	setPolicy := func(policy string) {
+		cfg := *server.currentConfig()
+		cfg.Auth.AccessPolicy = policy
+		server.setConfig(&cfg)
+	}
+	settings := performRequest(server, http.MethodGet, "/api/v1/settings", "", nil)
+	if !strings.Contains(settings.Body.String(), `"accessPolicy":"public"`) {
+		t.Fatalf("expected default public policy, got %s", settings.Body.String())
+	}
+
+	checks := []struct {
+		policy  string
+		headers map[string]string
+		path    string
+		want    int
+	}{
+		{config.AccessPublic, nil, "/api/v1/download?source=legacy&id=1", http.StatusNotFound},
+		{config.AccessLoginForDownloads, nil, "/api/v1/search?query=Go&field=title", http.StatusOK},
+		{config.AccessLoginForDownloads, nil, "/api/v1/cover?source=legacy&id=1", http.StatusNotFound},
+		{config.AccessLoginForDownloads, nil, "/api/v1/download?source=legacy&id=1", http.StatusUnauthorized},
+		{config.AccessLoginForDownloads, headers, "/api/v1/download?source=legacy&id=1", http.StatusNotFound},
+		{config.AccessLoginForDownloads, nil, "/api/v1/download?source=legacy&id=1&token=" + token, http.StatusNotFound},
+		{config.AccessLoginRequired, nil, "/api/v1/search?query=Go&field=title", http.StatusUnauthorized},
+		{config.AccessLoginRequired, nil, "/api/v1/available-dbs", http.StatusUnauthorized},
+		{config.AccessLoginRequired, nil, "/api/v1/cover?source=legacy&id=1", http.StatusUnauthorized},
+		{config.AccessLoginRequired, headers, "/api/v1/search?query=Go&field=title", http.StatusOK},
+		{config.AccessLoginRequired, nil, "/api/v1/settings", http.StatusOK},
+		{config.AccessLoginRequired, nil, "/api/v1/health", http.StatusOK},
+	}
+	for _, check := range checks {
+		setPolicy(check.policy)
+		if resp := performRequest(server, http.MethodGet, check.path, "", check.headers); resp.Code != check.want {
+			t.Fatalf("[%s] %s = %d, want %d (body %s)", check.policy, check.path, resp.Code, check.want, resp.Body.String())
+		}
+	}
+
+	denied := performRequest(server, http.MethodGet, "/api/v1/search?query=Go&field=title", "", nil)
+	if !strings.Contains(denied.Body.String(), `"loginRequired":true`) {
+		t.Fatalf("expected loginRequired hint, got %s", denied.Body.String())
+	}
+	settings = performRequest(server, http.MethodGet, "/api/v1/settings", "", nil)
+	if !strings.Contains(settings.Body.String(), `"accessPolicy":"loginRequired"`) {
+		t.Fatalf("expected settings to report active policy, got %s", settings.Body.String())
+	}
+}
+
+func TestDatasourceAccessControlFiltersSources(t *testing.T) {
+	server, _, cleanup := newMultiSourceTestServer(t, "alpha", "beta")
+	defer cleanup()
+	adminHeaders := map[string]string{"Authorization": "Bearer " + loginToken(t, server)}
+
+	tokens := make(map[string]map[string]string)
+	for _, name := range []string{"rita", "dana", "gus"} {
+		role := "reader"
+		if name == "gus" {
+			role = "guest"
+		}
+		body := `{"username": "` + name + `", "password": "` + name + `-password", "role": "` + role + `"}`
+		if resp := performRequest(server, http.MethodPost, "/api/v1/admin/users", body, adminHeaders); resp.Code != http.StatusCreated {
+			t.Fatalf("create user status = %d, body = %s", resp.Code, resp.Body.String())
+		}
+		login := performRequest(server, http.MethodPost, "/api/v1/login", `{"username": "`+name+`", "password": "`+name+`-password"}`, nil)
+		var payload struct {
+			Token string `json:"token"`
+		}
+		_ = json.Unmarshal(login.Body.Bytes(), &payload)
+		tokens[name] = map[string]string{"Authorization": "Bearer " + payload.Token}
+	}
+
+	generation := server.dbManager.Generation("beta")
+	cfg := *server.currentConfig()
+	cfg.Datasources = append([]config.DatasourceConfig(nil), cfg.Datasources...)
+	for i := range cfg.Datasources {
+		if cfg.Datasources[i].Name == "beta" {
+			cfg.Datasources[i].Access = &config.DatasourceAccess{
+				Search:   config.AccessRule{Roles: []string{"reader"}},
+				Download: config.AccessRule{Users: []string{"DANA"}},
+			}
+		}
+	}
+	if err := server.ApplyConfig(&cfg); err != nil {
+		t.Fatalf("ApplyConfig returned error: %v", err)
+	}
+	if server.dbManager.Generation("beta") != generation {
+		t.Fatalf("expected access-only change to keep the datasource instance")
+	}
+
+	searchTotal := func(headers map[string]string) int {
+		resp := performRequest(server, http.MethodGet, "/api/v1/search?field=title&query=Go%20Systems", "", headers)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("search status = %d, body = %s", resp.Code, resp.Body.String())
+		}
+		var payload struct {
+			TotalRecords int `json:"totalRecords"`
+		}
+		_ = json.Unmarshal(resp.Body.Bytes(), &payload)
+		return payload.TotalRecords
+	}
+	if got := searchTotal(nil); got != 1 {
+		t.Fatalf("anonymous search should only see alpha, got %d results", got)
+	}
+	if got := searchTotal(tokens["gus"]); got != 1 {
+		t.Fatalf("guest search should only see alpha, got %d results", got)
+	}
+	if got := searchTotal(tokens["rita"]); got != 2 {
+		t.Fatalf("reader search should see both sources, got %d results", got)
+	}
+
+	dbs := performRequest(server, http.MethodGet, "/api/v1/available-dbs", "", nil)
+	if strings.Contains(dbs.Body.String(), "beta") {
+		t.Fatalf("expected beta to be hidden from anonymous callers, got %s", dbs.Body.String())
+	}
+	if resp := performRequest(server, http.MethodGet, "/api/v1/search?field=title&query=Go&sources[]=beta", "", nil); resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected hidden source to be rejected, got %d", resp.Code)
+	}
+
+	checks := []struct {
+		headers map[string]string
+		path    string
+		want    int
+	}{
+		{nil, "/api/v1/cover?source=beta&id=1", http.StatusNotFound},
+		{nil, "/api/v1/download?source=beta&id=1", http.StatusNotFound},
+		{tokens["rita"], "/api/v1/download?source=beta&id=1", http.StatusForbidden},
+		{adminHeaders, "/api/v1/download?source=beta&id=1", http.StatusNotFound},
+		{tokens["dana"], "/api/v1/download?source=beta&id=1", http.StatusNotFound},
+	}
+	for _, check := range checks {
+		if resp := performRequest(server, http.MethodGet, check.path, "", check.headers); resp.Code != check.want {
+			t.Fatalf("%s = %d, want %d (body %s)", check.path, resp.Code, check.want, resp.Body.String())
+		}
+	}
+	if resp := performRequest(server, http.MethodGet, "/api/v1/download?source=beta&id=1", "", tokens["dana"]); strings.Contains(resp.Body.String(), "数据源不存在") {
+		t.Fatalf("expected dana to reach the datasource, got %s", resp.Body.String())
+	}
+}
+
+func TestLibrarianCannotManagePrivateDatasources(t *testing.T) {
+	server, paths, cleanup := newMultiSourceTestServer(t, "alpha", "beta")
+	defer cleanup()
+	adminHeaders := map[string]string{"Authorization": "Bearer " + loginToken(t, server)}
+
+	cfg := *server.currentConfig()
+	cfg.Datasources = append([]config.DatasourceConfig(nil), cfg.Datasources...)
+	for i := range cfg.Datasources {
+		if cfg.Datasources[i].Name == "beta" {
+			cfg.Datasources[i].Access = &config.DatasourceAccess{Search: config.AccessRule{Roles: []string{"admin"}}}
+		}
+	}
+	if err := server.ApplyConfig(&cfg); err != nil {
+		t.Fatalf("ApplyConfig returned error: %v", err)
+	}
+
+	if resp := performRequest(server, http.MethodPost, "/api/v1/admin/users", `{"username": "libby", "password": "libby-password", "role": "librarian"}`, adminHeaders); resp.Code != http.StatusCreated {
+		t.Fatalf("create user status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	login := performRequest(server, http.MethodPost, "/api/v1/login", `{"username": "libby", "password": "libby-password"}`, nil)
+	var loginPayload struct {
+		Token string `json:"token"`
+	}
+	_ = json.Unmarshal(login.Body.Bytes(), &loginPayload)
+	librarian := map[string]string{"Authorization": "Bearer " + loginPayload.Token}
+
+	listed := func(headers map[string]string) string {
+		t.Helper()
+		resp := performRequest(server, http.MethodGet, "/api/v1/admin/datasources", "", headers)
+		var payload struct {
+			Datasources []struct {
+				Name string `json:"name"`
+			} `json:"datasources"`
+		}
+		_ = json.Unmarshal(resp.Body.Bytes(), &payload)
+		names := make([]string, 0, len(payload.Datasources))
+		for _, item := range payload.Datasources {
+			names = append(names, item.Name)
+		}
+		return strings.Join(names, ",")
+	}
+	if got := listed(librarian); got != "alpha" {
+		t.Fatalf("expected librarian to see only public datasources, got %q", got)
+	}
+	if got := listed(adminHeaders); got != "alpha,beta" {
+		t.Fatalf("expected admin to see all datasources, got %q", got)
+	}
+
+	alphaBody := `{"type": "legacy_db", "path": "` + filepath.ToSlash(paths["alpha"]) + `", "priority": 3`
+	betaBody := `{"type": "legacy_db", "path": "` + filepath.ToSlash(paths["beta"]) + `", "access": {"search": {"users": ["libby"]}}}`
+	checks := []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{http.MethodGet, "/api/v1/admin/datasources/beta", "", http.StatusNotFound},
+		{http.MethodPut, "/api/v1/admin/datasources/beta", betaBody, http.StatusNotFound},
+		{http.MethodPost, "/api/v1/admin/datasources/beta/test", "", http.StatusNotFound},
+		{http.MethodPost, "/api/v1/admin/datasources/beta/reindex", "", http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/admin/datasources/beta", "", http.StatusNotFound},
+		{http.MethodPut, "/api/v1/admin/datasources/alpha", alphaBody + `, "access": {"search": {"users": ["libby"]}}}`, http.StatusForbidden},
+		{http.MethodPost, "/api/v1/admin/datasources", `{"name": "gamma", "type": "legacy_db", "path": "` + filepath.ToSlash(paths["alpha"]) + `", "access": {"search": {"roles": ["librarian"]}}}`, http.StatusForbidden},
+		{http.MethodPut, "/api/v1/admin/datasources/alpha", alphaBody + `}`, http.StatusOK},
+	}
+	for _, check := range checks {
+		if resp := performRequest(server, check.method, check.path, check.body, librarian); resp.Code != check.want {
+			t.Fatalf("%s %s = %d, want %d (body %s)", check.method, check.path, resp.Code, check.want, resp.Body.String())
+		}
+	}
+	if item, _ := server.dbManager.DatasourceConfig("beta"); item.Access == nil || len(item.Access.Search.Users) != 0 {
+		t.Fatalf("expected beta access rule to stay unchanged, got %+v", item.Access)
+	}
+
+	if resp := performRequest(server, http.MethodPut, "/api/v1/admin/datasources/beta", betaBody, adminHeaders); resp.Code != http.StatusOK {
+		t.Fatalf("expected admin to change access rules, got %d: %s", resp.Code, resp.Body.String())
+	}
+	if got := listed(librarian); got != "alpha,beta" {
+		t.Fatalf("expected librarian to see datasources shared with them, got %q", got)
+	}
+}
+
+func TestAPIKeysAuthenticateScriptsByScope(t *testing.T) {
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+	adminHeaders := map[string]string{"Authorization": "Bearer " + loginToken(t, server)}
+
+	createKey := func(scope string) (string, string) {
+		resp := performRequest(server, http.MethodPost, "/api/v1/admin/api-keys", `{"name": "`+scope+`-script", "scope": "`+scope+`"}`, adminHeaders)
+		if resp.Code != http.StatusCreated {
+			t.Fatalf("create api key status = %d, body = %s", resp.Code, resp.Body.String())
+		}
+		var payload struct {
+			Key    string `json:"key"`
+			APIKey struct {
+				ID string `json:"id"`
+			} `json:"apiKey"`
+		}
+		_ = json.Unmarshal(resp.Body.Bytes(), &payload)
+		return payload.APIKey.ID, payload.Key
+	}
+	_, searchKey := createKey("search")
+	_, downloadKey := createKey("download")
+	adminKeyID, adminKey := createKey("admin")
+	if resp := performRequest(server, http.MethodPost, "/api/v1/admin/api-keys", `{"name": "x", "scope": "root"}`, adminHeaders); resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown scope to be rejected, got %d", resp.Code)
+	}
+
+	cfg := *server.currentConfig()
+	cfg.Auth.AccessPolicy = config.AccessLoginRequired
+	server.setConfig(&cfg)
+
+	checks := []struct {
+		headers map[string]string
+		path    string
+		want    int
+	}{
+		{map[string]string{"X-API-Key": searchKey}, "/api/v1/search?field=title&query=Go%20Systems", http.StatusOK},
+		{map[string]string{"X-API-Key": searchKey}, "/api/v1/download?source=legacy&id=1", http.StatusForbidden},
+		{map[string]string{"X-API-Key": searchKey}, "/api/v1/admin/datasources", http.StatusForbidden},
+		{map[string]string{"Authorization": "Bearer " + downloadKey}, "/api/v1/download?source=legacy&id=1", http.StatusNotFound},
+		{nil, "/api/v1/download?source=legacy&id=1&token=" + downloadKey, http.StatusNotFound},
+		{map[string]string{"Authorization": "Bearer " + adminKey}, "/api/v1/admin/users", http.StatusOK},
+		{map[string]string{"X-API-Key": "ebk_unknown_key"}, "/api/v1/search?field=title&query=Go", http.StatusUnauthorized},
+	}
+	for _, check := range checks {
+		if resp := performRequest(server, http.MethodGet, check.path, "", check.headers); resp.Code != check.want {
+			t.Fatalf("%s = %d, want %d (body %s)", check.path, resp.Code, check.want, resp.Body.String())
+		}
+	}
+
+	if resp := performRequest(server, http.MethodPost, "/api/v1/logout", "", map[string]string{"X-API-Key": adminKey}); resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected logout with api key to be rejected, got %d", resp.Code)
+	}
+
+	list := performRequest(server, http.MethodGet, "/api/v1/admin/api-keys", "", adminHeaders)
+	if list.Code != http.StatusOK || !strings.Contains(list.Body.String(), `"lastUsedAt"`) || strings.Contains(list.Body.String(), searchKey) {
+		t.Fatalf("unexpected api key list: %d %s", list.Code, list.Body.String())
+	}
+
+	if resp := performRequest(server, http.MethodDelete, "/api/v1/admin/api-keys/"+adminKeyID, "", adminHeaders); resp.Code != http.StatusNoContent {
+		t.Fatalf("revoke status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if resp := performRequest(server, http.MethodGet, "/api/v1/admin/users", "", map[string]string{"X-API-Key": adminKey}); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected revoked key to be rejected, got %d", resp.Code)
+	}
+}
+
+func TestMergeBooksByPriorityKeepsHigherPriorityFirst(t *testing.T) {
+	groups := [][]core.CanonicalBook{
+		{{ID: "2", Source: "high"}},
+		{{ID: "9", Source: "low-a"}},
+		{{ID: "7", Source: "low-b"}, {ID: "1", Source: "low-b"}},
+	}
+	merged := mergeBooksByPriority(groups, []int{10, 0, 0})
+
+	got := make([]string, 0, len(merged))
+	for _, book := range merged {
+		got = append(got, book.Source+":"+book.ID)
+	}
+	want := "high:2,low-a:9,low-b:7,low-b:1"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("merged order = %v, want %s", got, want)
+	}
+}
+
+func newMultiSourceTestServer(t *testing.T, names ...string) (*Server, map[string]string, func()) {
+	t.Helper()
+
+	dir := t.TempDir()
+	paths := make(map[string]string, len(names))
+	entries := make([]string, 0, len(names))
+	for _, name := range names {
+		path := filepath.Join(dir, name+".db")
+		createLegacyDB(t, path)
+		paths[name] = path
+		entries = append(entries, `{"name": "`+name+`", "type": "legacy_db", "path": "`+filepath.ToSlash(path)+`"}`)
+	}
+
+	configPath := filepath.Join(dir, "settings.json")
+	settings := `{
+  "pageSize": 5,
+  "defaultSearchField": "title",
+  "adminPassword": "secret",
+  "instanceDir": "` + filepath.ToSlash(filepath.Join(dir, "instance")) + `",
+  "datasources": [` + strings.Join(entries, ",") + `]
+}`
+	if err := os.WriteFile(configPath, []byte(settings), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+
+	manager := infra.NewDBManager()
+	if err := manager.InitFromConfig(cfg); err != nil {
+		t.Fatalf("InitFromConfig returned error: %v", err)
+	}
+
+	server, err := NewServer(cfg, manager, configPath, ":10223")
+	if err != nil {
+		_ = manager.Close()
+		t.Fatalf("NewServer returned error: %v", err)
+	}
+
+	return server, paths, func() {
+		server.Close()
+		_ = manager.Close()
+	}
+}
+
+func TestAdminDatasourceCRUD(t *testing.T) {
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+	headers := map[string]string{"Authorization": "Bearer " + loginToken(t, server)}
+
+	extraPath := filepath.Join(t.TempDir(), "extra.db")
+	createLegacyDB(t, extraPath)
+	extraJSON := `{"name": "extra", "type": "legacy_db", "path": "` + filepath.ToSlash(extraPath) + `", "label": "额外书库"}`
+
+	probe := performRequest(server, http.MethodPost, "/api/v1/admin/datasources/test", extraJSON, headers)
+	var probePayload struct {
+		OK   bool `json:"ok"`
+		Info struct {
+			Schema    string `json:"schema"`
+			BookCount int64  `json:"bookCount"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(probe.Body.Bytes(), &probePayload); err != nil {
+		t.Fatalf("failed to decode test response: %v", err)
+	}
+	if probe.Code != http.StatusOK || !probePayload.OK || probePayload.Info.Schema != "legacy_id" || probePayload.Info.BookCount != 1 {
+		t.Fatalf("unexpected test response: %d %s", probe.Code, probe.Body.String())
+	}
+
+	missing := performRequest(server, http.MethodPost, "/api/v1/admin/datasources/test", `{"name": "missing", "type": "legacy_db", "path": "/does/not/exist.db"}`, headers)
+	if missing.Code != http.StatusOK || !strings.Contains(missing.Body.String(), `"ok":false`) {
+		t.Fatalf("unexpected test response for missing path: %d %s", missing.Code, missing.Body.String())
+	}
+
+	created := performRequest(server, http.MethodPost, "/api/v1/admin/datasources", extraJSON, headers)
+	if created.Code != http.StatusCreated {
+		t.Fatalf("create datasource status = %d, body = %s", created.Code, created.Body.String())
+	}
+	duplicate := performRequest(server, http.MethodPost, "/api/v1/admin/datasources", extraJSON, headers)
+	if duplicate.Code != http.StatusConflict {
+		t.Fatalf("duplicate datasource status = %d, body = %s", duplicate.Code, duplicate.Body.String())
+	}
+	invalidType := performRequest(server, http.MethodPost, "/api/v1/admin/datasources", `{"name": "bad", "type": "unknown", "path": "/x"}`, headers)
+	if invalidType.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("invalid type status = %d, body = %s", invalidType.Code, invalidType.Body.String())
+	}
+
+	sources := server.dbManager.ListSources()
+	if len(sources) != 2 {
+		t.Fatalf("expected two registered sources, got %v", sources)
+	}
+	saved, err := config.LoadConfig(server.configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig after create returned error: %v", err)
+	}
+	if len(saved.Datasources) != 2 || saved.Datasources[1].Label != "额外书库" {
+		t.Fatalf("expected datasource to be persisted, got %+v", saved.Datasources)
+	}
+
+	disabled := performRequest(server, http.MethodPut, "/api/v1/admin/datasources/extra", `{"type": "legacy_db", "path": "`+filepath.ToSlash(extraPath)+`", "enabled": false}`, headers)
+	if disabled.Code != http.StatusOK {
+		t.Fatalf("update datasource status = %d, body = %s", disabled.Code, disabled.Body.String())
+	}
+	if _, ok := server.dbManager.GetDatasource("extra"); ok {
+		t.Fatalf("expected disabled datasource to be unregistered")
+	}
+	if _, ok := server.dbManager.GetDatasource("legacy"); !ok {
+		t.Fatalf("expected other datasource to stay registered")
+	}
+
+	detail := performRequest(server, http.MethodGet, "/api/v1/admin/datasources/extra", "", headers)
+	if detail.Code != http.StatusOK || !strings.Contains(detail.Body.String(), `"registered":false`) {
+		t.Fatalf("unexpected datasource detail: %d %s", detail.Code, detail.Body.String())
+	}
+
+	deleted := performRequest(server, http.MethodDelete, "/api/v1/admin/datasources/extra", "", headers)
+	if deleted.Code != http.StatusNoContent {
+		t.Fatalf("delete datasource status = %d, body = %s", deleted.Code, deleted.Body.String())
+	}
+	list := performRequest(server, http.MethodGet, "/api/v1/admin/datasources", "", headers)
+	var listPayload struct {
+		Datasources []struct {
+			Name       string `json:"name"`
+			Registered bool   `json:"registered"`
+		} `json:"datasources"`
+	}
+	if err := json.Unmarshal(list.Body.Bytes(), &listPayload); err != nil {
+		t.Fatalf("failed to decode datasource list: %v", err)
+	}
+	if len(listPayload.Datasources) != 1 || listPayload.Datasources[0].Name != "legacy" || !listPayload.Datasources[0].Registered {
+		t.Fatalf("unexpected datasource list: %s", list.Body.String())
+	}
+}
+
+func TestAdminConfigRejectsInvalidPayloadAndSupportsRollback(t *testing.T) {
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+	headers := map[string]string{"Authorization": "Bearer " + loginToken(t, server)}
+
+	original, err := os.ReadFile(server.configPath)
+	if err != nil {
+		t.Fatalf("failed to read settings: %v", err)
+	}
+
+	invalid := performRequest(server, http.MethodPost, "/api/v1/admin/config", `{"pageSize": 5, "unknownKey": true}`, headers)
+	if invalid.Code != http.StatusBadRequest {
+		t.Fatalf("invalid config status = %d, body = %s", invalid.Code, invalid.Body.String())
+	}
+	brokenSource := performRequest(server, http.MethodPost, "/api/v1/admin/config", `{"pageSize": 5, "adminPassword": "secret", "datasources": [{"name": "x", "type": "legacy_db", "path": "/does/not/exist/x.db"}]}`, headers)
+	if brokenSource.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("broken datasource status = %d, body = %s", brokenSource.Code, brokenSource.Body.String())
+	}
+	if current, _ := os.ReadFile(server.configPath); string(current) != string(original) {
+		t.Fatalf("expected settings file to stay untouched, got %s", current)
+	}
+	if names := server.dbManager.ListSources(); len(names) != 1 || names[0] != "legacy" {
+		t.Fatalf("expected running datasources to stay untouched, got %v", names)
+	}
+
+	updated := `{"pageSize": 9, "adminPassword": "secret", "datasources": [{"name": "legacy", "type": "legacy_db", "path": "` + filepath.ToSlash(server.currentConfig().Datasources[0].Path) + `"}]}`
+	saved := performRequest(server, http.MethodPost, "/api/v1/admin/config", updated, headers)
+	if saved.Code != http.StatusOK {
+		t.Fatalf("save config status = %d, body = %s", saved.Code, saved.Body.String())
+	}
+	if server.currentConfig().PageSize != 9 {
+		t.Fatalf("expected pageSize 9 after save, got %d", server.currentConfig().PageSize)
+	}
+
+	versions := performRequest(server, http.MethodGet, "/api/v1/admin/config/versions", "", headers)
+	var versionsPayload struct {
+		Versions []struct {
+			ID string `json:"id"`
+		} `json:"versions"`
+	}
+	if err := json.Unmarshal(versions.Body.Bytes(), &versionsPayload); err != nil {
+		t.Fatalf("failed to decode versions: %v", err)
+	}
+	if len(versionsPayload.Versions) != 1 {
+		t.Fatalf("expected one archived version, got %s", versions.Body.String())
+	}
+
+	missing := performRequest(server, http.MethodPost, "/api/v1/admin/config/rollback", `{"version": "20000101T000000.000000000"}`, headers)
+	if missing.Code != http.StatusNotFound {
+		t.Fatalf("missing version status = %d, body = %s", missing.Code, missing.Body.String())
+	}
+
+	rollback := performRequest(server, http.MethodPost, "/api/v1/admin/config/rollback", `{"version": "`+versionsPayload.Versions[0].ID+`"}`, headers)
+	if rollback.Code != http.StatusOK {
+		t.Fatalf("rollback status = %d, body = %s", rollback.Code, rollback.Body.String())
+	}
+	if server.currentConfig().PageSize != 5 {
+		t.Fatalf("expected pageSize 5 after rollback, got %d", server.currentConfig().PageSize)
+	}
+	// 回滚恢复原有设置，只有明文的 adminPassword 会转为哈希。
+	current, _ := os.ReadFile(server.configPath)
+	var restored, want map[string]any
+	_ = json.Unmarshal(current, &restored)
+	_ = json.Unmarshal(original, &want)
+	if password, _ := restored["adminPassword"].(string); !config.IsBcryptHash(password) {
+		t.Fatalf("expected rolled back password to be hashed, got %s", current)
+	}
+	delete(restored, "adminPassword")
+	delete(want, "adminPassword")
+	got, _ := json.Marshal(restored)
+	expected, _ := json.Marshal(want)
+	if string(got) != string(expected) {
+		t.Fatalf("expected rollback to restore original settings, got %s", current)
+	}
+}
+
+func loginToken(t *testing.T, server *Server) string {
+	t.Helper()
+
+	login := performRequest(server, http.MethodPost, "/api/v1/login", `{"password":"secret"}`, nil)
+	if login.Code != http.StatusOK {
+		t.Fatalf("login status = %d, body = %s", login.Code, login.Body.String())
+	}
+	var payload struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(login.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("failed to decode login response: %v", err)
+	}
+	return payload.Token
+}
+
 func newTestServer(t *testing.T) (*Server, func()) {
 	t.Helper()
 
@@ -332,6 +1564,7 @@ func newTestServer(t *testing.T) (*Server, func()) {
   "pageSize": 5,
   "defaultSearchField": "title",
   "adminPassword": "secret",
+  "instanceDir": "` + filepath.ToSlash(filepath.Join(dir, "instance")) + `",
   "corsAllowedOrigins": ["http://localhost:5173", "http://127.0.0.1:5173", "*"],
   "datasources": [
     {"name": "legacy", "type": "legacy_db", "path": "` + filepath.ToSlash(dbPath) + `"}
@@ -351,13 +1584,14 @@ func newTestServer(t *testing.T) (*Server, func()) {
 		t.Fatalf("InitFromConfig returned error: %v", err)
 	}
 
-	server, err := NewServer(cfg, manager, configPath, ":10223", time.Minute)
+	server, err := NewServer(cfg, manager, configPath, ":10223")
 	if err != nil {
 		_ = manager.Close()
 		t.Fatalf("NewServer returned error: %v", err)
 	}
 
 	return server, func() {
+		server.Close()
 		_ = manager.Close()
 	}
 }
@@ -460,3 +1694,431 @@ func performRequest(server *Server, method, path, body string, headers map[strin
 	server.Engine().ServeHTTP(rec, req)
 	return rec
 }
+
+func TestConfigWatcherAppliesValidEditsAndKeepsConfigOnInvalid(t *testing.T) {
+	server, paths, cleanup := newMultiSourceTestServer(t, "alpha", "beta")
+	defer cleanup()
+	defer server.Close()
+
+	if err := server.StartConfigWatcher(10 * time.Millisecond); err != nil {
+		t.Fatalf("StartConfigWatcher returned error: %v", err)
+	}
+	alpha, _ := server.dbManager.GetDatasource("alpha")
+
+	edited := `{
+  "pageSize": 7,
+  "defaultSearchField": "title",
+  "adminPassword": "secret",
+  "datasources": [{"name": "alpha", "type": "legacy_db", "path": "` + filepath.ToSlash(paths["alpha"]) + `"}]
+}`
+	if err := os.WriteFile(server.configPath, []byte(edited), 0o644); err != nil {
+		t.Fatalf("failed to edit config: %v", err)
+	}
+	waitFor(t, func() bool { return server.currentConfig().PageSize == 7 })
+
+	if _, ok := server.dbManager.GetDatasource("beta"); ok {
+		t.Fatalf("expected beta to be removed after reload")
+	}
+	if current, _ := server.dbManager.GetDatasource("alpha"); current != alpha {
+		t.Fatalf("expected unchanged datasource alpha to keep its instance")
+	}
+
+	if err := os.WriteFile(server.configPath, []byte(`{"pageSize": "many", "unknownKey": true}`), 0o644); err != nil {
+		t.Fatalf("failed to write invalid config: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := server.currentConfig().PageSize; got != 7 {
+		t.Fatalf("expected invalid edit to be ignored, pageSize = %d", got)
+	}
+	if _, ok := server.dbManager.GetDatasource("alpha"); !ok {
+		t.Fatalf("expected alpha to stay registered after invalid edit")
+	}
+}
+
+func TestApplyConfigKeepsRunningConfigWhenDatasourceFails(t *testing.T) {
+	server, paths, cleanup := newMultiSourceTestServer(t, "alpha")
+	defer cleanup()
+
+	broken := *server.currentConfig()
+	broken.PageSize = 9
+	broken.Datasources = []config.DatasourceConfig{
+		{Name: "alpha", Type: "legacy_db", Path: paths["alpha"]},
+		{Name: "missing", Type: "calibre", Path: filepath.Join(t.TempDir(), "nowhere")},
+	}
+	if err := server.ApplyConfig(&broken); err == nil {
+		t.Fatalf("expected ApplyConfig to fail for missing datasource")
+	}
+	if got := server.currentConfig().PageSize; got != 5 {
+		t.Fatalf("expected running config to be kept, pageSize = %d", got)
+	}
+	if _, ok := server.dbManager.GetDatasource("missing"); ok {
+		t.Fatalf("expected failed datasource not to be registered")
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("condition not met before deadline")
+}
+
+func TestAuditLogRecordsAdminActions(t *testing.T) {
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	forged := map[string]string{"X-Forwarded-For": "10.0.0.1"}
+	if resp := performRequest(server, http.MethodPost, "/api/v1/login", `{"password": "wrong"}`, forged); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected wrong password to fail, got %d", resp.Code)
+	}
+	headers := map[string]string{"Authorization": "Bearer " + loginToken(t, server)}
+
+	current := performRequest(server, http.MethodGet, "/api/v1/admin/config", "", headers)
+	var settings map[string]any
+	if err := json.Unmarshal(current.Body.Bytes(), &settings); err != nil {
+		t.Fatalf("failed to decode config: %v", err)
+	}
+	settings["pageSize"] = 13
+	settings["adminPassword"] = "rotated-secret"
+	body, _ := json.Marshal(settings)
+	if resp := performRequest(server, http.MethodPost, "/api/v1/admin/config", string(body), headers); resp.Code != http.StatusOK {
+		t.Fatalf("save config status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	relogin := performRequest(server, http.MethodPost, "/api/v1/login", `{"password": "rotated-secret"}`, nil)
+	var reloginPayload struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(relogin.Body.Bytes(), &reloginPayload); err != nil || relogin.Code != http.StatusOK {
+		t.Fatalf("login with rotated password status = %d, body = %s", relogin.Code, relogin.Body.String())
+	}
+	headers = map[string]string{"Authorization": "Bearer " + reloginPayload.Token}
+	if resp := performRequest(server, http.MethodPost, "/api/v1/admin/users", `{"username": "carol", "password": "longpassword", "role": "reader"}`, headers); resp.Code != http.StatusCreated {
+		t.Fatalf("create user status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if resp := performRequest(server, http.MethodPatch, "/api/v1/admin/users/carol", `{"role": "librarian", "password": "anotherpassword"}`, headers); resp.Code != http.StatusOK {
+		t.Fatalf("update user status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+
+	type auditPage struct {
+		Entries []struct {
+			Actor    string          `json:"actor"`
+			Role     string          `json:"role"`
+			ClientIP string          `json:"clientIp"`
+			Action   string          `json:"action"`
+			Target   string          `json:"target"`
+			Details  json.RawMessage `json:"details"`
+		} `json:"entries"`
+		Total    int64 `json:"total"`
+		PageSize int   `json:"pageSize"`
+	}
+	list := func(query string) auditPage {
+		t.Helper()
+		resp := performRequest(server, http.MethodGet, "/api/v1/admin/audit"+query, "", headers)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("audit status = %d, body = %s", resp.Code, resp.Body.String())
+		}
+		var page auditPage
+		if err := json.Unmarshal(resp.Body.Bytes(), &page); err != nil {
+			t.Fatalf("failed to decode audit page: %v", err)
+		}
+		return page
+	}
+
+	all := list("")
+	actions := make([]string, 0, len(all.Entries))
+	for _, entry := range all.Entries {
+		actions = append(actions, entry.Action)
+	}
+	want := []string{"user.update", "user.create", "login.success", "config.update", "login.success", "login.failure"}
+	if all.Total != 6 || strings.Join(actions, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected audit actions: %v (total %d)", actions, all.Total)
+	}
+	if entry := all.Entries[5]; entry.ClientIP != "192.0.2.1" {
+		t.Fatalf("expected login failure to record the peer address instead of a forged header, got %+v", entry)
+	}
+	if entry := all.Entries[3]; entry.Actor != "admin" || entry.Role != "admin" || entry.ClientIP == "" {
+		t.Fatalf("unexpected config audit entry: %+v", entry)
+	}
+	configDetails := string(all.Entries[3].Details)
+	if !strings.Contains(configDetails, `{"path":"pageSize","old":5,"new":13}`) ||
+		!strings.Contains(configDetails, `"[REDACTED]"`) || strings.Contains(configDetails, "rotated-secret") {
+		t.Fatalf("unexpected config diff: %s", configDetails)
+	}
+	if details := string(all.Entries[0].Details); !strings.Contains(details, `"passwordChanged":true`) ||
+		!strings.Contains(details, `{"path":"role","old":"reader","new":"librarian"}`) || strings.Contains(details, "anotherpassword") {
+		t.Fatalf("unexpected user update details: %s", details)
+	}
+
+	users := list("?action=user.&pageSize=1&page=2")
+	if users.Total != 2 || len(users.Entries) != 1 || users.Entries[0].Action != "user.create" || users.Entries[0].Target != "carol" {
+		t.Fatalf("unexpected filtered audit page: %+v", users)
+	}
+	if resp := performRequest(server, http.MethodGet, "/api/v1/admin/audit?since=yesterday", "", headers); resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid since to be rejected, got %d", resp.Code)
+	}
+
+	if resp := performRequest(server, http.MethodPost, "/api/v1/admin/datasources/legacy/reindex", "", headers); resp.Code != http.StatusOK {
+		t.Fatalf("reindex status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if reindex := list("?action=datasource.reindex"); reindex.Total != 1 || reindex.Entries[0].Target != "legacy" {
+		t.Fatalf("unexpected reindex audit entries: %+v", reindex)
+	}
+
+	readerToken := performRequest(server, http.MethodPost, "/api/v1/login", `{"username": "carol", "password": "anotherpassword"}`, nil)
+	var login struct {
+		Token string `json:"token"`
+	}
+	_ = json.Unmarshal(readerToken.Body.Bytes(), &login)
+	if resp := performRequest(server, http.MethodGet, "/api/v1/admin/audit", "", map[string]string{"Authorization": "Bearer " + login.Token}); resp.Code != http.StatusForbidden {
+		t.Fatalf("expected librarian to be denied audit log, got %d", resp.Code)
+	}
+}
+
+func TestAdminConfigRedactsAndHashesAdminPassword(t *testing.T) {
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+	headers := map[string]string{"Authorization": "Bearer " + loginToken(t, server)}
+
+	load := func() map[string]any {
+		t.Helper()
+		resp := performRequest(server, http.MethodGet, "/api/v1/admin/config", "", headers)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("admin config status = %d, body = %s", resp.Code, resp.Body.String())
+		}
+		if strings.Contains(resp.Body.String(), `"secret"`) {
+			t.Fatalf("expected admin password to be redacted: %s", resp.Body.String())
+		}
+		var settings map[string]any
+		_ = json.Unmarshal(resp.Body.Bytes(), &settings)
+		return settings
+	}
+	save := func(settings map[string]any) {
+		t.Helper()
+		body, _ := json.Marshal(settings)
+		resp := performRequest(server, http.MethodPost, "/api/v1/admin/config", string(body), headers)
+		if resp.Code != http.StatusOK || strings.Contains(resp.Body.String(), "$2a$") {
+			t.Fatalf("save config status = %d, body = %s", resp.Code, resp.Body.String())
+		}
+	}
+	storedPassword := func() string {
+		t.Helper()
+		data, err := os.ReadFile(server.configPath)
+		if err != nil {
+			t.Fatalf("failed to read settings: %v", err)
+		}
+		var settings map[string]any
+		_ = json.Unmarshal(data, &settings)
+		value, _ := settings["adminPassword"].(string)
+		return value
+	}
+
+	settings := load()
+	if settings["adminPassword"] != config.SecretUnchanged {
+		t.Fatalf("expected placeholder, got %v", settings["adminPassword"])
+	}
+	settings["pageSize"] = 11
+	save(settings)
+	// 沿用的明文密码在保存时同样转为哈希。
+	if stored := storedPassword(); !config.IsBcryptHash(stored) {
+		t.Fatalf("expected stored plain password to be migrated to bcrypt, got %q", stored)
+	}
+	if resp := performRequest(server, http.MethodPost, "/api/v1/login", `{"password": "secret"}`, nil); resp.Code != http.StatusOK {
+		t.Fatalf("expected placeholder to keep the existing password, got %d", resp.Code)
+	}
+
+	settings = load()
+	settings["adminPassword"] = "new-secret"
+	save(settings)
+	if stored := storedPassword(); !config.IsBcryptHash(stored) {
+		t.Fatalf("expected new password to be stored as bcrypt hash, got %q", stored)
+	}
+	login := performRequest(server, http.MethodPost, "/api/v1/login", `{"password": "new-secret"}`, nil)
+	if login.Code != http.StatusOK {
+		t.Fatalf("expected login with new password, got %d", login.Code)
+	}
+	if resp := performRequest(server, http.MethodGet, "/api/v1/admin/config", "", headers); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected changing adminPassword to invalidate earlier shared admin tokens, got %d", resp.Code)
+	}
+	var loginPayload struct {
+		Token string `json:"token"`
+	}
+	_ = json.Unmarshal(login.Body.Bytes(), &loginPayload)
+	headers = map[string]string{"Authorization": "Bearer " + loginPayload.Token}
+
+	// 重复提交相同的密码沿用原有哈希，不会使令牌失效。
+	settings = load()
+	settings["adminPassword"] = "new-secret"
+	hashed := storedPassword()
+	save(settings)
+	if stored := storedPassword(); stored != hashed {
+		t.Fatalf("expected resubmitted password to keep its hash, got %q", stored)
+	}
+	load()
+
+	// 回滚到保存明文密码的最早版本时，密码同样以哈希写回设置文件。
+	versions, err := config.ListVersions(server.configPath)
+	if err != nil || len(versions) == 0 {
+		t.Fatalf("expected settings history, got %+v, %v", versions, err)
+	}
+	rollback := performRequest(server, http.MethodPost, "/api/v1/admin/config/rollback", `{"version": "`+versions[len(versions)-1].ID+`"}`, headers)
+	if rollback.Code != http.StatusOK || strings.Contains(rollback.Body.String(), `"secret"`) {
+		t.Fatalf("rollback status = %d, body = %s", rollback.Code, rollback.Body.String())
+	}
+	if stored := storedPassword(); !config.IsBcryptHash(stored) {
+		t.Fatalf("expected rolled back password to be stored as bcrypt hash, got %q", stored)
+	}
+	if resp := performRequest(server, http.MethodPost, "/api/v1/login", `{"password": "secret"}`, nil); resp.Code != http.StatusOK {
+		t.Fatalf("expected login with rolled back password, got %d", resp.Code)
+	}
+}
+
+func TestShelvesStoreEntriesPerUserAndHydrateBooks(t *testing.T) {
+	server, _, cleanup := newMultiSourceTestServer(t, "alpha", "beta")
+	defer cleanup()
+	adminHeaders := map[string]string{"Authorization": "Bearer " + loginToken(t, server)}
+
+	if resp := performRequest(server, http.MethodPost, "/api/v1/admin/users", `{"username": "rita", "password": "rita-password", "role": "reader"}`, adminHeaders); resp.Code != http.StatusCreated {
+		t.Fatalf("create user status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	login := performRequest(server, http.MethodPost, "/api/v1/login", `{"username": "rita", "password": "rita-password"}`, nil)
+	var loginPayload struct {
+		Token string `json:"token"`
+	}
+	_ = json.Unmarshal(login.Body.Bytes(), &loginPayload)
+	rita := map[string]string{"Authorization": "Bearer " + loginPayload.Token}
+
+	if resp := performRequest(server, http.MethodGet, "/api/v1/shelves", "", nil); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected shelves to require login, got %d", resp.Code)
+	}
+
+	created := performRequest(server, http.MethodPost, "/api/v1/shelves", `{"name": "Favorites", "description": "keepers"}`, rita)
+	if created.Code != http.StatusCreated {
+		t.Fatalf("create shelf status = %d, body = %s", created.Code, created.Body.String())
+	}
+	var shelf struct {
+		ID int64 `json:"id"`
+	}
+	_ = json.Unmarshal(created.Body.Bytes(), &shelf)
+	base := "/api/v1/shelves/" + strconv.FormatInt(shelf.ID, 10)
+
+	adds := []struct {
+		body string
+		want int
+	}{
+		{`{"source": "alpha", "id": "1", "note": "great"}`, http.StatusCreated},
+		{`{"source": "beta", "id": "1", "position": 0}`, http.StatusCreated},
+		{`{"source": "alpha", "id": "1"}`, http.StatusConflict},
+		{`{"source": "alpha", "id": "999"}`, http.StatusNotFound},
+		{`{"source": "missing", "id": "1"}`, http.StatusNotFound},
+		{`{"source": "alpha"}`, http.StatusBadRequest},
+	}
+	for _, add := range adds {
+		if resp := performRequest(server, http.MethodPost, base+"/books", add.body, rita); resp.Code != add.want {
+			t.Fatalf("add %s status = %d, want %d, body = %s", add.body, resp.Code, add.want, resp.Body.String())
+		}
+	}
+
+	type shelfPayload struct {
+		Shelf struct {
+			Name       string `json:"name"`
+			EntryCount int    `json:"entryCount"`
+		} `json:"shelf"`
+		Entries []struct {
+			Source    string `json:"source"`
+			ID        string `json:"id"`
+			Note      string `json:"note"`
+			Available bool   `json:"available"`
+			Book      *struct {
+				Title  string `json:"title"`
+				Source string `json:"source"`
+			} `json:"book"`
+		} `json:"entries"`
+	}
+	load := func(headers map[string]string) shelfPayload {
+		t.Helper()
+		resp := performRequest(server, http.MethodGet, base, "", headers)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("get shelf status = %d, body = %s", resp.Code, resp.Body.String())
+		}
+		var payload shelfPayload
+		_ = json.Unmarshal(resp.Body.Bytes(), &payload)
+		return payload
+	}
+
+	got := load(rita)
+	if got.Shelf.Name != "Favorites" || got.Shelf.EntryCount != 2 || len(got.Entries) != 2 {
+		t.Fatalf("unexpected shelf: %+v", got)
+	}
+	if first := got.Entries[0]; first.Source != "beta" || !first.Available || first.Book == nil || first.Book.Title != "Go Systems" {
+		t.Fatalf("unexpected first entry: %+v", first)
+	}
+	if second := got.Entries[1]; second.Source != "alpha" || second.Note != "great" || second.Book == nil || second.Book.Source != "alpha" {
+		t.Fatalf("unexpected second entry: %+v", second)
+	}
+
+	if resp := performRequest(server, http.MethodGet, base, "", adminHeaders); resp.Code != http.StatusNotFound {
+		t.Fatalf("expected other users not to see the shelf, got %d", resp.Code)
+	}
+
+	cfg := *server.currentConfig()
+	cfg.Datasources = append([]config.DatasourceConfig(nil), cfg.Datasources...)
+	for i := range cfg.Datasources {
+		if cfg.Datasources[i].Name == "beta" {
+			cfg.Datasources[i].Access = &config.DatasourceAccess{Search: config.AccessRule{Roles: []string{"librarian"}}}
+		}
+	}
+	if err := server.ApplyConfig(&cfg); err != nil {
+		t.Fatalf("ApplyConfig returned error: %v", err)
+	}
+	if hidden := load(rita).Entries[0]; hidden.Source != "beta" || hidden.Available || hidden.Book != nil {
+		t.Fatalf("expected hidden datasource entry to be unavailable, got %+v", hidden)
+	}
+
+	moved := performRequest(server, http.MethodPatch, base+"/books?source=alpha&id=1", `{"position": 0, "note": "re-read"}`, rita)
+	if moved.Code != http.StatusOK {
+		t.Fatalf("move entry status = %d, body = %s", moved.Code, moved.Body.String())
+	}
+	if got := load(rita); got.Entries[0].Source != "alpha" || got.Entries[0].Note != "re-read" {
+		t.Fatalf("unexpected order after move: %+v", got.Entries)
+	}
+	if resp := performRequest(server, http.MethodDelete, base+"/books?source=beta&id=1", "", rita); resp.Code != http.StatusNoContent {
+		t.Fatalf("remove entry status = %d", resp.Code)
+	}
+	if resp := performRequest(server, http.MethodDelete, base+"/books?source=beta&id=1", "", rita); resp.Code != http.StatusNotFound {
+		t.Fatalf("expected removing a missing entry to return 404, got %d", resp.Code)
+	}
+	if resp := performRequest(server, http.MethodDelete, base+"/books?source=alpha", "", rita); resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected a missing id to be rejected, got %d", resp.Code)
+	}
+
+	// 数据源名称中的 / 等字符不影响修改与移除条目。
+	withSlash := *server.currentConfig()
+	slashed := withSlash.Datasources[0]
+	slashed.Name, slashed.Access = "旧版/备份 #1", nil
+	withSlash.Datasources = append(append([]config.DatasourceConfig(nil), withSlash.Datasources...), slashed)
+	if err := server.ApplyConfig(&withSlash); err != nil {
+		t.Fatalf("ApplyConfig returned error: %v", err)
+	}
+	if resp := performRequest(server, http.MethodPost, base+"/books", `{"source": "旧版/备份 #1", "id": "1"}`, rita); resp.Code != http.StatusCreated {
+		t.Fatalf("add entry from slashed source status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	entryQuery := "/books?" + url.Values{"source": {"旧版/备份 #1"}, "id": {"1"}}.Encode()
+	if resp := performRequest(server, http.MethodPatch, base+entryQuery, `{"note": "backup"}`, rita); resp.Code != http.StatusOK {
+		t.Fatalf("update entry from slashed source status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if resp := performRequest(server, http.MethodDelete, base+entryQuery, "", rita); resp.Code != http.StatusNoContent {
+		t.Fatalf("remove entry from slashed source status = %d", resp.Code)
+	}
+
+	if resp := performRequest(server, http.MethodDelete, "/api/v1/admin/users/rita", "", adminHeaders); resp.Code != http.StatusNoContent {
+		t.Fatalf("delete user status = %d", resp.Code)
+	}
+	if remaining, err := server.shelves.ListShelves(context.Background(), auth.UserSubject("rita")); err != nil || len(remaining) != 0 {
+		t.Fatalf("expected deleted user's shelves to be removed, got %+v, %v", remaining, err)
+	}
+}
@@ -0,0 +1,284 @@
+// path: internal/api/shelves.go
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/internal/core"
+	"ebookdatabase/internal/shelves"
+)
+
+// shelfEntryView 是书架条目及其对应的书籍信息。数据源被移除、对调用者不可见或书籍已不存在时
+// Available 为 false 且不返回 Book，条目本身保留，数据源恢复后即可重新显示。
+type shelfEntryView struct {
+	shelves.Entry
+	Available bool                `json:"available"`
+	Book      *core.CanonicalBook `json:"book,omitempty"`
+}
+
+func (s *Server) handleListShelves(c *gin.Context) {
+	list, err := s.shelves.ListShelves(c.Request.Context(), requestPrincipal(c).subject())
+	if err != nil {
+		s.respondShelfError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shelves": list})
+}
+
+func (s *Server) handleCreateShelf(c *gin.Context) {
+	var payload struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体格式错误"})
+		return
+	}
+	shelf, err := s.shelves.CreateShelf(c.Request.Context(), requestPrincipal(c).subject(), payload.Name, payload.Description)
+	if err != nil {
+		s.respondShelfError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, shelf)
+}
+
+// handleGetShelf 返回书架信息与按顺序排列的条目，条目通过各数据源还原为完整的书籍信息。
+func (s *Server) handleGetShelf(c *gin.Context) {
+	id, ok := shelfID(c)
+	if !ok {
+		return
+	}
+	owner := requestPrincipal(c).subject()
+	shelf, err := s.shelves.GetShelf(c.Request.Context(), owner, id)
+	if err != nil {
+		s.respondShelfError(c, err)
+		return
+	}
+	entries, err := s.shelves.Entries(c.Request.Context(), owner, id)
+	if err != nil {
+		s.respondShelfError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shelf": shelf, "entries": s.hydrateShelfEntries(c, entries)})
+}
+
+func (s *Server) handleUpdateShelf(c *gin.Context) {
+	id, ok := shelfID(c)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体格式错误"})
+		return
+	}
+	shelf, err := s.shelves.UpdateShelf(c.Request.Context(), requestPrincipal(c).subject(), id,
+		shelves.ShelfUpdate{Name: payload.Name, Description: payload.Description})
+	if err != nil {
+		s.respondShelfError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, shelf)
+}
+
+func (s *Server) handleDeleteShelf(c *gin.Context) {
+	id, ok := shelfID(c)
+	if !ok {
+		return
+	}
+	if err := s.shelves.DeleteShelf(c.Request.Context(), requestPrincipal(c).subject(), id); err != nil {
+		s.respondShelfError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// handleAddShelfEntry 把调用者可以检索的一本书加入书架，数据源支持按 ID 读取时会先确认书籍存在。
+func (s *Server) handleAddShelfEntry(c *gin.Context) {
+	id, ok := shelfID(c)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Source   string `json:"source"`
+		ID       string `json:"id"`
+		Note     string `json:"note"`
+		Position *int   `json:"position"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体格式错误"})
+		return
+	}
+	source, bookID := strings.TrimSpace(payload.Source), strings.TrimSpace(payload.ID)
+	if source == "" || bookID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少必要参数"})
+		return
+	}
+	if !s.authorizeSource(c, source, false) {
+		return
+	}
+
+	books, fetched := s.fetchBooks(c, source, []string{bookID})
+	if fetched && len(books) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "书籍不存在"})
+		return
+	}
+
+	entry, err := s.shelves.AddEntry(c.Request.Context(), requestPrincipal(c).subject(), id, source, bookID, payload.Note, payload.Position)
+	if err != nil {
+		s.respondShelfError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, s.hydrateShelfEntries(c, []shelves.Entry{entry})[0])
+}
+
+// handleUpdateShelfEntry 修改 source 与 id 指定条目的备注或位置，请求体中未出现的字段保持不变。
+func (s *Server) handleUpdateShelfEntry(c *gin.Context) {
+	id, ok := shelfID(c)
+	if !ok {
+		return
+	}
+	source, bookID, ok := shelfEntryKey(c)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Note     *string `json:"note"`
+		Position *int    `json:"position"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体格式错误"})
+		return
+	}
+	if payload.Note == nil && payload.Position == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少要修改的 note 或 position"})
+		return
+	}
+
+	owner := requestPrincipal(c).subject()
+	update := shelves.EntryUpdate{Note: payload.Note, Position: payload.Position}
+	if err := s.shelves.UpdateEntry(c.Request.Context(), owner, id, source, bookID, update); err != nil {
+		s.respondShelfError(c, err)
+		return
+	}
+	entries, err := s.shelves.Entries(c.Request.Context(), owner, id)
+	if err != nil {
+		s.respondShelfError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": s.hydrateShelfEntries(c, entries)})
+}
+
+// handleRemoveShelfEntry 从书架中移除 source 与 id 指定的条目。
+func (s *Server) handleRemoveShelfEntry(c *gin.Context) {
+	id, ok := shelfID(c)
+	if !ok {
+		return
+	}
+	source, bookID, ok := shelfEntryKey(c)
+	if !ok {
+		return
+	}
+	if err := s.shelves.RemoveEntry(c.Request.Context(), requestPrincipal(c).subject(), id, source, bookID); err != nil {
+		s.respondShelfError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// hydrateShelfEntries 按数据源分组批量读取条目对应的书籍，只查询调用者可以检索的数据源，
+// 并按下载权限修正 can_download。
+func (s *Server) hydrateShelfEntries(c *gin.Context, entries []shelves.Entry) []shelfEntryView {
+	ids := make(map[string][]string)
+	for _, entry := range entries {
+		ids[entry.Source] = append(ids[entry.Source], entry.BookID)
+	}
+
+	found := make(map[string]map[string]int)
+	books := make([]core.CanonicalBook, 0, len(entries))
+	for _, source := range s.searchableSources(c) {
+		if len(ids[source]) == 0 {
+			continue
+		}
+		fetched, _ := s.fetchBooks(c, source, ids[source])
+		found[source] = make(map[string]int, len(fetched))
+		for _, book := range fetched {
+			found[source][book.ID] = len(books)
+			books = append(books, book)
+		}
+	}
+	s.restrictDownloads(c, books)
+
+	views := make([]shelfEntryView, 0, len(entries))
+	for _, entry := range entries {
+		view := shelfEntryView{Entry: entry}
+		if index, ok := found[entry.Source][entry.BookID]; ok {
+			view.Available = true
+			view.Book = &books[index]
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+// fetchBooks 从已注册的数据源按 ID 读取书籍，数据源不支持按 ID 读取或读取失败时 ok 为 false。
+func (s *Server) fetchBooks(c *gin.Context, source string, ids []string) ([]core.CanonicalBook, bool) {
+	datasource, ok := s.dbManager.GetDatasource(source)
+	if !ok {
+		return nil, false
+	}
+	fetcher, ok := datasource.(core.BookFetcher)
+	if !ok {
+		return nil, false
+	}
+	books, err := fetcher.GetBooks(c.Request.Context(), ids)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "读取书架书籍失败", slog.String("datasource", source), slog.String("error", err.Error()))
+		return nil, false
+	}
+	return books, true
+}
+
+func shelfID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": shelves.ErrShelfNotFound.Error()})
+		return 0, false
+	}
+	return id, true
+}
+
+// shelfEntryKey 从查询参数 source 与 id 中读取条目所属的数据源与书籍 ID。数据源名称可以包含 / 等字符，
+// 不适合放在路径中。缺少任一参数时写入 400 响应并返回 false。
+func shelfEntryKey(c *gin.Context) (string, string, bool) {
+	source := strings.TrimSpace(c.Query("source"))
+	bookID := strings.TrimSpace(c.Query("id"))
+	if source == "" || bookID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少参数 source 或 id"})
+		return "", "", false
+	}
+	return source, bookID, true
+}
+
+func (s *Server) respondShelfError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, shelves.ErrShelfNotFound), errors.Is(err, shelves.ErrEntryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, shelves.ErrShelfExists), errors.Is(err, shelves.ErrEntryExists), errors.Is(err, shelves.ErrLimitExceeded):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, shelves.ErrInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), "书架操作失败", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "书架操作失败"})
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFlightGroupSharesConcurrentCalls(t *testing.T) {
+	var group flightGroup[int]
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	const callers = 8
+	var wg sync.WaitGroup
+	results := make(chan int, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, err := group.Do(context.Background(), "key", func(context.Context) (int, error) {
+				calls.Add(1)
+				<-release
+				return 42, nil
+			})
+			if err != nil {
+				t.Errorf("Do returned error: %v", err)
+			}
+			results <- val
+		}()
+	}
+
+	waitFor(t, func() bool {
+		group.mu.Lock()
+		defer group.mu.Unlock()
+		call := group.calls["key"]
+		return call != nil && call.waiters == callers
+	})
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected a single execution, got %d", got)
+	}
+	for val := range results {
+		if val != 42 {
+			t.Fatalf("expected shared result 42, got %d", val)
+		}
+	}
+}
+
+func TestFlightGroupCancellationOnlyAffectsCaller(t *testing.T) {
+	var group flightGroup[int]
+	release := make(chan struct{})
+	fnCtx := make(chan context.Context, 1)
+	fn := func(ctx context.Context) (int, error) {
+		fnCtx <- ctx
+		<-release
+		return 7, nil
+	}
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := group.Do(leaderCtx, "key", fn)
+		leaderErr <- err
+	}()
+	shared := <-fnCtx
+
+	followerVal := make(chan int, 1)
+	go func() {
+		val, _ := group.Do(context.Background(), "key", fn)
+		followerVal <- val
+	}()
+	waitFor(t, func() bool {
+		group.mu.Lock()
+		defer group.mu.Unlock()
+		return group.calls["key"] != nil && group.calls["key"].waiters == 2
+	})
+
+	cancelLeader()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected leader to observe cancellation, got %v", err)
+	}
+	if shared.Err() != nil {
+		t.Fatalf("expected shared execution to continue while a follower waits")
+	}
+
+	close(release)
+	select {
+	case val := <-followerVal:
+		if val != 7 {
+			t.Fatalf("expected follower to receive 7, got %d", val)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("follower did not receive the shared result")
+	}
+}
+
+func TestFlightGroupCancelsWhenAllCallersLeave(t *testing.T) {
+	var group flightGroup[int]
+	fnCtx := make(chan context.Context, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = group.Do(ctx, "key", func(ctx context.Context) (int, error) {
+			fnCtx <- ctx
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+	}()
+	shared := <-fnCtx
+	cancel()
+	<-done
+
+	select {
+	case <-shared.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected shared execution to be cancelled once no caller waits")
+	}
+
+	val, err := group.Do(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+	if err != nil || val != 1 {
+		t.Fatalf("expected a fresh execution after cancellation, got %d, %v", val, err)
+	}
+}
@@ -0,0 +1,163 @@
+// path: internal/audit/diff.go
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// redacted 替换差异中的密码与密钥，审计日志只记录它们被修改过。
+const redacted = "[REDACTED]"
+
+// Change 是两份 JSON 文档在 Path 处的差异，新增时 Old 为空，删除时 New 为空。
+type Change struct {
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// Diff 比较两个可序列化为 JSON 的值（[]byte 与 json.RawMessage 按 JSON 文本解析），
+// 按路径返回全部差异。名称包含 password 或 secret 的字段只记录为 [REDACTED]。
+func Diff(before, after any) ([]Change, error) {
+	a, err := normalize(before)
+	if err != nil {
+		return nil, err
+	}
+	b, err := normalize(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]Change, 0)
+	walk("", a, b, false, &changes)
+	return changes, nil
+}
+
+func normalize(value any) (any, error) {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case json.RawMessage:
+		data = v
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("序列化比较对象失败: %w", err)
+		}
+		data = encoded
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("解析比较对象失败: %w", err)
+	}
+	return out, nil
+}
+
+func walk(path string, a, b any, sensitive bool, changes *[]Change) {
+	switch {
+	case isMap(a) && isMap(b):
+		am, bm := a.(map[string]any), b.(map[string]any)
+		keys := make([]string, 0, len(am)+len(bm))
+		for key := range am {
+			keys = append(keys, key)
+		}
+		for key := range bm {
+			if _, ok := am[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			av, inA := am[key]
+			bv, inB := bm[key]
+			child := joinPath(path, key)
+			childSensitive := sensitive || isSensitiveKey(key)
+			switch {
+			case !inA:
+				*changes = append(*changes, Change{Path: child, New: redact(bv, childSensitive)})
+			case !inB:
+				*changes = append(*changes, Change{Path: child, Old: redact(av, childSensitive)})
+			default:
+				walk(child, av, bv, childSensitive, changes)
+			}
+		}
+	case isSlice(a) && isSlice(b):
+		as, bs := a.([]any), b.([]any)
+		for i := range max(len(as), len(bs)) {
+			child := path + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= len(as):
+				*changes = append(*changes, Change{Path: child, New: redact(bs[i], sensitive)})
+			case i >= len(bs):
+				*changes = append(*changes, Change{Path: child, Old: redact(as[i], sensitive)})
+			default:
+				walk(child, as[i], bs[i], sensitive, changes)
+			}
+		}
+	case !reflect.DeepEqual(a, b):
+		*changes = append(*changes, Change{Path: path, Old: redact(a, sensitive), New: redact(b, sensitive)})
+	}
+}
+
+// Redact 把可序列化为 JSON 的值转换为可以写入审计日志的形式，规则与 Diff 相同。
+func Redact(value any) (any, error) {
+	normalized, err := normalize(value)
+	if err != nil {
+		return nil, err
+	}
+	return redact(normalized, false), nil
+}
+
+// redact 返回可以写入审计日志的值：敏感字段整体替换，其余对象递归处理内部的敏感字段。
+func redact(value any, sensitive bool) any {
+	if value == nil {
+		return nil
+	}
+	if sensitive {
+		return redacted
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = redact(item, isSensitiveKey(key))
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = redact(item, false)
+		}
+		return out
+	}
+	return value
+}
+
+func isSensitiveKey(key string) bool {
+	lower := strings.ToLower(key)
+	return strings.Contains(lower, "password") || strings.Contains(lower, "secret")
+}
+
+func isMap(value any) bool {
+	_, ok := value.(map[string]any)
+	return ok
+}
+
+func isSlice(value any) bool {
+	_, ok := value.([]any)
+	return ok
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,194 @@
+// path: internal/audit/store.go
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// 审计日志只允许追加：触发器拒绝任何修改或删除，避免操作痕迹被事后抹去。
+const schemaSQL = `
+CREATE TABLE IF NOT EXISTS audit_log (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	occurred_at INTEGER NOT NULL,
+	actor       TEXT    NOT NULL,
+	role        TEXT    NOT NULL,
+	api_key_id  TEXT    NOT NULL DEFAULT '',
+	client_ip   TEXT    NOT NULL,
+	action      TEXT    NOT NULL,
+	target      TEXT    NOT NULL DEFAULT '',
+	details     TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor);
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+	SELECT RAISE(ABORT, '审计日志只允许追加');
+END;
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+	SELECT RAISE(ABORT, '审计日志只允许追加');
+END;
+`
+
+// Entry 是一条审计记录。Details 为动作相关的附加信息，例如配置变更的差异。
+type Entry struct {
+	ID         int64           `json:"id"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Actor      string          `json:"actor"`
+	Role       string          `json:"role"`
+	APIKeyID   string          `json:"apiKeyId,omitempty"`
+	ClientIP   string          `json:"clientIp"`
+	Action     string          `json:"action"`
+	Target     string          `json:"target,omitempty"`
+	Details    json.RawMessage `json:"details,omitempty"`
+}
+
+// Query 是分页查询条件：Action 以 "." 结尾时按前缀匹配（如 "user."），Actor 不区分大小写。
+type Query struct {
+	Action   string
+	Actor    string
+	Since    time.Time
+	Page     int
+	PageSize int
+}
+
+// Page 是按时间倒序排列的一页审计记录。
+type Page struct {
+	Entries  []Entry `json:"entries"`
+	Total    int64   `json:"total"`
+	Page     int     `json:"page"`
+	PageSize int     `json:"pageSize"`
+}
+
+// Store 把审计记录同步写入实例目录下的 audit.db。
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// Open 打开（必要时创建）审计数据库。
+func Open(path string) (*Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("未指定审计数据库路径")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("创建审计数据库目录失败: %w", err)
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", filepath.ToSlash(path))
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开审计数据库失败: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schemaSQL); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化审计数据库失败: %w", err)
+	}
+	return &Store{db: db, now: time.Now}, nil
+}
+
+// Close 关闭审计数据库。
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Record 追加一条审计记录，OccurredAt 为空时使用当前时间；details 为 nil 时不保存附加信息。
+func (s *Store) Record(ctx context.Context, entry Entry, details any) error {
+	if entry.OccurredAt.IsZero() {
+		entry.OccurredAt = s.now()
+	}
+	var encoded sql.NullString
+	if details != nil {
+		data, err := json.Marshal(details)
+		if err != nil {
+			return fmt.Errorf("序列化审计详情失败: %w", err)
+		}
+		encoded = sql.NullString{String: string(data), Valid: true}
+	}
+
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO audit_log
+		(occurred_at, actor, role, api_key_id, client_ip, action, target, details)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.OccurredAt.UnixMilli(), entry.Actor, entry.Role, entry.APIKeyID, entry.ClientIP,
+		entry.Action, entry.Target, encoded); err != nil {
+		return fmt.Errorf("写入审计记录失败: %w", err)
+	}
+	return nil
+}
+
+// List 按时间倒序分页返回符合条件的审计记录。
+func (s *Store) List(ctx context.Context, query Query) (Page, error) {
+	page := Page{Entries: make([]Entry, 0), Page: max(query.Page, 1), PageSize: max(query.PageSize, 1)}
+
+	var (
+		filters []string
+		args    []any
+	)
+	if action := strings.TrimSpace(query.Action); action != "" {
+		if prefix, ok := strings.CutSuffix(action, "."); ok {
+			filters = append(filters, "substr(action, 1, ?) = ?")
+			args = append(args, len(prefix)+1, prefix+".")
+		} else {
+			filters = append(filters, "action = ?")
+			args = append(args, action)
+		}
+	}
+	if actor := strings.TrimSpace(query.Actor); actor != "" {
+		filters = append(filters, "actor = ? COLLATE NOCASE")
+		args = append(args, actor)
+	}
+	if !query.Since.IsZero() {
+		filters = append(filters, "occurred_at >= ?")
+		args = append(args, query.Since.UnixMilli())
+	}
+	where := ""
+	if len(filters) > 0 {
+		where = " WHERE " + strings.Join(filters, " AND ")
+	}
+
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log"+where, args...).Scan(&page.Total); err != nil {
+		return Page{}, fmt.Errorf("统计审计记录失败: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, occurred_at, actor, role, api_key_id, client_ip, action, target, details
+		FROM audit_log`+where+` ORDER BY id DESC LIMIT ? OFFSET ?`,
+		append(args, page.PageSize, (page.Page-1)*page.PageSize)...)
+	if err != nil {
+		return Page{}, fmt.Errorf("读取审计记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			entry      Entry
+			occurredAt int64
+			details    sql.NullString
+		)
+		if err := rows.Scan(&entry.ID, &occurredAt, &entry.Actor, &entry.Role, &entry.APIKeyID,
+			&entry.ClientIP, &entry.Action, &entry.Target, &details); err != nil {
+			return Page{}, fmt.Errorf("读取审计记录失败: %w", err)
+		}
+		entry.OccurredAt = time.UnixMilli(occurredAt).UTC()
+		if details.Valid {
+			entry.Details = json.RawMessage(details.String)
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return Page{}, fmt.Errorf("读取审计记录失败: %w", err)
+	}
+	return page, nil
+}
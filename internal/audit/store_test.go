@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreAppendsAndPaginates(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "nested", "audit.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	base := time.Unix(1_700_000_000, 0)
+	entries := []Entry{
+		{Actor: "admin", Role: "admin", ClientIP: "10.0.0.1", Action: "login.success"},
+		{Actor: "Alice", Role: "admin", ClientIP: "10.0.0.2", Action: "user.create", Target: "bob"},
+		{Actor: "alice", Role: "admin", ClientIP: "10.0.0.2", Action: "user.delete", Target: "bob"},
+		{Actor: "admin", Role: "admin", ClientIP: "10.0.0.1", Action: "config.update"},
+	}
+	for i, entry := range entries {
+		entry.OccurredAt = base.Add(time.Duration(i) * time.Minute)
+		var details any
+		if entry.Action == "config.update" {
+			details = map[string]any{"changes": []Change{{Path: "pageSize", Old: 20, New: 50}}}
+		}
+		if err := store.Record(ctx, entry, details); err != nil {
+			t.Fatalf("Record returned error: %v", err)
+		}
+	}
+
+	page, err := store.List(ctx, Query{Page: 1, PageSize: 3})
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if page.Total != 4 || len(page.Entries) != 3 || page.Entries[0].Action != "config.update" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	if got := string(page.Entries[0].Details); got != `{"changes":[{"path":"pageSize","old":20,"new":50}]}` {
+		t.Fatalf("unexpected details: %s", got)
+	}
+	if !page.Entries[0].OccurredAt.Equal(base.Add(3 * time.Minute)) {
+		t.Fatalf("unexpected timestamp: %v", page.Entries[0].OccurredAt)
+	}
+
+	second, err := store.List(ctx, Query{Page: 2, PageSize: 3})
+	if err != nil || len(second.Entries) != 1 || second.Entries[0].Action != "login.success" {
+		t.Fatalf("unexpected second page: %+v, %v", second, err)
+	}
+
+	users, err := store.List(ctx, Query{Action: "user.", Actor: "ALICE", PageSize: 10})
+	if err != nil || users.Total != 2 {
+		t.Fatalf("expected prefix and actor filters to match 2 entries, got %+v, %v", users, err)
+	}
+	recent, err := store.List(ctx, Query{Since: base.Add(2 * time.Minute), PageSize: 10})
+	if err != nil || recent.Total != 2 {
+		t.Fatalf("expected since filter to match 2 entries, got %+v, %v", recent, err)
+	}
+
+	if _, err := store.db.Exec("UPDATE audit_log SET actor = 'mallory'"); err == nil {
+		t.Fatalf("expected audit log updates to be rejected")
+	}
+	if _, err := store.db.Exec("DELETE FROM audit_log"); err == nil {
+		t.Fatalf("expected audit log deletes to be rejected")
+	}
+}
+
+func TestDiffReportsPathsAndRedactsSecrets(t *testing.T) {
+	before := []byte(`{"pageSize": 20, "adminPassword": "old", "datasources": [{"name": "a"}], "auth": {"tokenTTLMinutes": 60}}`)
+	after := map[string]any{
+		"pageSize":      50,
+		"adminPassword": "new",
+		"datasources":   []any{map[string]any{"name": "a"}, map[string]any{"name": "b", "password": "p"}},
+		"auth":          map[string]any{"tokenTTLMinutes": 60},
+		"showCovers":    true,
+	}
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		t.Fatalf("Diff returned error: %v", err)
+	}
+	data, _ := json.Marshal(changes)
+	want := `[{"path":"adminPassword","old":"[REDACTED]","new":"[REDACTED]"},` +
+		`{"path":"datasources[1]","new":{"name":"b","password":"[REDACTED]"}},` +
+		`{"path":"pageSize","old":20,"new":50},` +
+		`{"path":"showCovers","new":true}]`
+	if string(data) != want {
+		t.Fatalf("unexpected diff:\n got %s\nwant %s", data, want)
+	}
+
+	if same, err := Diff(before, json.RawMessage(before)); err != nil || len(same) != 0 {
+		t.Fatalf("expected identical documents to have no changes, got %v, %v", same, err)
+	}
+}
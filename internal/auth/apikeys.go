@@ -0,0 +1,250 @@
+// path: internal/auth/apikeys.go
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// APIKeyPrefix 是 API Key 的固定前缀，用于区分 API Key 与 JWT。
+	APIKeyPrefix = "ebk_"
+
+	apiKeySecretBytes  = 32
+	maxAPIKeyNameRunes = 64
+	// apiKeyTouchInterval 限制 last_used_at 的写入频率，避免每个请求都写数据库。
+	apiKeyTouchInterval = time.Minute
+)
+
+var (
+	// ErrAPIKeyNotFound 表示 API Key 不存在或已被吊销。
+	ErrAPIKeyNotFound = errors.New("API Key 不存在")
+	// ErrInvalidAPIKey 表示请求携带的 API Key 无法通过校验。
+	ErrInvalidAPIKey = errors.New("API Key 无效")
+	// ErrInvalidAPIKeyRequest 表示新建 API Key 的名称或范围不符合要求。
+	ErrInvalidAPIKeyRequest = errors.New("API Key 信息不合法")
+)
+
+// APIKeyScope 是 API Key 的权限范围。
+type APIKeyScope string
+
+const (
+	// ScopeMetrics 只能读取 /metrics，供 Prometheus 等监控系统抓取，不能访问其他任何接口。
+	ScopeMetrics APIKeyScope = "metrics"
+	// ScopeSearch 只能检索与查看封面，不能下载。
+	ScopeSearch APIKeyScope = "search"
+	// ScopeDownload 可以检索与下载，等同于 reader 角色。
+	ScopeDownload APIKeyScope = "download"
+	// ScopeAdmin 可以调用全部后台接口，等同于 admin 角色。
+	ScopeAdmin APIKeyScope = "admin"
+)
+
+// APIKeyScopes 按权限从低到高返回全部范围。
+func APIKeyScopes() []APIKeyScope {
+	return []APIKeyScope{ScopeMetrics, ScopeSearch, ScopeDownload, ScopeAdmin}
+}
+
+// ParseAPIKeyScope 解析范围名称，大小写不敏感。
+func ParseAPIKeyScope(value string) (APIKeyScope, error) {
+	scope := APIKeyScope(strings.ToLower(strings.TrimSpace(value)))
+	switch scope {
+	case ScopeMetrics, ScopeSearch, ScopeDownload, ScopeAdmin:
+		return scope, nil
+	}
+	return "", fmt.Errorf("未知的 API Key 范围: %s（可选 metrics、search、download、admin）", value)
+}
+
+// Role 返回该范围对应的角色，用于复用按角色授权的接口检查。
+func (s APIKeyScope) Role() Role {
+	switch s {
+	case ScopeAdmin:
+		return RoleAdmin
+	case ScopeDownload:
+		return RoleReader
+	default:
+		return RoleGuest
+	}
+}
+
+// AllowsDownload 返回该范围是否允许下载。
+func (s APIKeyScope) AllowsDownload() bool {
+	return s == ScopeDownload || s == ScopeAdmin
+}
+
+// APIKey 是已保存的 API Key 元数据，密钥本身只在创建时返回一次，数据库中仅保存其 SHA-256 哈希。
+type APIKey struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Prefix     string      `json:"prefix"`
+	Scope      APIKeyScope `json:"scope"`
+	CreatedBy  string      `json:"createdBy"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	LastUsedAt *time.Time  `json:"lastUsedAt,omitempty"`
+}
+
+// IsAPIKey 返回凭证是否为 API Key 格式。
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
+// CreateAPIKey 新建 API Key，返回元数据与完整密钥。完整密钥形如 ebk_<id>_<secret>，之后无法再次取回。
+func (s *Store) CreateAPIKey(name string, scope APIKeyScope, createdBy string) (APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxAPIKeyNameRunes {
+		return APIKey{}, "", fmt.Errorf("%w: 名称不能为空且不超过 %d 个字符", ErrInvalidAPIKeyRequest, maxAPIKeyNameRunes)
+	}
+	if _, err := ParseAPIKeyScope(string(scope)); err != nil {
+		return APIKey{}, "", fmt.Errorf("%w: %w", ErrInvalidAPIKeyRequest, err)
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return APIKey{}, "", fmt.Errorf("生成 API Key 失败: %w", err)
+	}
+	key := APIKeyPrefix + id + "_" + hex.EncodeToString(secret)
+
+	now := s.now().Unix()
+	if _, err := s.db.Exec(`INSERT INTO api_keys (id, name, key_hash, scope, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`, id, name, hashAPIKey(key), string(scope), createdBy, now); err != nil {
+		return APIKey{}, "", fmt.Errorf("保存 API Key 失败: %w", err)
+	}
+	created, err := s.GetAPIKey(id)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	return created, key, nil
+}
+
+// GetAPIKey 按 ID 查找 API Key。
+func (s *Store) GetAPIKey(id string) (APIKey, error) {
+	row := s.db.QueryRow(`SELECT id, name, scope, created_by, created_at, last_used_at FROM api_keys WHERE id = ?`, id)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, fmt.Errorf("%w: %s", ErrAPIKeyNotFound, id)
+	}
+	if err != nil {
+		return APIKey{}, fmt.Errorf("读取 API Key 失败: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys 按创建时间倒序返回全部 API Key。
+func (s *Store) ListAPIKeys() ([]APIKey, error) {
+	rows, err := s.db.Query(`SELECT id, name, scope, created_by, created_at, last_used_at FROM api_keys
+		ORDER BY created_at DESC, id`)
+	if err != nil {
+		return nil, fmt.Errorf("读取 API Key 列表失败: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("读取 API Key 列表失败: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey 删除 API Key，之后使用该密钥的请求立即被拒绝。
+func (s *Store) RevokeAPIKey(id string) error {
+	result, err := s.db.Exec("DELETE FROM api_keys WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("吊销 API Key 失败: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("%w: %s", ErrAPIKeyNotFound, id)
+	}
+	s.forgetAPIKeyTouch(id)
+	return nil
+}
+
+// AuthenticateAPIKey 校验完整密钥并返回其元数据，同时按 apiKeyTouchInterval 更新最近使用时间。
+func (s *Store) AuthenticateAPIKey(key string) (APIKey, error) {
+	rest, ok := strings.CutPrefix(key, APIKeyPrefix)
+	if !ok {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+	id, _, ok := strings.Cut(rest, "_")
+	if !ok || id == "" {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	var storedHash string
+	err := s.db.QueryRow("SELECT key_hash FROM api_keys WHERE id = ?", id).Scan(&storedHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return APIKey{}, fmt.Errorf("读取 API Key 失败: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(storedHash), []byte(hashAPIKey(key))) != 1 {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	if now := s.now(); s.shouldTouchAPIKey(id, now) {
+		if _, err := s.db.Exec(`UPDATE api_keys SET last_used_at = ? WHERE id = ? AND (last_used_at IS NULL OR last_used_at <= ?)`,
+			now.Unix(), id, now.Add(-apiKeyTouchInterval).Unix()); err != nil {
+			s.forgetAPIKeyTouch(id)
+			return APIKey{}, fmt.Errorf("更新 API Key 使用时间失败: %w", err)
+		}
+	}
+	return s.GetAPIKey(id)
+}
+
+// shouldTouchAPIKey 判断是否需要写入 last_used_at：本进程距上次写入不足 apiKeyTouchInterval 时返回 false，
+// 否则记下本次时间并返回 true。SQL 中的时间条件仍然保留，多个进程共用数据库时也不会频繁改写。
+func (s *Store) shouldTouchAPIKey(id string, now time.Time) bool {
+	s.touchMu.Lock()
+	defer s.touchMu.Unlock()
+	if last, ok := s.touched[id]; ok && now.Sub(last) < apiKeyTouchInterval {
+		return false
+	}
+	s.touched[id] = now
+	return true
+}
+
+func (s *Store) forgetAPIKeyTouch(id string) {
+	s.touchMu.Lock()
+	defer s.touchMu.Unlock()
+	delete(s.touched, id)
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func scanAPIKey(row rowScanner) (APIKey, error) {
+	var (
+		key      APIKey
+		scope    string
+		created  int64
+		lastUsed sql.NullInt64
+	)
+	if err := row.Scan(&key.ID, &key.Name, &scope, &key.CreatedBy, &created, &lastUsed); err != nil {
+		return APIKey{}, err
+	}
+	key.Scope = APIKeyScope(scope)
+	key.Prefix = APIKeyPrefix + key.ID[:8]
+	key.CreatedAt = time.Unix(created, 0).UTC()
+	if lastUsed.Valid {
+		at := time.Unix(lastUsed.Int64, 0).UTC()
+		key.LastUsedAt = &at
+	}
+	return key, nil
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "auth.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer store.Close()
+	now := time.Unix(1_700_000_000, 0)
+	store.now = func() time.Time { return now }
+
+	if _, _, err := store.CreateAPIKey("  ", ScopeSearch, "admin"); !errors.Is(err, ErrInvalidAPIKeyRequest) {
+		t.Fatalf("expected empty name to be rejected, got %v", err)
+	}
+
+	meta, key, err := store.CreateAPIKey("nightly-sync", ScopeDownload, "admin")
+	if err != nil {
+		t.Fatalf("CreateAPIKey returned error: %v", err)
+	}
+	if !IsAPIKey(key) || !strings.HasPrefix(key, meta.Prefix) || meta.LastUsedAt != nil {
+		t.Fatalf("unexpected key %q with metadata %+v", key, meta)
+	}
+
+	var storedHash string
+	if err := store.db.QueryRow("SELECT key_hash FROM api_keys WHERE id = ?", meta.ID).Scan(&storedHash); err != nil {
+		t.Fatalf("failed to read stored hash: %v", err)
+	}
+	if strings.Contains(key, storedHash) || storedHash != hashAPIKey(key) {
+		t.Fatalf("expected only the hash of the key to be stored")
+	}
+
+	used, err := store.AuthenticateAPIKey(key)
+	if err != nil || used.Scope != ScopeDownload || used.LastUsedAt == nil || !used.LastUsedAt.Equal(now) {
+		t.Fatalf("expected successful authentication with last-used time, got %+v %v", used, err)
+	}
+	now = now.Add(10 * time.Second)
+	if used, _ = store.AuthenticateAPIKey(key); !used.LastUsedAt.Equal(now.Add(-10 * time.Second)) {
+		t.Fatalf("expected last-used time to be throttled, got %v", used.LastUsedAt)
+	}
+	now = now.Add(apiKeyTouchInterval)
+	if used, _ = store.AuthenticateAPIKey(key); !used.LastUsedAt.Equal(now) {
+		t.Fatalf("expected last-used time to advance, got %v", used.LastUsedAt)
+	}
+
+	tampered := key[:len(key)-1] + "0"
+	if tampered == key {
+		tampered = key[:len(key)-1] + "1"
+	}
+	for _, candidate := range []string{tampered, "ebk_missing_secret", "ebk_", "not-a-key"} {
+		if _, err := store.AuthenticateAPIKey(candidate); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Fatalf("expected %q to be rejected, got %v", candidate, err)
+		}
+	}
+
+	if err := store.RevokeAPIKey(meta.ID); err != nil {
+		t.Fatalf("RevokeAPIKey returned error: %v", err)
+	}
+	if _, err := store.AuthenticateAPIKey(key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("expected revoked key to be rejected, got %v", err)
+	}
+	if err := store.RevokeAPIKey(meta.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Fatalf("expected second revoke to report not found, got %v", err)
+	}
+	if err := store.db.QueryRow("SELECT key_hash FROM api_keys WHERE id = ?", meta.ID).Scan(&storedHash); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected revoked key to be removed, got %v", err)
+	}
+}
+
+func TestAPIKeyAuthenticationSkipsWritesWithinTouchInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer store.Close()
+	now := time.Unix(1_700_000_000, 0)
+	store.now = func() time.Time { return now }
+
+	_, key, err := store.CreateAPIKey("reader", ScopeSearch, "admin")
+	if err != nil {
+		t.Fatalf("CreateAPIKey returned error: %v", err)
+	}
+	if _, err := store.AuthenticateAPIKey(key); err != nil {
+		t.Fatalf("AuthenticateAPIKey returned error: %v", err)
+	}
+
+	// 另一个连接持有写锁时，间隔内的校验不应再尝试写入数据库。
+	other, err := sql.Open("sqlite", "file:"+filepath.ToSlash(path)+"?_pragma=busy_timeout(0)")
+	if err != nil {
+		t.Fatalf("failed to open second connection: %v", err)
+	}
+	defer other.Close()
+	ctx := context.Background()
+	conn, err := other.Conn(ctx)
+	if err != nil {
+		t.Fatalf("failed to get connection: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		t.Fatalf("failed to take write lock: %v", err)
+	}
+	defer conn.ExecContext(ctx, "ROLLBACK")
+
+	now = now.Add(apiKeyTouchInterval / 2)
+	start := time.Now()
+	if _, err := store.AuthenticateAPIKey(key); err != nil {
+		t.Fatalf("expected authentication without a write, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected authentication not to wait for the write lock, took %v", elapsed)
+	}
+}
+
+func TestAPIKeyScopeRoles(t *testing.T) {
+	if _, err := ParseAPIKeyScope("owner"); err == nil {
+		t.Fatalf("expected unknown scope to be rejected")
+	}
+	if scope, err := ParseAPIKeyScope(" Admin "); err != nil || scope != ScopeAdmin || scope.Role() != RoleAdmin {
+		t.Fatalf("unexpected admin scope: %q %v", scope, err)
+	}
+	if ScopeSearch.AllowsDownload() || ScopeSearch.Role() != RoleGuest || !ScopeDownload.AllowsDownload() {
+		t.Fatalf("unexpected scope permissions")
+	}
+}
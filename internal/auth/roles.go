@@ -0,0 +1,51 @@
+// path: internal/auth/roles.go
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Role 是用户角色，权限按 guest < reader < librarian < admin 递增，高级角色拥有低级角色的全部权限。
+type Role string
+
+const (
+	// RoleAdmin 可以修改全部配置、管理用户与轮换签名密钥。
+	RoleAdmin Role = "admin"
+	// RoleLibrarian 可以管理数据源、查看统计并清空缓存。
+	RoleLibrarian Role = "librarian"
+	// RoleReader 是普通读者。
+	RoleReader Role = "reader"
+	// RoleGuest 是权限最低的访客账户。
+	RoleGuest Role = "guest"
+)
+
+var roleRanks = map[Role]int{
+	RoleGuest:     0,
+	RoleReader:    1,
+	RoleLibrarian: 2,
+	RoleAdmin:     3,
+}
+
+// Roles 按权限从低到高返回全部角色。
+func Roles() []Role {
+	return []Role{RoleGuest, RoleReader, RoleLibrarian, RoleAdmin}
+}
+
+// ParseRole 解析角色名称，大小写不敏感。
+func ParseRole(value string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(value)))
+	if _, ok := roleRanks[role]; !ok {
+		return "", fmt.Errorf("未知角色: %s（可选 admin、librarian、reader、guest）", value)
+	}
+	return role, nil
+}
+
+// Allows 返回该角色是否具备 required 所需的权限。
+func (r Role) Allows(required Role) bool {
+	rank, ok := roleRanks[r]
+	if !ok {
+		return false
+	}
+	return rank >= roleRanks[required]
+}
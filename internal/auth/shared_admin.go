@@ -0,0 +1,70 @@
+// path: internal/auth/shared_admin.go
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+func (s *Store) loadSharedAdmin() error {
+	var (
+		salt        []byte
+		fingerprint string
+		generation  int64
+	)
+	err := s.db.QueryRow("SELECT salt, fingerprint, generation FROM shared_admin WHERE id = 1").
+		Scan(&salt, &fingerprint, &generation)
+	if errors.Is(err, sql.ErrNoRows) {
+		salt = make([]byte, signingKeyBytes)
+		if _, err := rand.Read(salt); err != nil {
+			return fmt.Errorf("生成共享管理员指纹盐值失败: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("读取共享管理员令牌代次失败: %w", err)
+	}
+
+	s.sharedMu.Lock()
+	defer s.sharedMu.Unlock()
+	s.sharedSalt, s.sharedFingerprint, s.sharedGeneration = salt, fingerprint, generation
+	return nil
+}
+
+// SharedAdminGeneration 返回共享管理员令牌当前的凭证代次，adminPassword 修改后递增。
+func (s *Store) SharedAdminGeneration() string {
+	s.sharedMu.Lock()
+	defer s.sharedMu.Unlock()
+	return strconv.FormatInt(s.sharedGeneration, 10)
+}
+
+// SyncSharedAdminPassword 记录设置文件中 adminPassword 的当前值（明文或哈希）。与上次记录的值不同时递增
+// 共享管理员令牌的代次，此前签发的令牌随之失效；服务停止期间修改设置文件同样在下次启动时生效。
+// unchanged 为 true 表示调用方确认密码本身未变（如明文转为同一密码的哈希），只更新记录不递增代次。
+// 数据库写入失败时内存中的代次仍会更新，已签发的令牌按修改后处理。
+func (s *Store) SyncSharedAdminPassword(password string, unchanged bool) error {
+	s.sharedMu.Lock()
+	defer s.sharedMu.Unlock()
+
+	mac := hmac.New(sha256.New, s.sharedSalt)
+	mac.Write([]byte(password))
+	fingerprint := hex.EncodeToString(mac.Sum(nil))
+	if fingerprint == s.sharedFingerprint {
+		return nil
+	}
+	// 首次记录时沿用初始代次，升级前签发的令牌不带代次，已经无法通过校验。
+	if !unchanged && s.sharedFingerprint != "" {
+		s.sharedGeneration++
+	}
+	s.sharedFingerprint = fingerprint
+
+	if _, err := s.db.Exec(`INSERT OR REPLACE INTO shared_admin (id, salt, fingerprint, generation) VALUES (1, ?, ?, ?)`,
+		s.sharedSalt, fingerprint, s.sharedGeneration); err != nil {
+		return fmt.Errorf("保存共享管理员令牌代次失败: %w", err)
+	}
+	return nil
+}
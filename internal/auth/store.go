@@ -0,0 +1,292 @@
+// path: internal/auth/store.go
+package auth
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// signingKeyBytes 为 HS256 签名密钥的长度。
+const signingKeyBytes = 32
+
+const schemaSQL = `
+CREATE TABLE IF NOT EXISTS signing_keys (
+	id         TEXT    PRIMARY KEY,
+	secret     BLOB    NOT NULL,
+	created_at INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	jti        TEXT    PRIMARY KEY,
+	expires_at INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS users (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	username      TEXT    NOT NULL UNIQUE COLLATE NOCASE,
+	password_hash TEXT    NOT NULL,
+	role          TEXT    NOT NULL,
+	disabled      INTEGER NOT NULL DEFAULT 0,
+	token_version INTEGER NOT NULL DEFAULT 0,
+	created_at    INTEGER NOT NULL,
+	updated_at    INTEGER NOT NULL,
+	last_login_at INTEGER
+);
+CREATE TABLE IF NOT EXISTS shared_admin (
+	id          INTEGER PRIMARY KEY CHECK (id = 1),
+	salt        BLOB    NOT NULL,
+	fingerprint TEXT    NOT NULL,
+	generation  INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS api_keys (
+	id           TEXT    PRIMARY KEY,
+	name         TEXT    NOT NULL,
+	key_hash     TEXT    NOT NULL,
+	scope        TEXT    NOT NULL,
+	created_by   TEXT    NOT NULL,
+	created_at   INTEGER NOT NULL,
+	last_used_at INTEGER
+);
+`
+
+// Store 保存认证相关的持久化数据（JWT 签名密钥、已吊销的令牌、用户账户与 API Key），数据库位于实例目录下的 auth.db。
+// 吊销列表在内存中保留一份副本，校验令牌时无需访问数据库。
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+
+	mu         sync.RWMutex
+	keyID      string
+	key        []byte
+	keyCreated time.Time
+	revoked    map[string]time.Time
+
+	// usersMu 串行化用户的新增、修改与删除，保证“至少保留一个管理员”的检查不被并发绕过。
+	usersMu sync.Mutex
+
+	// touchMu 保护 touched，touched 记录本进程最近一次写入各 API Key last_used_at 的时间，
+	// 间隔内的请求不再执行 UPDATE，避免每次请求都争用 SQLite 写锁。
+	touchMu sync.Mutex
+	touched map[string]time.Time
+
+	// sharedMu 保护共享管理员密码的指纹与令牌代次，见 SyncSharedAdminPassword。
+	sharedMu          sync.Mutex
+	sharedSalt        []byte
+	sharedFingerprint string
+	sharedGeneration  int64
+}
+
+// Open 打开（必要时创建）认证数据库。首次打开时生成随机签名密钥并持久化，之后重启沿用同一密钥。
+func Open(path string) (*Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("未指定认证数据库路径")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("创建认证数据库目录失败: %w", err)
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", filepath.ToSlash(path))
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开认证数据库失败: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schemaSQL); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化认证数据库失败: %w", err)
+	}
+	if err := ensureColumn(db, "users", "token_version", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &Store{db: db, now: time.Now, revoked: make(map[string]time.Time), touched: make(map[string]time.Time)}
+	if err := s.loadSigningKey(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := s.loadRevoked(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := s.loadSharedAdmin(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close 关闭认证数据库。
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// ensureColumn 为旧版本创建的表补充缺少的列。
+func ensureColumn(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return fmt.Errorf("读取表 %s 结构失败: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			kind      string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &kind, &notNull, &dfltValue, &pk); err != nil {
+			return fmt.Errorf("读取表 %s 结构失败: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("读取表 %s 结构失败: %w", table, err)
+	}
+	rows.Close()
+	if _, err := db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition); err != nil {
+		return fmt.Errorf("升级表 %s 失败: %w", table, err)
+	}
+	return nil
+}
+
+func (s *Store) loadSigningKey() error {
+	var (
+		id      string
+		secret  []byte
+		created int64
+	)
+	err := s.db.QueryRow("SELECT id, secret, created_at FROM signing_keys ORDER BY created_at DESC LIMIT 1").
+		Scan(&id, &secret, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, _, err = s.RotateSigningKey()
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("读取签名密钥失败: %w", err)
+	}
+
+	s.mu.Lock()
+	s.keyID, s.key, s.keyCreated = id, secret, time.Unix(created, 0)
+	s.mu.Unlock()
+	return nil
+}
+
+// RotateSigningKey 生成新的签名密钥并替换旧密钥，此前签发的所有令牌随即失效。
+func (s *Store) RotateSigningKey() (string, time.Time, error) {
+	secret := make([]byte, signingKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", time.Time{}, fmt.Errorf("生成签名密钥失败: %w", err)
+	}
+	id, err := randomID()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	created := s.now()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("保存签名密钥失败: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM signing_keys"); err != nil {
+		return "", time.Time{}, fmt.Errorf("保存签名密钥失败: %w", err)
+	}
+	if _, err := tx.Exec("INSERT INTO signing_keys (id, secret, created_at) VALUES (?, ?, ?)", id, secret, created.Unix()); err != nil {
+		return "", time.Time{}, fmt.Errorf("保存签名密钥失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", time.Time{}, fmt.Errorf("保存签名密钥失败: %w", err)
+	}
+
+	s.mu.Lock()
+	s.keyID, s.key, s.keyCreated = id, secret, created
+	s.mu.Unlock()
+	return id, created, nil
+}
+
+// SigningKey 返回当前签名密钥的 ID、内容与生成时间。
+func (s *Store) SigningKey() (string, []byte, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keyID, s.key, s.keyCreated
+}
+
+func (s *Store) loadRevoked() error {
+	if _, err := s.db.Exec("DELETE FROM revoked_tokens WHERE expires_at < ?", s.now().Unix()); err != nil {
+		return fmt.Errorf("清理已过期的吊销记录失败: %w", err)
+	}
+	rows, err := s.db.Query("SELECT jti, expires_at FROM revoked_tokens")
+	if err != nil {
+		return fmt.Errorf("读取吊销列表失败: %w", err)
+	}
+	defer rows.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for rows.Next() {
+		var (
+			jti     string
+			expires int64
+		)
+		if err := rows.Scan(&jti, &expires); err != nil {
+			return fmt.Errorf("读取吊销列表失败: %w", err)
+		}
+		s.revoked[jti] = time.Unix(expires, 0)
+	}
+	return rows.Err()
+}
+
+// Revoke 吊销指定 jti 的令牌，记录保留到令牌本身过期为止。
+func (s *Store) Revoke(jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return fmt.Errorf("令牌缺少 jti")
+	}
+	now := s.now()
+	if _, err := s.db.Exec("INSERT OR REPLACE INTO revoked_tokens (jti, expires_at) VALUES (?, ?)", jti, expiresAt.Unix()); err != nil {
+		return fmt.Errorf("保存吊销记录失败: %w", err)
+	}
+	if _, err := s.db.Exec("DELETE FROM revoked_tokens WHERE expires_at < ?", now.Unix()); err != nil {
+		return fmt.Errorf("清理已过期的吊销记录失败: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[jti] = expiresAt
+	for id, expires := range s.revoked {
+		if expires.Before(now) {
+			delete(s.revoked, id)
+		}
+	}
+	return nil
+}
+
+// IsRevoked 返回指定 jti 的令牌是否已被吊销。
+func (s *Store) IsRevoked(jti string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, revoked := s.revoked[jti]
+	return revoked
+}
+
+func randomID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成随机 ID 失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTokensSurviveReopenAndFailAfterRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instance", "auth.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+
+	token, claims, err := store.IssueToken("admin", "", time.Hour)
+	if err != nil {
+		t.Fatalf("IssueToken returned error: %v", err)
+	}
+	if claims.ID == "" {
+		t.Fatalf("expected token to carry a jti")
+	}
+	store.Close()
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen returned error: %v", err)
+	}
+	defer reopened.Close()
+	if _, err := reopened.ParseToken(token); err != nil {
+		t.Fatalf("expected token to remain valid after restart: %v", err)
+	}
+
+	if _, _, err := reopened.RotateSigningKey(); err != nil {
+		t.Fatalf("RotateSigningKey returned error: %v", err)
+	}
+	if _, err := reopened.ParseToken(token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected rotated key to invalidate old token, got %v", err)
+	}
+}
+
+func TestRevokedTokensArePersistedUntilExpiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+
+	revoked, revokedClaims, _ := store.IssueToken("admin", "", time.Hour)
+	kept, _, _ := store.IssueToken("admin", "", time.Hour)
+	if err := store.Revoke(revokedClaims.ID, revokedClaims.ExpiresAt.Time); err != nil {
+		t.Fatalf("Revoke returned error: %v", err)
+	}
+	store.Close()
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen returned error: %v", err)
+	}
+	defer reopened.Close()
+	if _, err := reopened.ParseToken(revoked); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected revoked token to be rejected after restart, got %v", err)
+	}
+	if _, err := reopened.ParseToken(kept); err != nil {
+		t.Fatalf("expected other token to stay valid: %v", err)
+	}
+
+	reopened.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := reopened.ParseToken(kept); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected expired token to be rejected, got %v", err)
+	}
+}
+
+func TestParseTokenRejectsForeignSignatures(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "auth.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer store.Close()
+
+	keyID, _, _ := store.SigningKey()
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "admin",
+		"jti": "forged",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	forged.Header["kid"] = keyID
+	signed, err := forged.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("failed to sign forged token: %v", err)
+	}
+	if _, err := store.ParseToken(signed); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected token signed with another key to be rejected, got %v", err)
+	}
+}
+
+func TestSharedAdminGenerationFollowsPasswordChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+
+	record := func(store *Store, password string, unchanged bool) string {
+		t.Helper()
+		if err := store.SyncSharedAdminPassword(password, unchanged); err != nil {
+			t.Fatalf("SyncSharedAdminPassword returned error: %v", err)
+		}
+		return store.SharedAdminGeneration()
+	}
+	initial := record(store, "secret", false)
+	if got := record(store, "secret", false); got != initial {
+		t.Fatalf("expected the same password to keep the generation, got %s -> %s", initial, got)
+	}
+	if got := record(store, "$2a$10$hashed", true); got != initial {
+		t.Fatalf("expected an unchanged password to keep the generation, got %s -> %s", initial, got)
+	}
+	changed := record(store, "$2a$10$another", false)
+	if changed == initial {
+		t.Fatalf("expected a new password to bump the generation")
+	}
+	store.Close()
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen returned error: %v", err)
+	}
+	defer reopened.Close()
+	if got := record(reopened, "$2a$10$another", false); got != changed {
+		t.Fatalf("expected the generation to survive a restart, got %s -> %s", changed, got)
+	}
+	// 服务停止期间修改了设置文件。
+	if got := record(reopened, "edited-offline", false); got == changed {
+		t.Fatalf("expected a password edited while stopped to bump the generation")
+	}
+}
@@ -0,0 +1,89 @@
+// path: internal/auth/token.go
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken 表示令牌无法通过校验：签名错误、已过期、由已轮换的密钥签发或已被吊销。
+var ErrInvalidToken = errors.New("令牌无效")
+
+// SharedAdminSubject 是使用设置文件中的 adminPassword 登录时令牌的 subject。
+const SharedAdminSubject = "admin"
+
+// userSubjectPrefix 是用户账户令牌 subject 的前缀，避免与 SharedAdminSubject 冲突。
+const userSubjectPrefix = "user:"
+
+// UserSubject 返回用户账户令牌的 subject。
+func UserSubject(username string) string {
+	return userSubjectPrefix + username
+}
+
+// UsernameFromSubject 从用户账户令牌的 subject 中取出用户名。
+func UsernameFromSubject(subject string) (string, bool) {
+	return strings.CutPrefix(subject, userSubjectPrefix)
+}
+
+// UserTokenGeneration 返回用户令牌的凭证代次：用户 ID 与令牌版本。删除后重建的同名用户 ID 不同，
+// 修改密码、停用或启用会递增令牌版本，代次不一致的令牌不再有效。
+func UserTokenGeneration(user User) string {
+	return fmt.Sprintf("%d.%d", user.ID, user.TokenVersion)
+}
+
+// Claims 是服务签发的 JWT 声明，ID（jti）用于吊销单个令牌，Generation 记录签发时 subject 的凭证代次。
+type Claims struct {
+	jwt.RegisteredClaims
+	Generation string `json:"gen,omitempty"`
+}
+
+// IssueToken 使用当前签名密钥为 subject 签发有效期为 ttl 的令牌，generation 由调用方在校验令牌时比对。
+func (s *Store) IssueToken(subject, generation string, ttl time.Duration) (string, *Claims, error) {
+	jti, err := randomID()
+	if err != nil {
+		return "", nil, err
+	}
+	keyID, key, _ := s.SigningKey()
+	now := s.now()
+
+	claims := &Claims{RegisteredClaims: jwt.RegisteredClaims{
+		ID:        jti,
+		Subject:   subject,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}, Generation: generation}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = keyID
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return "", nil, fmt.Errorf("签名令牌失败: %w", err)
+	}
+	return signed, claims, nil
+}
+
+// ParseToken 校验令牌的签名、有效期、签名密钥与吊销状态，通过后返回其声明。
+func (s *Store) ParseToken(tokenString string) (*Claims, error) {
+	keyID, key, _ := s.SigningKey()
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		if kid, _ := token.Header["kid"].(string); kid != keyID {
+			return nil, fmt.Errorf("签名密钥已轮换")
+		}
+		return key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if claims.ID == "" || s.IsRevoked(claims.ID) {
+		return nil, fmt.Errorf("%w: 令牌已被吊销", ErrInvalidToken)
+	}
+	return claims, nil
+}
@@ -0,0 +1,281 @@
+// path: internal/auth/users.go
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	minPasswordLength = 8
+	// maxPasswordBytes 是 bcrypt 能处理的最大密码长度。
+	maxPasswordBytes = 72
+)
+
+var (
+	// ErrUserNotFound 表示用户不存在。
+	ErrUserNotFound = errors.New("用户不存在")
+	// ErrUserExists 表示用户名已被占用。
+	ErrUserExists = errors.New("用户名已存在")
+	// ErrInvalidCredentials 表示用户名或密码错误，或账户已停用。
+	ErrInvalidCredentials = errors.New("用户名或密码错误")
+	// ErrInvalidUser 表示用户名、密码或角色不符合要求。
+	ErrInvalidUser = errors.New("用户信息不合法")
+	// ErrLastAdmin 表示操作会移除最后一个可用的管理员。
+	ErrLastAdmin = errors.New("至少需要保留一个启用的管理员")
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,32}$`)
+
+// User 是用户存储中的一个账户，不包含密码哈希。TokenVersion 在修改密码、停用或启用时递增，用于使旧令牌失效。
+type User struct {
+	ID           int64      `json:"id"`
+	Username     string     `json:"username"`
+	Role         Role       `json:"role"`
+	Disabled     bool       `json:"disabled"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	LastLoginAt  *time.Time `json:"lastLoginAt,omitempty"`
+	TokenVersion int64      `json:"-"`
+}
+
+// UserUpdate 描述对用户的部分修改，为 nil 的字段保持不变。
+type UserUpdate struct {
+	Password *string
+	Role     *Role
+	Disabled *bool
+}
+
+// ValidateUsername 校验用户名：3 到 32 个字母、数字或 _.- 字符。
+func ValidateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return fmt.Errorf("用户名需为 3 到 32 个字母、数字或 _.- 字符")
+	}
+	return nil
+}
+
+// ValidatePassword 校验密码长度。
+func ValidatePassword(password string) error {
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return fmt.Errorf("密码长度不能少于 %d 个字符", minPasswordLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("密码长度不能超过 %d 字节", maxPasswordBytes)
+	}
+	return nil
+}
+
+// CreateUser 新建用户，密码以 bcrypt 哈希保存。
+func (s *Store) CreateUser(username, password string, role Role) (User, error) {
+	username = strings.TrimSpace(username)
+	if err := ValidateUsername(username); err != nil {
+		return User{}, fmt.Errorf("%w: %w", ErrInvalidUser, err)
+	}
+	if err := ValidatePassword(password); err != nil {
+		return User{}, fmt.Errorf("%w: %w", ErrInvalidUser, err)
+	}
+	if _, err := ParseRole(string(role)); err != nil {
+		return User{}, fmt.Errorf("%w: %w", ErrInvalidUser, err)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return User{}, fmt.Errorf("生成密码哈希失败: %w", err)
+	}
+
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+	if _, err := s.GetUser(username); err == nil {
+		return User{}, fmt.Errorf("%w: %s", ErrUserExists, username)
+	} else if !errors.Is(err, ErrUserNotFound) {
+		return User{}, err
+	}
+
+	now := s.now().Unix()
+	if _, err := s.db.Exec(`INSERT INTO users (username, password_hash, role, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)`, username, string(hash), string(role), now, now); err != nil {
+		return User{}, fmt.Errorf("保存用户失败: %w", err)
+	}
+	return s.GetUser(username)
+}
+
+// GetUser 按用户名（大小写不敏感）查找用户。
+func (s *Store) GetUser(username string) (User, error) {
+	row := s.db.QueryRow(`SELECT id, username, role, disabled, created_at, updated_at, last_login_at, token_version
+		FROM users WHERE username = ?`, strings.TrimSpace(username))
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("读取用户失败: %w", err)
+	}
+	return user, nil
+}
+
+// ListUsers 按用户名排序返回全部用户。
+func (s *Store) ListUsers() ([]User, error) {
+	rows, err := s.db.Query(`SELECT id, username, role, disabled, created_at, updated_at, last_login_at, token_version
+		FROM users ORDER BY username COLLATE NOCASE`)
+	if err != nil {
+		return nil, fmt.Errorf("读取用户列表失败: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("读取用户列表失败: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// UpdateUser 修改用户的密码、角色或停用状态。降级、停用最后一个启用的管理员时返回 ErrLastAdmin。
+// 修改密码或切换停用状态会递增令牌版本，此前签发的令牌随之失效。
+func (s *Store) UpdateUser(username string, update UserUpdate) (User, error) {
+	current, err := s.GetUser(username)
+	if err != nil {
+		return User{}, err
+	}
+
+	sets := []string{"updated_at = ?"}
+	args := []any{s.now().Unix()}
+	if update.Password != nil {
+		if err := ValidatePassword(*update.Password); err != nil {
+			return User{}, fmt.Errorf("%w: %w", ErrInvalidUser, err)
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(*update.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return User{}, fmt.Errorf("生成密码哈希失败: %w", err)
+		}
+		sets = append(sets, "password_hash = ?")
+		args = append(args, string(hash))
+	}
+	if update.Role != nil {
+		if _, err := ParseRole(string(*update.Role)); err != nil {
+			return User{}, fmt.Errorf("%w: %w", ErrInvalidUser, err)
+		}
+		sets = append(sets, "role = ?")
+		args = append(args, string(*update.Role))
+	}
+	if update.Disabled != nil {
+		sets = append(sets, "disabled = ?")
+		args = append(args, *update.Disabled)
+	}
+	if update.Password != nil || update.Disabled != nil && *update.Disabled != current.Disabled {
+		sets = append(sets, "token_version = token_version + 1")
+	}
+
+	losesAdmin := current.Role == RoleAdmin && !current.Disabled &&
+		((update.Role != nil && *update.Role != RoleAdmin) || (update.Disabled != nil && *update.Disabled))
+
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+	if losesAdmin {
+		if err := s.ensureOtherAdminLocked(current.ID); err != nil {
+			return User{}, err
+		}
+	}
+	args = append(args, current.ID)
+	if _, err := s.db.Exec("UPDATE users SET "+strings.Join(sets, ", ")+" WHERE id = ?", args...); err != nil {
+		return User{}, fmt.Errorf("更新用户失败: %w", err)
+	}
+	return s.GetUser(current.Username)
+}
+
+// DeleteUser 删除用户。删除最后一个启用的管理员时返回 ErrLastAdmin。
+func (s *Store) DeleteUser(username string) error {
+	current, err := s.GetUser(username)
+	if err != nil {
+		return err
+	}
+
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+	if current.Role == RoleAdmin && !current.Disabled {
+		if err := s.ensureOtherAdminLocked(current.ID); err != nil {
+			return err
+		}
+	}
+	if _, err := s.db.Exec("DELETE FROM users WHERE id = ?", current.ID); err != nil {
+		return fmt.Errorf("删除用户失败: %w", err)
+	}
+	return nil
+}
+
+// ensureOtherAdminLocked 确认除 id 之外仍有启用的管理员。调用方需持有 usersMu，以串行化管理员相关的修改。
+func (s *Store) ensureOtherAdminLocked(id int64) error {
+	var others int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM users WHERE role = ? AND disabled = 0 AND id <> ?",
+		string(RoleAdmin), id).Scan(&others); err != nil {
+		return fmt.Errorf("统计管理员失败: %w", err)
+	}
+	if others == 0 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// Authenticate 校验用户名与密码，成功时记录登录时间并返回用户。用户不存在、密码错误或账户停用时
+// 统一返回 ErrInvalidCredentials。
+func (s *Store) Authenticate(username, password string) (User, error) {
+	var (
+		hash string
+		id   int64
+	)
+	err := s.db.QueryRow("SELECT id, password_hash FROM users WHERE username = ? AND disabled = 0",
+		strings.TrimSpace(username)).Scan(&id, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		// 仍执行一次哈希比较，避免通过响应时间判断用户名是否存在。
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("读取用户失败: %w", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return User{}, ErrInvalidCredentials
+	}
+
+	if _, err := s.db.Exec("UPDATE users SET last_login_at = ? WHERE id = ?", s.now().Unix(), id); err != nil {
+		return User{}, fmt.Errorf("更新登录时间失败: %w", err)
+	}
+	return s.GetUser(username)
+}
+
+// dummyHash 用于用户不存在时的等时比较。
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("ebookdatabase-dummy-password"), bcrypt.DefaultCost)
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (User, error) {
+	var (
+		user      User
+		role      string
+		created   int64
+		updated   int64
+		lastLogin sql.NullInt64
+	)
+	if err := row.Scan(&user.ID, &user.Username, &role, &user.Disabled, &created, &updated, &lastLogin, &user.TokenVersion); err != nil {
+		return User{}, err
+	}
+	user.Role = Role(role)
+	user.CreatedAt = time.Unix(created, 0).UTC()
+	user.UpdatedAt = time.Unix(updated, 0).UTC()
+	if lastLogin.Valid {
+		at := time.Unix(lastLogin.Int64, 0).UTC()
+		user.LastLoginAt = &at
+	}
+	return user, nil
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestUserLifecycleAndLastAdminGuard(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "auth.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer store.Close()
+
+	if _, err := store.CreateUser("alice", "correct horse", RoleAdmin); err != nil {
+		t.Fatalf("CreateUser returned error: %v", err)
+	}
+	if _, err := store.CreateUser("ALICE", "another password", RoleReader); !errors.Is(err, ErrUserExists) {
+		t.Fatalf("expected case-insensitive duplicate to be rejected, got %v", err)
+	}
+	if _, err := store.CreateUser("bob", "short", RoleReader); !errors.Is(err, ErrInvalidUser) {
+		t.Fatalf("expected short password to be rejected, got %v", err)
+	}
+
+	user, err := store.Authenticate("Alice", "correct horse")
+	if err != nil || user.Username != "alice" || user.LastLoginAt == nil {
+		t.Fatalf("expected successful login with recorded time, got %+v %v", user, err)
+	}
+	if _, err := store.Authenticate("alice", "wrong password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected wrong password to fail, got %v", err)
+	}
+	if _, err := store.Authenticate("nobody", "correct horse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected unknown user to fail, got %v", err)
+	}
+
+	reader := RoleReader
+	if _, err := store.UpdateUser("alice", UserUpdate{Role: &reader}); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("expected demoting the last admin to fail, got %v", err)
+	}
+	if err := store.DeleteUser("alice"); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("expected deleting the last admin to fail, got %v", err)
+	}
+
+	if _, err := store.CreateUser("carol", "another password", RoleAdmin); err != nil {
+		t.Fatalf("CreateUser returned error: %v", err)
+	}
+	disabled := true
+	if _, err := store.UpdateUser("alice", UserUpdate{Disabled: &disabled}); err != nil {
+		t.Fatalf("expected disabling alice to succeed with another admin present: %v", err)
+	}
+	if _, err := store.Authenticate("alice", "correct horse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected disabled user to be unable to log in, got %v", err)
+	}
+
+	users, err := store.ListUsers()
+	if err != nil || len(users) != 2 || users[0].Username != "alice" || !users[0].Disabled {
+		t.Fatalf("unexpected user list: %+v %v", users, err)
+	}
+}
+
+func TestTokenVersionTracksCredentialChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.db")
+	legacy, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to open legacy db: %v", err)
+	}
+	// 旧版本创建的 users 表没有 token_version 列。
+	if _, err := legacy.Exec(`CREATE TABLE users (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	username      TEXT    NOT NULL UNIQUE COLLATE NOCASE,
+	password_hash TEXT    NOT NULL,
+	role          TEXT    NOT NULL,
+	disabled      INTEGER NOT NULL DEFAULT 0,
+	created_at    INTEGER NOT NULL,
+	updated_at    INTEGER NOT NULL,
+	last_login_at INTEGER
+)`); err != nil {
+		t.Fatalf("failed to create legacy users table: %v", err)
+	}
+	legacy.Close()
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer store.Close()
+	if _, err := store.CreateUser("alice", "correct horse", RoleAdmin); err != nil {
+		t.Fatalf("CreateUser returned error: %v", err)
+	}
+	user, err := store.CreateUser("bob", "battery staple", RoleReader)
+	if err != nil || user.TokenVersion != 0 {
+		t.Fatalf("unexpected new user: %+v %v", user, err)
+	}
+	generation := UserTokenGeneration(user)
+
+	librarian, password, disabled, enabled := RoleLibrarian, "another password", true, false
+	steps := []struct {
+		update  UserUpdate
+		version int64
+	}{
+		{UserUpdate{Role: &librarian}, 0},
+		{UserUpdate{Password: &password}, 1},
+		{UserUpdate{Disabled: &disabled}, 2},
+		{UserUpdate{Disabled: &disabled}, 2},
+		{UserUpdate{Disabled: &enabled}, 3},
+	}
+	for _, step := range steps {
+		if user, err = store.UpdateUser("bob", step.update); err != nil || user.TokenVersion != step.version {
+			t.Fatalf("expected token version %d, got %+v %v", step.version, user, err)
+		}
+	}
+
+	if err := store.DeleteUser("bob"); err != nil {
+		t.Fatalf("DeleteUser returned error: %v", err)
+	}
+	recreated, err := store.CreateUser("bob", "battery staple", RoleReader)
+	if err != nil || UserTokenGeneration(recreated) == generation {
+		t.Fatalf("expected recreated user to get a new token generation, got %+v %v", recreated, err)
+	}
+}
+
+func TestRoleHierarchy(t *testing.T) {
+	if !RoleAdmin.Allows(RoleLibrarian) || !RoleLibrarian.Allows(RoleReader) || !RoleReader.Allows(RoleGuest) {
+		t.Fatalf("expected higher roles to include lower ones")
+	}
+	if RoleReader.Allows(RoleLibrarian) || Role("root").Allows(RoleGuest) {
+		t.Fatalf("expected lower or unknown roles to be denied")
+	}
+	if role, err := ParseRole(" Librarian "); err != nil || role != RoleLibrarian {
+		t.Fatalf("ParseRole = %q, %v", role, err)
+	}
+}
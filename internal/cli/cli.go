@@ -0,0 +1,162 @@
+// path: internal/cli/cli.go
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"ebookdatabase/config"
+	"ebookdatabase/logger"
+)
+
+const (
+	// DefaultConfigPath 是未指定 --config 时使用的设置文件路径。
+	DefaultConfigPath = "static/settings.json"
+	// DefaultListenAddr 是未指定 --listen 时 serve 监听的地址。
+	DefaultListenAddr = ":10223"
+)
+
+// errUsage 表示参数错误，用法说明已输出，调用方只需返回退出码 2。
+var errUsage = errors.New("参数错误")
+
+type command struct {
+	name    string
+	summary string
+	run     func(env *environment, args []string) error
+}
+
+// environment 汇总子命令可使用的输出流，便于测试时替换。
+type environment struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+var commands = []command{
+	{name: "serve", summary: "启动 HTTP 服务（默认子命令）", run: runServe},
+	{name: "reindex", summary: "重建指定数据源的全文索引", run: runReindex},
+	{name: "check", summary: "检查指定数据源的完整性与表结构", run: runCheck},
+	{name: "search", summary: "在终端中搜索并以表格或 JSON 输出结果", run: runSearch},
+	{name: "hash-password", summary: "生成可填入 adminPassword 的 bcrypt 哈希", run: runHashPassword},
+}
+
+// Run 解析命令行参数并执行对应子命令，返回进程退出码。未指定子命令时等同于 serve。
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	env := &environment{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		printUsage(stdout)
+		return 0
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(stderr, "未知子命令: %s\n\n", name)
+		printUsage(stderr)
+		return 2
+	}
+
+	if err := cmd.run(env, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		fmt.Fprintf(stderr, "错误: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "用法: ebook-server [子命令] [参数]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "子命令:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "使用 \"ebook-server <子命令> -h\" 查看子命令参数。")
+}
+
+// newFlagSet 创建子命令的参数集合，错误信息与用法说明输出到 stderr。
+func newFlagSet(env *environment, name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(env.stderr, "用法: ebook-server %s %s\n\n参数:\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs 允许参数与位置参数交替出现，例如 "reindex legacy --config x.json"。
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, errUsage
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// useConsoleLogger 让非 serve 子命令只把警告及以上日志输出到 stderr，避免干扰结果输出。
+func useConsoleLogger(env *environment) {
+	handler := slog.NewTextHandler(env.stderr, &slog.HandlerOptions{Level: slog.LevelWarn})
+	slog.SetDefault(slog.New(logger.NewContextHandler(handler)))
+}
+
+// loadDatasource 读取配置并返回指定名称的数据源配置，未启用的数据源同样可以被离线处理。
+func loadDatasource(configPath, name string) (config.DatasourceConfig, error) {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return config.DatasourceConfig{}, fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	names := make([]string, 0, len(cfg.Datasources))
+	for _, item := range cfg.Datasources {
+		if item.Name == name {
+			return item, nil
+		}
+		names = append(names, item.Name)
+	}
+	sort.Strings(names)
+	return config.DatasourceConfig{}, fmt.Errorf("数据源 %s 不存在，可用数据源: %s", name, strings.Join(names, ", "))
+}
+
+// requireSource 校验子命令恰好收到一个数据源名称。
+func requireSource(fs *flag.FlagSet, positional []string) (string, error) {
+	if len(positional) != 1 || strings.TrimSpace(positional[0]) == "" {
+		fs.Usage()
+		return "", errUsage
+	}
+	return strings.TrimSpace(positional[0]), nil
+}
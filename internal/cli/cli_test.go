@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	_ "modernc.org/sqlite"
+
+	"ebookdatabase/internal/api"
+)
+
+func TestHashPasswordFromArgumentAndStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"hash-password", "--cost", "4", "s3cret"}, strings.NewReader(""), &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	hash := strings.TrimSpace(stdout.String())
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte("s3cret")); err != nil {
+		t.Fatalf("expected a valid bcrypt hash, got %q: %v", hash, err)
+	}
+
+	stdout.Reset()
+	if code := Run([]string{"hash-password", "--cost", "4"}, strings.NewReader("from-stdin\n"), &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(strings.TrimSpace(stdout.String())), []byte("from-stdin")); err != nil {
+		t.Fatalf("expected stdin password to be hashed: %v", err)
+	}
+}
+
+func TestRunRejectsUnknownCommandAndMissingSource(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"bogus"}, strings.NewReader(""), &stdout, &stderr); code != 2 {
+		t.Fatalf("expected exit code 2 for unknown command, got %d", code)
+	}
+	if code := Run([]string{"reindex"}, strings.NewReader(""), &stdout, &stderr); code != 2 {
+		t.Fatalf("expected exit code 2 without datasource, got %d", code)
+	}
+}
+
+func TestCheckReindexAndSearch(t *testing.T) {
+	configPath := writeTestSettings(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"check", "library", "--config", configPath}, strings.NewReader(""), &stdout, &stderr); code != 0 {
+		t.Fatalf("check exit code %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	for _, want := range []string{"legacy_id", "图书数量: 2", "完整性: ok"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Fatalf("expected check report to contain %q, got:\n%s", want, stdout.String())
+		}
+	}
+
+	stdout.Reset()
+	if code := Run([]string{"reindex", "library", "--config", configPath}, strings.NewReader(""), &stdout, &stderr); code != 0 {
+		t.Fatalf("reindex exit code %d: %s", code, stderr.String())
+	}
+	if code := Run([]string{"reindex", "missing", "--config", configPath}, strings.NewReader(""), &stdout, &stderr); code != 1 {
+		t.Fatalf("expected reindex of unknown datasource to fail, got %d", code)
+	}
+
+	stdout.Reset()
+	if code := Run([]string{"search", "--config", configPath, "--format", "json", "--fuzzy", "true", "Go"}, strings.NewReader(""), &stdout, &stderr); code != 0 {
+		t.Fatalf("search exit code %d: %s", code, stderr.String())
+	}
+	var result api.SearchResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("expected JSON output: %v\n%s", err, stdout.String())
+	}
+	if result.TotalRecords != 1 || len(result.Books) != 1 || result.Books[0].Title != "Go Systems" {
+		t.Fatalf("unexpected search result: %+v", result)
+	}
+
+	stdout.Reset()
+	if code := Run([]string{"search", "--config", configPath, "Rust", "Basics"}, strings.NewReader(""), &stdout, &stderr); code != 0 {
+		t.Fatalf("search exit code %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Rust Basics") || !strings.Contains(stdout.String(), "书名") {
+		t.Fatalf("expected table output, got:\n%s", stdout.String())
+	}
+}
+
+func TestSearchIgnoresAccessPolicyAndInstanceStores(t *testing.T) {
+	configPath := writeTestSettings(t)
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	restricted := strings.Replace(string(data), `"type": "legacy_db"`, `"type": "legacy_db", "access": {"search": {"roles": ["admin"]}}`, 1)
+	restricted = strings.Replace(restricted, `"pageSize": 10,`, `"pageSize": 10,
+  "auth": {"accessPolicy": "loginRequired"},`, 1)
+	if err := os.WriteFile(configPath, []byte(restricted), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"search", "--config", configPath, "--sources", "library", "--fuzzy", "true", "Go"}, strings.NewReader(""), &stdout, &stderr); code != 0 {
+		t.Fatalf("search exit code %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Go Systems") {
+		t.Fatalf("expected restricted datasource to be searched, got:\n%s", stdout.String())
+	}
+	for _, name := range []string{"auth.db", "audit.db", "shelves.db"} {
+		if _, err := os.Stat(filepath.Join(filepath.Dir(configPath), "instance", name)); !os.IsNotExist(err) {
+			t.Fatalf("expected search not to create %s, got %v", name, err)
+		}
+	}
+}
+
+func writeTestSettings(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "library.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("failed to open sqlite database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE books (
+id INTEGER PRIMARY KEY,
+title TEXT,
+author TEXT,
+publisher TEXT,
+publish_date TEXT,
+ISBN TEXT,
+SS_code TEXT,
+dxid TEXT
+)`); err != nil {
+		t.Fatalf("failed to create books table: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO books (id, title, author, publisher, publish_date, ISBN, SS_code, dxid) VALUES
+(1, 'Go Systems', 'Alice', 'Tech Press', '2026', '9780000000001', 'SS1', 'DX1'),
+(2, 'Rust Basics', 'Bob', 'Tech Press', '2025', '9780000000002', 'SS2', 'DX2')`); err != nil {
+		t.Fatalf("failed to seed books table: %v", err)
+	}
+
+	configPath := filepath.Join(dir, "settings.json")
+	settings := `{
+  "pageSize": 10,
+  "defaultSearchField": "title",
+  "adminPassword": "secret",
+  "instanceDir": "` + filepath.ToSlash(filepath.Join(dir, "instance")) + `",
+  "datasources": [{"name": "library", "type": "legacy_db", "path": "` + filepath.ToSlash(dbPath) + `"}]
+}`
+	if err := os.WriteFile(configPath, []byte(settings), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return configPath
+}
@@ -0,0 +1,110 @@
+// path: internal/cli/maintenance.go
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ebookdatabase/internal/infra"
+)
+
+const maintenanceTimeout = 30 * time.Minute
+
+// errCheckFailed 表示 check 发现了问题，报告已输出，仅需以非零状态退出。
+var errCheckFailed = errors.New("数据源检查未通过")
+
+func runReindex(env *environment, args []string) error {
+	fs := newFlagSet(env, "reindex", "<数据源名称> [--config 路径]")
+	configPath := fs.String("config", DefaultConfigPath, "设置文件路径")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	name, err := requireSource(fs, positional)
+	if err != nil {
+		return err
+	}
+	useConsoleLogger(env)
+
+	item, err := loadDatasource(*configPath, name)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), maintenanceTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := infra.NewDBManager().ReindexSource(ctx, item); err != nil {
+		return fmt.Errorf("重建 %s 的全文索引失败: %w", name, err)
+	}
+	fmt.Fprintf(env.stdout, "已重建 %s 的全文索引，用时 %s\n", name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func runCheck(env *environment, args []string) error {
+	fs := newFlagSet(env, "check", "<数据源名称> [--config 路径]")
+	configPath := fs.String("config", DefaultConfigPath, "设置文件路径")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	name, err := requireSource(fs, positional)
+	if err != nil {
+		return err
+	}
+	useConsoleLogger(env)
+
+	item, err := loadDatasource(*configPath, name)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), maintenanceTimeout)
+	defer cancel()
+
+	mgr := infra.NewDBManager()
+	out := env.stdout
+	fmt.Fprintf(out, "数据源: %s (%s)\n", item.Name, item.Type)
+	fmt.Fprintf(out, "路径: %s\n", item.Path)
+
+	failed := false
+	info, err := mgr.InspectSource(ctx, item)
+	if err != nil {
+		failed = true
+		fmt.Fprintf(out, "表结构: 检查失败: %v\n", err)
+	} else {
+		fmt.Fprintf(out, "表结构: %s\n", info.Schema)
+		fmt.Fprintf(out, "图书数量: %d\n", info.BookCount)
+		switch {
+		case info.FTSTable == "":
+			fmt.Fprintln(out, "全文索引: 无")
+		case info.FTSReady:
+			fmt.Fprintf(out, "全文索引: %s（已就绪）\n", info.FTSTable)
+		default:
+			fmt.Fprintf(out, "全文索引: %s（缺失，可执行 reindex 重建）\n", info.FTSTable)
+		}
+	}
+
+	problems, err := mgr.CheckSourceIntegrity(ctx, item)
+	switch {
+	case err != nil:
+		failed = true
+		fmt.Fprintf(out, "完整性: 检查失败: %v\n", err)
+	case len(problems) == 0:
+		fmt.Fprintln(out, "完整性: ok")
+	default:
+		failed = true
+		fmt.Fprintf(out, "完整性: 发现 %d 个问题\n", len(problems))
+		for _, problem := range problems {
+			fmt.Fprintf(out, "  - %s\n", problem)
+		}
+	}
+
+	if failed {
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// path: internal/cli/password.go
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// runHashPassword 输出密码的 bcrypt 哈希。未提供位置参数时从标准输入读取一行，避免密码留在 shell 历史中。
+func runHashPassword(env *environment, args []string) error {
+	fs := newFlagSet(env, "hash-password", "[密码] [--cost 成本]")
+	cost := fs.Int("cost", bcrypt.DefaultCost, fmt.Sprintf("bcrypt 成本（%d-%d）", bcrypt.MinCost, bcrypt.MaxCost))
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		fs.Usage()
+		return errUsage
+	}
+	if *cost < bcrypt.MinCost || *cost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt 成本需在 %d 到 %d 之间", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	var password string
+	if len(positional) == 1 {
+		password = positional[0]
+	} else {
+		fmt.Fprint(env.stderr, "请输入密码: ")
+		scanner := bufio.NewScanner(env.stdin)
+		if scanner.Scan() {
+			password = scanner.Text()
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("读取密码失败: %w", err)
+		}
+		fmt.Fprintln(env.stderr)
+	}
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		return fmt.Errorf("密码不能为空")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), *cost)
+	if err != nil {
+		return fmt.Errorf("生成密码哈希失败: %w", err)
+	}
+	fmt.Fprintln(env.stdout, string(hash))
+	return nil
+}
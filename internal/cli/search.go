@@ -0,0 +1,135 @@
+// path: internal/cli/search.go
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"ebookdatabase/config"
+	"ebookdatabase/internal/api"
+	"ebookdatabase/internal/infra"
+)
+
+// runSearch 在本进程内初始化数据源并直接调用与 HTTP 接口相同的搜索逻辑，参数校验、归并与跳过规则保持一致。
+// 命令行作为本机可信调用方，不受登录策略、数据源访问控制与限流影响，也不会创建认证等实例数据库。
+func runSearch(env *environment, args []string) error {
+	fs := newFlagSet(env, "search", "<关键字> [--field 字段] [--sources a,b] [--format table|json]")
+	configPath := fs.String("config", DefaultConfigPath, "设置文件路径")
+	field := fs.String("field", "", "搜索字段，默认使用配置中的 defaultSearchField")
+	sources := fs.String("sources", "", "仅搜索这些数据源，多个名称以逗号分隔")
+	fuzzy := fs.String("fuzzy", "", "是否模糊匹配（true/false），默认沿用数据源配置")
+	page := fs.Int("page", 1, "页码")
+	pageSize := fs.Int("page-size", 0, "每页条数，默认使用配置中的 pageSize")
+	format := fs.String("format", "table", "输出格式：table 或 json")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	query := strings.TrimSpace(strings.Join(positional, " "))
+	if query == "" {
+		fs.Usage()
+		return errUsage
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("不支持的输出格式: %s", *format)
+	}
+	useConsoleLogger(env)
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	requested := splitNames(*sources)
+
+	mgr := infra.NewDBManager()
+	defer mgr.Close()
+	if err := mgr.InitFromConfig(selectDatasources(cfg, requested)); err != nil {
+		fmt.Fprintf(env.stderr, "部分数据源初始化失败: %v\n", err)
+	}
+
+	searcher, err := api.NewLocalSearcher(cfg, mgr)
+	if err != nil {
+		return err
+	}
+
+	values := url.Values{}
+	values.Set("query", query)
+	if *field != "" {
+		values.Set("field", *field)
+	}
+	if *fuzzy != "" {
+		values.Set("fuzzy", *fuzzy)
+	}
+	values.Set("page", strconv.Itoa(*page))
+	if *pageSize > 0 {
+		values.Set("pageSize", strconv.Itoa(*pageSize))
+	}
+	for _, name := range requested {
+		values.Add("sources[]", name)
+	}
+
+	result, err := searcher.Search(context.Background(), values)
+	if err != nil {
+		return fmt.Errorf("搜索失败: %w", err)
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(env.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	return printSearchTable(env, result, *page)
+}
+
+func printSearchTable(env *environment, result *api.SearchResult, page int) error {
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "来源\tID\t书名\t作者\t出版社\t出版日期")
+	for _, book := range result.Books {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			book.Source, book.ID, book.Title, strings.Join(book.Authors, " / "), book.Publisher, book.PublishDate)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "\n共 %d 条，第 %d/%d 页，用时 %d ms\n", result.TotalRecords, page, result.TotalPages, result.SearchTimeMs)
+	for _, skipped := range result.SkippedSources {
+		fmt.Fprintf(env.stderr, "已跳过数据源 %s（%s）%s\n", skipped.Name, skipped.Reason, skipped.Error)
+	}
+	return nil
+}
+
+// selectDatasources 返回只包含指定数据源的配置副本，避免为一次搜索初始化无关的数据源。
+func selectDatasources(cfg *config.Config, names []string) *config.Config {
+	if len(names) == 0 {
+		return cfg
+	}
+	wanted := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		wanted[name] = struct{}{}
+	}
+
+	selected := *cfg
+	selected.Datasources = nil
+	for _, item := range cfg.Datasources {
+		if _, ok := wanted[item.Name]; ok {
+			selected.Datasources = append(selected.Datasources, item)
+		}
+	}
+	return &selected
+}
+
+func splitNames(raw string) []string {
+	var names []string
+	for _, part := range strings.Split(raw, ",") {
+		if name := strings.TrimSpace(part); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
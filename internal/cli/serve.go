@@ -0,0 +1,102 @@
+// path: internal/cli/serve.go
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ebookdatabase/config"
+	"ebookdatabase/internal/api"
+	"ebookdatabase/internal/infra"
+	"ebookdatabase/logger"
+)
+
+const (
+	defaultHealthProbeInterval = time.Minute
+)
+
+func runServe(env *environment, args []string) error {
+	fs := newFlagSet(env, "serve", "[--config 路径] [--listen 地址]")
+	configPath := fs.String("config", DefaultConfigPath, "设置文件路径")
+	listenAddr := fs.String("listen", DefaultListenAddr, "HTTP 监听地址")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	logOptions, err := logger.OptionsFromConfig(cfg.Logging)
+	if err != nil {
+		return fmt.Errorf("解析日志配置失败: %w", err)
+	}
+	if _, err := logger.InitLogger(logOptions); err != nil {
+		return fmt.Errorf("初始化日志失败: %w", err)
+	}
+
+	mgr := infra.NewDBManager()
+	if err := mgr.InitFromConfig(cfg); err != nil {
+		slog.Error("初始化数据源失败", slog.String("error", err.Error()))
+	}
+	mgr.StartHealthProbes(defaultHealthProbeInterval)
+	defer func() {
+		if err := mgr.Close(); err != nil {
+			slog.Error("关闭数据源失败", slog.String("error", err.Error()))
+		}
+	}()
+
+	server, err := api.NewServer(cfg, mgr, *configPath, *listenAddr)
+	if err != nil {
+		return fmt.Errorf("创建 HTTP 服务失败: %w", err)
+	}
+	if err := server.StartConfigWatcher(config.DefaultWatchInterval); err != nil {
+		slog.Error("启动配置热加载失败", slog.String("error", err.Error()))
+	}
+	defer server.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Run()
+	}()
+	slog.Info("HTTP 服务启动", slog.String("listen", *listenAddr), slog.String("config", *configPath))
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	// 恢复默认信号处理，排空期间再次收到信号时立即退出。
+	stop()
+
+	timeout := cfg.HTTP.ShutdownTimeout()
+	slog.Info("收到退出信号，等待进行中的请求完成", slog.Duration("timeout", timeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("排空超时，已强制关闭剩余连接", slog.String("error", err.Error()))
+	}
+	if err := <-serveErr; err != nil {
+		return err
+	}
+	slog.Info("HTTP 服务已关闭")
+	return nil
+}
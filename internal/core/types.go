@@ -32,3 +32,39 @@ type Datasource interface {
 	GetBookFile(bookID string) (string, error)
 	GetBookCover(bookID string) (string, error)
 }
+
+// HealthChecker 由支持健康探测的数据源实现，用于在不执行完整搜索的情况下确认数据源可用。
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+}
+
+// BookFetcher 由支持按 ID 读取书籍的数据源实现，用于把书架条目还原为完整的书籍信息。
+// 返回结果不保证顺序，不存在的 ID 直接忽略。
+type BookFetcher interface {
+	GetBooks(ctx context.Context, ids []string) ([]CanonicalBook, error)
+}
+
+// DatasourceInfo 是检查数据源时得到的结构与规模信息。
+type DatasourceInfo struct {
+	Type      string `json:"type"`
+	Path      string `json:"path"`
+	Schema    string `json:"schema"`
+	BookCount int64  `json:"bookCount"`
+	FTSTable  string `json:"ftsTable,omitempty"`
+	FTSReady  bool   `json:"ftsReady"`
+}
+
+// Inspector 由支持离线检查的数据源实现，Inspect 需自行以只读方式打开数据库，不依赖 Init。
+type Inspector interface {
+	Inspect(ctx context.Context) (DatasourceInfo, error)
+}
+
+// Reindexer 由支持离线重建全文索引的数据源实现，Reindex 需自行以可写方式打开数据库，不依赖 Init。
+type Reindexer interface {
+	Reindex(ctx context.Context) error
+}
+
+// IntegrityChecker 由支持完整性检查的数据源实现，返回 SQLite integrity_check 报告的问题，为空表示通过。
+type IntegrityChecker interface {
+	CheckIntegrity(ctx context.Context) ([]string, error)
+}
@@ -0,0 +1,143 @@
+// path: internal/infra/breaker.go
+package infra
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerThreshold = 3
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+const (
+	breakerStateClosed   = "closed"
+	breakerStateOpen     = "open"
+	breakerStateHalfOpen = "half_open"
+)
+
+// circuitBreaker 记录单个数据源的连续失败次数，达到阈值后在冷却期内拒绝请求。
+// 冷却期结束后仅放行一次试探请求，成功则恢复，失败则重新进入冷却。
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	failures    int
+	openUntil   time.Time
+	trialActive bool
+	lastError   string
+	lastChecked time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration, now func() time.Time) *circuitBreaker {
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       now,
+	}
+}
+
+// Allow 判断当前是否允许向数据源发起请求。
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.now().Before(b.openUntil) {
+		return false
+	}
+	if b.trialActive {
+		return false
+	}
+	b.trialActive = true
+	return true
+}
+
+// Success 记录一次成功调用并关闭熔断。
+func (b *circuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.trialActive = false
+	b.lastError = ""
+	b.lastChecked = b.now()
+}
+
+// Failure 记录一次失败调用，连续失败达到阈值时打开熔断。
+func (b *circuitBreaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trialActive = false
+	b.lastChecked = b.now()
+	if err != nil {
+		b.lastError = err.Error()
+	}
+	if b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
+
+// Release 结束一次没有结论的调用（如调用方已取消），不计入失败，只让出半开状态下的试探名额。
+func (b *circuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trialActive = false
+}
+
+// setClock 替换熔断器使用的时钟。
+func (b *circuitBreaker) setClock(now func() time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.now = now
+}
+
+func (b *circuitBreaker) snapshot(name string) HealthStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := breakerStateClosed
+	if b.failures >= b.threshold {
+		if b.now().Before(b.openUntil) {
+			state = breakerStateOpen
+		} else {
+			state = breakerStateHalfOpen
+		}
+	}
+
+	status := HealthStatus{
+		Name:                name,
+		Healthy:             b.failures == 0,
+		State:               state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+	if !b.lastChecked.IsZero() {
+		checked := b.lastChecked
+		status.LastCheckedAt = &checked
+	}
+	if state == breakerStateOpen {
+		until := b.openUntil
+		status.OpenUntil = &until
+	}
+	return status
+}
@@ -2,60 +2,78 @@
 package infra
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"ebookdatabase/config"
 	"ebookdatabase/internal/adapters"
 	"ebookdatabase/internal/core"
 )
 
+// ErrReadOnlySource 表示数据源配置为只读，拒绝重建索引等需要写入数据库文件的维护操作。
+var ErrReadOnlySource = errors.New("数据源为只读")
+
 // DBManager 负责管理系统中注册的数据源实例。
 type DBManager struct {
-	mu      sync.RWMutex
-	sources map[string]core.Datasource
+	mu       sync.RWMutex
+	sources  map[string]core.Datasource
+	configs  map[string]config.DatasourceConfig
+	breakers map[string]*circuitBreaker
+	now      func() time.Time
+
+	// generations 记录每个数据源实例的代次，实例被替换或索引被重建时递增，
+	// 供搜索缓存区分新旧数据；lastGeneration 为全局单调计数，删除后重新添加也不会复用旧值。
+	generations    map[string]uint64
+	lastGeneration uint64
+
+	stopProbes chan struct{}
+	probesDone chan struct{}
 }
 
 // NewDBManager 创建一个新的 DBManager 实例。
 func NewDBManager() *DBManager {
 	return &DBManager{
-		sources: make(map[string]core.Datasource),
+		sources:  make(map[string]core.Datasource),
+		configs:  make(map[string]config.DatasourceConfig),
+		breakers: make(map[string]*circuitBreaker),
+		now:      time.Now,
+
+		generations: make(map[string]uint64),
 	}
 }
 
-// InitFromConfig 根据配置初始化所有数据源。
+// InitFromConfig 根据配置初始化所有启用的数据源，enabled 为 false 的数据源会被跳过。
 func (m *DBManager) InitFromConfig(cfg *config.Config) error {
 	if cfg == nil {
 		return fmt.Errorf("配置不能为空")
 	}
 
 	newSources := make(map[string]core.Datasource)
+	newConfigs := make(map[string]config.DatasourceConfig)
 	var errs []error
 
 	for _, item := range cfg.Datasources {
-		adapter, err := m.createAdapter(item)
-		if err != nil {
-			errs = append(errs, err)
+		if !item.IsEnabled() {
 			continue
 		}
-		if err := adapter.Init(); err != nil {
-			if closer, ok := adapter.(interface{ Close() error }); ok {
-				_ = closer.Close()
-			}
-			errs = append(errs, fmt.Errorf("数据源 %s 初始化失败: %w", item.Name, err))
+		adapter, err := m.openSource(item)
+		if err != nil {
+			errs = append(errs, err)
 			continue
 		}
 		newSources[item.Name] = adapter
+		newConfigs[item.Name] = item
 	}
 
 	if len(errs) > 0 {
 		for _, src := range newSources {
-			if closer, ok := src.(interface{ Close() error }); ok {
-				_ = closer.Close()
-			}
+			_ = closeSource(src)
 		}
 		return errors.Join(errs...)
 	}
@@ -64,13 +82,271 @@ func (m *DBManager) InitFromConfig(cfg *config.Config) error {
 	defer m.mu.Unlock()
 
 	for name, src := range m.sources {
-		if closer, ok := src.(interface{ Close() error }); ok {
-			_ = closer.Close()
-		}
+		_ = closeSource(src)
 		delete(m.sources, name)
 	}
 
 	m.sources = newSources
+	m.configs = newConfigs
+	m.breakers = make(map[string]*circuitBreaker, len(newSources))
+	m.generations = make(map[string]uint64, len(newSources))
+	for name := range newSources {
+		m.breakers[name] = newCircuitBreaker(defaultBreakerThreshold, defaultBreakerCooldown, m.now)
+		m.bumpGeneration(name)
+	}
+	return nil
+}
+
+// ApplyDatasources 将已注册的数据源调整为 items 描述的集合：未变化的数据源保持原实例，
+// 新增或配置变化的数据源先全部初始化，任一失败则关闭已初始化的实例并返回错误，
+// 运行中的数据源不受影响；全部成功后才一次性替换并关闭旧实例。仅 access 变化的数据源只更新配置。
+func (m *DBManager) ApplyDatasources(items []config.DatasourceConfig) error {
+	desired := make(map[string]config.DatasourceConfig, len(items))
+	for _, item := range items {
+		if item.IsEnabled() {
+			desired[item.Name] = item
+		}
+	}
+
+	m.mu.RLock()
+	pending := make(map[string]config.DatasourceConfig)
+	aclOnly := make(map[string]config.DatasourceConfig)
+	for name, item := range desired {
+		current, ok := m.configs[name]
+		switch {
+		case !ok || !sameSourceSettings(current, item):
+			pending[name] = item
+		case !reflect.DeepEqual(current.Access, item.Access):
+			aclOnly[name] = item
+		}
+	}
+	var removed []string
+	for name := range m.sources {
+		if _, ok := desired[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	m.mu.RUnlock()
+
+	opened := make(map[string]core.Datasource, len(pending))
+	var errs []error
+	for name, item := range pending {
+		adapter, err := m.openSource(item)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		opened[name] = adapter
+	}
+	if len(errs) > 0 {
+		for _, src := range opened {
+			_ = closeSource(src)
+		}
+		return errors.Join(errs...)
+	}
+
+	m.mu.Lock()
+	var retired []core.Datasource
+	for name, item := range aclOnly {
+		if _, ok := m.sources[name]; ok {
+			m.configs[name] = item
+		}
+	}
+	for name, adapter := range opened {
+		if old, ok := m.sources[name]; ok {
+			retired = append(retired, old)
+		}
+		m.register(pending[name], adapter)
+	}
+	for _, name := range removed {
+		if old, ok := m.sources[name]; ok {
+			retired = append(retired, old)
+		}
+		delete(m.sources, name)
+		delete(m.configs, name)
+		delete(m.breakers, name)
+		delete(m.generations, name)
+	}
+	m.mu.Unlock()
+
+	for _, src := range retired {
+		if err := closeSource(src); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("关闭旧数据源失败: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// sameSourceSettings 比较除访问控制外的配置，仅 access 变化时无需重新打开数据源。
+func sameSourceSettings(a, b config.DatasourceConfig) bool {
+	a.Access, b.Access = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// AddSource 初始化并注册单个数据源，不影响其他已注册的数据源。未启用的数据源不会被注册。
+func (m *DBManager) AddSource(cfg config.DatasourceConfig) error {
+	if _, exists := m.GetDatasource(cfg.Name); exists {
+		return fmt.Errorf("数据源 %s 已存在", cfg.Name)
+	}
+	if !cfg.IsEnabled() {
+		return nil
+	}
+
+	adapter, err := m.openSource(cfg)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.sources[cfg.Name]; exists {
+		_ = closeSource(adapter)
+		return fmt.Errorf("数据源 %s 已存在", cfg.Name)
+	}
+	m.register(cfg, adapter)
+	return nil
+}
+
+// ReplaceSource 使用新配置重建单个数据源。新实例初始化成功后才会替换并关闭旧实例，
+// 初始化失败时旧实例保持可用。配置为未启用时等同于 RemoveSource。
+func (m *DBManager) ReplaceSource(cfg config.DatasourceConfig) error {
+	if !cfg.IsEnabled() {
+		if _, exists := m.GetDatasource(cfg.Name); !exists {
+			return nil
+		}
+		return m.RemoveSource(cfg.Name)
+	}
+
+	adapter, err := m.openSource(cfg)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	old := m.sources[cfg.Name]
+	m.register(cfg, adapter)
+	m.mu.Unlock()
+
+	if old != nil {
+		if err := closeSource(old); err != nil {
+			return fmt.Errorf("关闭旧数据源 %s 失败: %w", cfg.Name, err)
+		}
+	}
+	return nil
+}
+
+// RemoveSource 注销并关闭单个数据源。
+func (m *DBManager) RemoveSource(name string) error {
+	m.mu.Lock()
+	src, ok := m.sources[name]
+	delete(m.sources, name)
+	delete(m.configs, name)
+	delete(m.breakers, name)
+	delete(m.generations, name)
+	m.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("数据源 %s 不存在", name)
+	}
+	if err := closeSource(src); err != nil {
+		return fmt.Errorf("关闭数据源 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+// InspectSource 按配置选择适配器并以只读方式检查数据库，不会注册数据源或修改数据库文件。
+func (m *DBManager) InspectSource(ctx context.Context, cfg config.DatasourceConfig) (core.DatasourceInfo, error) {
+	adapter, err := m.createAdapter(cfg)
+	if err != nil {
+		return core.DatasourceInfo{}, err
+	}
+	inspector, ok := adapter.(core.Inspector)
+	if !ok {
+		return core.DatasourceInfo{}, fmt.Errorf("数据源类型 %s 不支持检查", cfg.Type)
+	}
+	return inspector.Inspect(ctx)
+}
+
+// ReindexSource 按给定配置离线重建数据源的全文索引，不影响已注册的实例。readOnly 的数据源返回 ErrReadOnlySource。
+func (m *DBManager) ReindexSource(ctx context.Context, cfg config.DatasourceConfig) error {
+	if cfg.ReadOnly {
+		return fmt.Errorf("%w，不支持重建索引: %s", ErrReadOnlySource, cfg.Name)
+	}
+	adapter, err := m.createAdapter(cfg)
+	if err != nil {
+		return err
+	}
+	reindexer, ok := adapter.(core.Reindexer)
+	if !ok {
+		return fmt.Errorf("数据源类型 %s 不支持重建索引", cfg.Type)
+	}
+	if err := reindexer.Reindex(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sources[cfg.Name]; ok {
+		m.bumpGeneration(cfg.Name)
+	}
+	return nil
+}
+
+// CheckSourceIntegrity 按给定配置对数据源执行完整性检查，返回发现的问题。
+func (m *DBManager) CheckSourceIntegrity(ctx context.Context, cfg config.DatasourceConfig) ([]string, error) {
+	adapter, err := m.createAdapter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	checker, ok := adapter.(core.IntegrityChecker)
+	if !ok {
+		return nil, fmt.Errorf("数据源类型 %s 不支持完整性检查", cfg.Type)
+	}
+	return checker.CheckIntegrity(ctx)
+}
+
+// register 在持有写锁时登记数据源实例、配置与熔断器。
+func (m *DBManager) register(cfg config.DatasourceConfig, src core.Datasource) {
+	m.sources[cfg.Name] = src
+	m.configs[cfg.Name] = cfg
+	m.breakers[cfg.Name] = newCircuitBreaker(defaultBreakerThreshold, defaultBreakerCooldown, m.now)
+	m.bumpGeneration(cfg.Name)
+}
+
+// bumpGeneration 为数据源分配新的代次，调用方需持有写锁。
+func (m *DBManager) bumpGeneration(name string) {
+	m.lastGeneration++
+	m.generations[name] = m.lastGeneration
+}
+
+// Generation 返回数据源当前实例的代次，未注册时返回 0。
+// 同名数据源被替换、重新添加或重建索引后代次都会变化。
+func (m *DBManager) Generation(name string) uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.generations[name]
+}
+
+func (m *DBManager) openSource(cfg config.DatasourceConfig) (core.Datasource, error) {
+	adapter, err := m.createAdapter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := adapter.Init(); err != nil {
+		_ = closeSource(adapter)
+		return nil, fmt.Errorf("数据源 %s 初始化失败: %w", cfg.Name, err)
+	}
+	return adapter, nil
+}
+
+func closeSource(src core.Datasource) error {
+	if closer, ok := src.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
 	return nil
 }
 
@@ -94,7 +370,16 @@ func (m *DBManager) GetDatasource(name string) (core.Datasource, bool) {
 	return src, ok
 }
 
-// ListSources 返回当前已注册的数据源名称列表。
+// DatasourceConfig 返回指定数据源注册时使用的配置。
+func (m *DBManager) DatasourceConfig(name string) (config.DatasourceConfig, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cfg, ok := m.configs[name]
+	return cfg, ok
+}
+
+// ListSources 返回当前已注册的数据源名称列表，按 priority 降序排列，优先级相同时按名称排序。
 func (m *DBManager) ListSources() []string {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -103,7 +388,13 @@ func (m *DBManager) ListSources() []string {
 	for name := range m.sources {
 		names = append(names, name)
 	}
-	sort.Strings(names)
+	sort.Slice(names, func(i, j int) bool {
+		left, right := m.configs[names[i]].Priority, m.configs[names[j]].Priority
+		if left != right {
+			return left > right
+		}
+		return names[i] < names[j]
+	})
 	return names
 }
 
@@ -114,17 +405,20 @@ func (m *DBManager) GetDBList() []string {
 
 // Close 关闭所有已注册的数据源。
 func (m *DBManager) Close() error {
+	m.stopHealthProbes()
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	var errs []error
 	for name, src := range m.sources {
-		if closer, ok := src.(interface{ Close() error }); ok {
-			if err := closer.Close(); err != nil {
-				errs = append(errs, fmt.Errorf("关闭数据源 %s 失败: %w", name, err))
-			}
+		if err := closeSource(src); err != nil {
+			errs = append(errs, fmt.Errorf("关闭数据源 %s 失败: %w", name, err))
 		}
 		delete(m.sources, name)
+		delete(m.configs, name)
+		delete(m.breakers, name)
+		delete(m.generations, name)
 	}
 
 	if len(errs) > 0 {
@@ -2,14 +2,19 @@
 package infra
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	_ "modernc.org/sqlite"
 
 	"ebookdatabase/config"
+	"ebookdatabase/internal/infra/sqlitecfg"
+	"ebookdatabase/search"
 )
 
 func TestInitFromConfigRegistersSources(t *testing.T) {
@@ -128,3 +133,238 @@ dxid TEXT
 		t.Fatalf("failed to create legacy books table: %v", err)
 	}
 }
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	current := time.Unix(1_700_000_000, 0)
+	breaker := newCircuitBreaker(2, time.Minute, func() time.Time { return current })
+
+	breaker.Failure(errors.New("boom"))
+	if !breaker.Allow() {
+		t.Fatalf("expected breaker to allow requests below threshold")
+	}
+	breaker.Failure(errors.New("boom"))
+	if breaker.Allow() {
+		t.Fatalf("expected breaker to reject requests once open")
+	}
+	if status := breaker.snapshot("legacy"); status.State != breakerStateOpen || status.LastError != "boom" {
+		t.Fatalf("unexpected open snapshot: %+v", status)
+	}
+
+	current = current.Add(2 * time.Minute)
+	if !breaker.Allow() {
+		t.Fatalf("expected breaker to allow a trial request after cooldown")
+	}
+	if breaker.Allow() {
+		t.Fatalf("expected breaker to allow only one trial request")
+	}
+
+	breaker.Success()
+	if !breaker.Allow() {
+		t.Fatalf("expected breaker to close after successful trial")
+	}
+	if status := breaker.snapshot("legacy"); !status.Healthy || status.State != breakerStateClosed {
+		t.Fatalf("unexpected closed snapshot: %+v", status)
+	}
+}
+
+func TestCircuitBreakerReleaseFreesTrialWithoutFailure(t *testing.T) {
+	current := time.Unix(1_700_000_000, 0)
+	breaker := newCircuitBreaker(1, time.Minute, func() time.Time { return current })
+
+	breaker.Failure(errors.New("boom"))
+	current = current.Add(2 * time.Minute)
+	if !breaker.Allow() {
+		t.Fatalf("expected breaker to allow a trial request after cooldown")
+	}
+	breaker.Release()
+	if status := breaker.snapshot("legacy"); status.State != breakerStateHalfOpen || status.ConsecutiveFailures != 1 {
+		t.Fatalf("expected released trial to leave the breaker half-open, got %+v", status)
+	}
+	if !breaker.Allow() {
+		t.Fatalf("expected a new trial request after release")
+	}
+}
+
+func TestProbeHealthReportsBrokenSource(t *testing.T) {
+	dir := t.TempDir()
+	legacyPath := filepath.Join(dir, "legacy.db")
+	createMinimalLegacyDB(t, legacyPath)
+
+	manager := NewDBManager()
+	t.Cleanup(func() {
+		_ = manager.Close()
+	})
+	if err := manager.InitFromConfig(&config.Config{
+		Datasources: []config.DatasourceConfig{{Name: "legacy", Type: "legacy_db", Path: legacyPath}},
+	}); err != nil {
+		t.Fatalf("InitFromConfig returned error: %v", err)
+	}
+
+	statuses := manager.ProbeHealth(context.Background())
+	if len(statuses) != 1 || !statuses[0].Healthy {
+		t.Fatalf("expected healthy source, got %+v", statuses)
+	}
+
+	db, err := sql.Open("sqlite", legacyPath)
+	if err != nil {
+		t.Fatalf("failed to open legacy db: %v", err)
+	}
+	if _, err := db.Exec("DROP TABLE books"); err != nil {
+		t.Fatalf("failed to drop books table: %v", err)
+	}
+	_ = db.Close()
+
+	statuses = manager.ProbeHealth(context.Background())
+	if len(statuses) != 1 || statuses[0].Healthy || statuses[0].ConsecutiveFailures != 1 {
+		t.Fatalf("expected unhealthy source after probe, got %+v", statuses)
+	}
+}
+
+func TestInitFromConfigHonoursEnabledAndPriority(t *testing.T) {
+	dir := t.TempDir()
+	paths := make(map[string]string)
+	for _, name := range []string{"alpha", "beta", "gamma"} {
+		paths[name] = filepath.Join(dir, name+".db")
+		createMinimalLegacyDB(t, paths[name])
+	}
+	disabled := false
+
+	manager := NewDBManager()
+	t.Cleanup(func() {
+		_ = manager.Close()
+	})
+	if err := manager.InitFromConfig(&config.Config{
+		Datasources: []config.DatasourceConfig{
+			{Name: "alpha", Type: "legacy_db", Path: paths["alpha"]},
+			{Name: "beta", Type: "legacy_db", Path: paths["beta"], Priority: 10},
+			{Name: "gamma", Type: "legacy_db", Path: paths["gamma"], Enabled: &disabled},
+		},
+	}); err != nil {
+		t.Fatalf("InitFromConfig returned error: %v", err)
+	}
+
+	names := manager.ListSources()
+	if len(names) != 2 || names[0] != "beta" || names[1] != "alpha" {
+		t.Fatalf("expected [beta alpha], got %v", names)
+	}
+	if cfg, ok := manager.DatasourceConfig("beta"); !ok || cfg.Priority != 10 {
+		t.Fatalf("expected beta config to be stored, got %+v", cfg)
+	}
+}
+
+func TestReadOnlyLegacySourceFallsBackWithoutFTS(t *testing.T) {
+	dir := t.TempDir()
+	legacyPath := filepath.Join(dir, "legacy.db")
+	createMinimalLegacyDB(t, legacyPath)
+
+	manager := NewDBManager()
+	t.Cleanup(func() {
+		_ = manager.Close()
+	})
+	if err := manager.InitFromConfig(&config.Config{
+		Datasources: []config.DatasourceConfig{{Name: "legacy", Type: "legacy_db", Path: legacyPath, ReadOnly: true}},
+	}); err != nil {
+		t.Fatalf("InitFromConfig returned error: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", legacyPath)
+	if err != nil {
+		t.Fatalf("failed to open legacy db: %v", err)
+	}
+	defer db.Close()
+	exists, err := sqlitecfg.TableExists(db, "books_fts")
+	if err != nil {
+		t.Fatalf("TableExists returned error: %v", err)
+	}
+	if exists {
+		t.Fatalf("expected read-only source not to create books_fts")
+	}
+
+	src, _ := manager.GetDatasource("legacy")
+	fuzzy := true
+	if _, _, err := src.Search(context.Background(), &search.QueryParams{
+		Fields:   []string{"title"},
+		Queries:  []string{"Go"},
+		Fuzzies:  []*bool{&fuzzy},
+		Page:     1,
+		PageSize: 5,
+	}); err != nil {
+		t.Fatalf("expected LIKE fallback search to succeed, got %v", err)
+	}
+
+	dsConfig, _ := manager.DatasourceConfig("legacy")
+	if err := manager.ReindexSource(context.Background(), dsConfig); !errors.Is(err, ErrReadOnlySource) {
+		t.Fatalf("expected reindex of read-only source to be rejected, got %v", err)
+	}
+	if exists, _ := sqlitecfg.TableExists(db, "books_fts"); exists {
+		t.Fatalf("expected rejected reindex not to create books_fts")
+	}
+}
+
+func TestReplaceSourceKeepsPreviousInstanceOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	legacyPath := filepath.Join(dir, "legacy.db")
+	createMinimalLegacyDB(t, legacyPath)
+
+	manager := NewDBManager()
+	t.Cleanup(func() {
+		_ = manager.Close()
+	})
+	if err := manager.AddSource(config.DatasourceConfig{Name: "legacy", Type: "legacy_db", Path: legacyPath}); err != nil {
+		t.Fatalf("AddSource returned error: %v", err)
+	}
+	before, _ := manager.GetDatasource("legacy")
+
+	err := manager.ReplaceSource(config.DatasourceConfig{Name: "legacy", Type: "legacy_db", Path: filepath.Join(dir, "missing", "x.db")})
+	if err == nil {
+		t.Fatalf("expected ReplaceSource to fail for invalid path")
+	}
+	after, ok := manager.GetDatasource("legacy")
+	if !ok || after != before {
+		t.Fatalf("expected previous instance to remain registered")
+	}
+
+	if err := manager.RemoveSource("legacy"); err != nil {
+		t.Fatalf("RemoveSource returned error: %v", err)
+	}
+	if len(manager.ListSources()) != 0 {
+		t.Fatalf("expected no sources after RemoveSource, got %v", manager.ListSources())
+	}
+}
+
+func TestGenerationChangesWhenSourceIsReplaced(t *testing.T) {
+	dir := t.TempDir()
+	legacyPath := filepath.Join(dir, "legacy.db")
+	createMinimalLegacyDB(t, legacyPath)
+	item := config.DatasourceConfig{Name: "legacy", Type: "legacy_db", Path: legacyPath}
+
+	manager := NewDBManager()
+	t.Cleanup(func() {
+		_ = manager.Close()
+	})
+	if got := manager.Generation("legacy"); got != 0 {
+		t.Fatalf("expected generation 0 for unregistered source, got %d", got)
+	}
+	if err := manager.AddSource(item); err != nil {
+		t.Fatalf("AddSource returned error: %v", err)
+	}
+	first := manager.Generation("legacy")
+
+	if err := manager.ReplaceSource(item); err != nil {
+		t.Fatalf("ReplaceSource returned error: %v", err)
+	}
+	second := manager.Generation("legacy")
+	if second == first {
+		t.Fatalf("expected ReplaceSource to change generation")
+	}
+
+	if err := manager.RemoveSource("legacy"); err != nil {
+		t.Fatalf("RemoveSource returned error: %v", err)
+	}
+	if err := manager.AddSource(item); err != nil {
+		t.Fatalf("AddSource returned error: %v", err)
+	}
+	if third := manager.Generation("legacy"); third == first || third == second {
+		t.Fatalf("expected re-added source to get a new generation, got %d", third)
+	}
+}
@@ -0,0 +1,163 @@
+// path: internal/infra/health.go
+package infra
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ebookdatabase/internal/core"
+)
+
+const defaultProbeTimeout = 5 * time.Second
+
+// HealthStatus 描述单个数据源最近一次调用或探测后的健康与熔断状态。
+type HealthStatus struct {
+	Name                string     `json:"name"`
+	Healthy             bool       `json:"healthy"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastError           string     `json:"lastError,omitempty"`
+	LastCheckedAt       *time.Time `json:"lastCheckedAt,omitempty"`
+	OpenUntil           *time.Time `json:"openUntil,omitempty"`
+}
+
+// Allow 判断指定数据源的熔断器是否允许本次请求通过。
+func (m *DBManager) Allow(name string) bool {
+	breaker := m.breakerFor(name)
+	if breaker == nil {
+		return true
+	}
+	return breaker.Allow()
+}
+
+// ReportSuccess 记录数据源的一次成功调用。
+func (m *DBManager) ReportSuccess(name string) {
+	if breaker := m.breakerFor(name); breaker != nil {
+		breaker.Success()
+	}
+}
+
+// ReportFailure 记录数据源的一次失败调用，连续失败会触发熔断。
+func (m *DBManager) ReportFailure(name string, err error) {
+	if breaker := m.breakerFor(name); breaker != nil {
+		breaker.Failure(err)
+	}
+}
+
+// ReportCancelled 记录一次因调用方取消而未完成的调用：不计入失败，但释放半开状态下占用的试探名额，
+// 否则熔断器会一直等待这次试探的结果而拒绝后续请求。
+func (m *DBManager) ReportCancelled(name string) {
+	if breaker := m.breakerFor(name); breaker != nil {
+		breaker.Release()
+	}
+}
+
+// SetClock 替换熔断器计算冷却期使用的时钟，已注册与之后注册的数据源均生效，供测试模拟时间流逝。
+func (m *DBManager) SetClock(now func() time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now = now
+	for _, breaker := range m.breakers {
+		breaker.setClock(now)
+	}
+}
+
+// HealthStatuses 返回所有数据源最近一次记录的健康状态，不会主动发起探测。
+func (m *DBManager) HealthStatuses() []HealthStatus {
+	names := m.ListSources()
+	statuses := make([]HealthStatus, 0, len(names))
+	for _, name := range names {
+		breaker := m.breakerFor(name)
+		if breaker == nil {
+			continue
+		}
+		statuses = append(statuses, breaker.snapshot(name))
+	}
+	return statuses
+}
+
+// ProbeHealth 对所有实现了 core.HealthChecker 的数据源执行一次探测并更新熔断状态。
+func (m *DBManager) ProbeHealth(ctx context.Context) []HealthStatus {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, name := range m.ListSources() {
+		src, ok := m.GetDatasource(name)
+		if !ok {
+			continue
+		}
+		checker, ok := src.(core.HealthChecker)
+		if !ok {
+			continue
+		}
+		probeCtx, cancel := context.WithTimeout(ctx, defaultProbeTimeout)
+		err := checker.Ping(probeCtx)
+		cancel()
+		if err != nil {
+			slog.Warn("数据源健康探测失败",
+				slog.String("datasource", name),
+				slog.String("error", err.Error()),
+			)
+			m.ReportFailure(name, fmt.Errorf("健康探测失败: %w", err))
+			continue
+		}
+		m.ReportSuccess(name)
+	}
+	return m.HealthStatuses()
+}
+
+// StartHealthProbes 以固定间隔在后台探测数据源健康状态，Close 时自动停止。
+func (m *DBManager) StartHealthProbes(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	if m.stopProbes != nil {
+		m.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	m.stopProbes = stop
+	m.probesDone = done
+	m.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				m.ProbeHealth(context.Background())
+			}
+		}
+	}()
+}
+
+func (m *DBManager) stopHealthProbes() {
+	m.mu.Lock()
+	stop := m.stopProbes
+	done := m.probesDone
+	m.stopProbes = nil
+	m.probesDone = nil
+	m.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (m *DBManager) breakerFor(name string) *circuitBreaker {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.breakers[name]
+}
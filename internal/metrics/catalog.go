@@ -0,0 +1,36 @@
+// path: internal/metrics/catalog.go
+package metrics
+
+// Default 是进程级的指标注册表，/metrics 接口导出其中的全部指标。
+var Default = NewRegistry()
+
+// 以下为系统中使用的全部指标，集中声明以便查阅名称与标签。
+var (
+	// DatasourceQueryDuration 记录每个数据源单次搜索的耗时（含失败的查询）。
+	DatasourceQueryDuration = Default.NewHistogramVec("ebookdb_datasource_query_duration_seconds",
+		"单个数据源搜索耗时（秒）", DefaultBuckets, "datasource")
+	// DatasourceQueryErrors 按原因统计数据源被跳过或查询失败的次数，原因与 skippedSources 中的 reason 一致。
+	DatasourceQueryErrors = Default.NewCounterVec("ebookdb_datasource_query_errors_total",
+		"数据源搜索失败或被跳过的次数", "datasource", "reason")
+	// DatasourceResults 累计各数据源返回的结果条数。
+	DatasourceResults = Default.NewCounterVec("ebookdb_datasource_results_total",
+		"数据源搜索返回的结果条数", "datasource")
+
+	// FilesServed 统计下载与封面请求成功返回的文件数，kind 为 download 或 cover。
+	FilesServed = Default.NewCounterVec("ebookdb_files_served_total",
+		"成功返回的下载与封面文件数", "kind", "datasource")
+	// FileBytes 统计下载与封面请求写出的字节数。
+	FileBytes = Default.NewCounterVec("ebookdb_file_bytes_total",
+		"下载与封面请求写出的字节数", "kind", "datasource")
+
+	// FTSRebuildDuration 记录全文索引重建耗时。
+	FTSRebuildDuration = Default.NewHistogramVec("ebookdb_fts_rebuild_duration_seconds",
+		"全文索引重建耗时（秒）", []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300, 600}, "datasource")
+
+	// HTTPRequests 按路由模板、方法与状态码统计 HTTP 请求数。
+	HTTPRequests = Default.NewCounterVec("ebookdb_http_requests_total",
+		"HTTP 请求数", "method", "route", "status")
+	// HTTPRequestDuration 按路由模板与方法记录 HTTP 请求耗时。
+	HTTPRequestDuration = Default.NewHistogramVec("ebookdb_http_request_duration_seconds",
+		"HTTP 请求耗时（秒）", DefaultBuckets, "method", "route")
+)
@@ -0,0 +1,314 @@
+// path: internal/metrics/registry.go
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType 是 Prometheus 文本格式的 Content-Type。
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets 是延迟类直方图的默认分桶（秒）。
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector 是注册表中的一个指标族，按 Prometheus 文本格式输出自身。
+type collector interface {
+	metricName() string
+	write(w *bufio.Writer)
+}
+
+// Registry 保存所有指标并以 Prometheus 文本格式导出，指标按名称排序输出。
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]collector
+}
+
+// NewRegistry 创建空的指标注册表。
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.collectors[c.metricName()]; exists {
+		panic(fmt.Sprintf("指标 %s 重复注册", c.metricName()))
+	}
+	r.collectors[c.metricName()] = c
+}
+
+// NewCounterVec 注册带标签的计数器。
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{family: newFamily(name, help, labels)}
+	r.register(c)
+	return c
+}
+
+// NewHistogramVec 注册带标签的直方图，buckets 需按升序排列。
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{family: newFamily(name, help, labels), buckets: append([]float64(nil), buckets...)}
+	r.register(h)
+	return h
+}
+
+// SetGaugeFunc 注册或替换一个在导出时求值的仪表盘指标，重复调用以最后一次为准。
+func (r *Registry) SetGaugeFunc(name, help string, fn func() float64) {
+	r.setFunc(&funcMetric{name: name, help: help, kind: "gauge", fn: fn})
+}
+
+// SetCounterFunc 注册或替换一个在导出时求值的计数器指标，用于暴露其他组件自行维护的累计值。
+func (r *Registry) SetCounterFunc(name, help string, fn func() float64) {
+	r.setFunc(&funcMetric{name: name, help: help, kind: "counter", fn: fn})
+}
+
+func (r *Registry) setFunc(m *funcMetric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors[m.name] = m
+}
+
+// WriteText 以 Prometheus 文本格式输出全部指标。
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	collectors := make([]collector, 0, len(names))
+	for _, name := range names {
+		collectors = append(collectors, r.collectors[name])
+	}
+	r.mu.RUnlock()
+
+	buf := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(buf)
+	}
+	return buf.Flush()
+}
+
+// Handler 返回导出指标的 HTTP 处理器。
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.WriteText(w)
+	})
+}
+
+// family 保存一个指标族的元数据与按标签值区分的序列。
+type family struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string][]string
+}
+
+func newFamily(name, help string, labels []string) family {
+	return family{name: name, help: help, labels: labels, series: make(map[string][]string)}
+}
+
+func (f *family) metricName() string { return f.name }
+
+// keyLocked 校验标签值数量并返回序列键，调用方需持有 mu。
+func (f *family) keyLocked(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("指标 %s 需要 %d 个标签值，实际为 %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	if _, ok := f.series[key]; !ok {
+		f.series[key] = append([]string(nil), values...)
+	}
+	return key
+}
+
+// sortedKeysLocked 返回按键排序的序列，保证输出稳定。调用方需持有 mu。
+func (f *family) sortedKeysLocked() []string {
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *family) writeHeader(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, kind)
+}
+
+// labelString 把标签名与值拼成 {a="x",b="y"}，extra 追加在末尾（如直方图的 le）。
+func (f *family) labelString(values []string, extra ...string) string {
+	if len(f.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, label := range f.labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(label)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(values[i]))
+		b.WriteByte('"')
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		if len(f.labels) > 0 || i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(extra[i])
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(extra[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// CounterVec 是只增不减的带标签计数器。
+type CounterVec struct {
+	family
+	values map[string]float64
+}
+
+// Add 为指定标签值的序列增加 v，v 必须为非负数。
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.values == nil {
+		c.values = make(map[string]float64)
+	}
+	c.values[c.keyLocked(labelValues)] += v
+}
+
+// Inc 为指定标签值的序列加一。
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Value 返回指定标签值的当前计数，主要用于测试。
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[strings.Join(labelValues, "\xff")]
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(w, "counter")
+	for _, key := range c.sortedKeysLocked() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelString(c.series[key]), formatFloat(c.values[key]))
+	}
+}
+
+// HistogramVec 是带标签的累积分桶直方图。
+type HistogramVec struct {
+	family
+	buckets []float64
+	data    map[string]*histogramData
+}
+
+type histogramData struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe 记录一次观测值。
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.data == nil {
+		h.data = make(map[string]*histogramData)
+	}
+	key := h.keyLocked(labelValues)
+	data, ok := h.data[key]
+	if !ok {
+		data = &histogramData{counts: make([]uint64, len(h.buckets))}
+		h.data[key] = data
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			data.counts[i]++
+		}
+	}
+	data.count++
+	data.sum += v
+}
+
+// Count 返回指定标签值的观测次数，主要用于测试。
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if data, ok := h.data[strings.Join(labelValues, "\xff")]; ok {
+		return data.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w, "histogram")
+	for _, key := range h.sortedKeysLocked() {
+		values := h.series[key]
+		data := h.data[key]
+		for i, upper := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(values, "le", formatFloat(upper)), data.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(values, "le", "+Inf"), data.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelString(values), formatFloat(data.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelString(values), data.count)
+	}
+}
+
+// funcMetric 是导出时通过回调求值的无标签指标。
+type funcMetric struct {
+	name string
+	help string
+	kind string
+	fn   func() float64
+}
+
+func (m *funcMetric) metricName() string { return m.name }
+
+func (m *funcMetric) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", m.name, escapeHelp(m.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.kind)
+	fmt.Fprintf(w, "%s %s\n", m.name, formatFloat(m.fn()))
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryWritesPrometheusText(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.NewCounterVec("test_requests_total", "请求数", "route", "status")
+	latency := registry.NewHistogramVec("test_latency_seconds", "耗时", []float64{0.1, 1}, "route")
+	registry.SetGaugeFunc("test_ratio", "比例", func() float64 { return 0.5 })
+
+	requests.Inc("/a", "200")
+	requests.Add(2, `/b"x`, "500")
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.5, "/a")
+
+	var out strings.Builder
+	if err := registry.WriteText(&out); err != nil {
+		t.Fatalf("WriteText returned error: %v", err)
+	}
+	text := out.String()
+
+	for _, want := range []string{
+		"# TYPE test_requests_total counter",
+		`test_requests_total{route="/a",status="200"} 1`,
+		`test_requests_total{route="/b\"x",status="500"} 2`,
+		"# TYPE test_latency_seconds histogram",
+		`test_latency_seconds_bucket{route="/a",le="0.1"} 1`,
+		`test_latency_seconds_bucket{route="/a",le="1"} 2`,
+		`test_latency_seconds_bucket{route="/a",le="+Inf"} 2`,
+		`test_latency_seconds_sum{route="/a"} 0.55`,
+		`test_latency_seconds_count{route="/a"} 2`,
+		"# TYPE test_ratio gauge",
+		"test_ratio 0.5",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected output to contain %q, got:\n%s", want, text)
+		}
+	}
+	if strings.Index(text, "test_latency_seconds") > strings.Index(text, "test_ratio") {
+		t.Fatalf("expected metric families to be sorted by name")
+	}
+}
+
+func TestRegistryRejectsDuplicateAndWrongLabelCount(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounterVec("dup_total", "重复", "a")
+
+	assertPanics(t, func() { registry.NewCounterVec("dup_total", "重复") })
+	assertPanics(t, func() { counter.Inc("x", "y") })
+}
+
+func assertPanics(t *testing.T, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic")
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,516 @@
+// path: internal/shelves/store.go
+package shelves
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	// MaxShelvesPerOwner 与 MaxEntriesPerShelf 限制单个用户的书架规模，避免书架库无限增长。
+	MaxShelvesPerOwner = 100
+	MaxEntriesPerShelf = 1000
+
+	maxNameRunes        = 64
+	maxDescriptionRunes = 500
+	maxNoteRunes        = 1000
+)
+
+var (
+	// ErrShelfNotFound 表示书架不存在或不属于当前用户。
+	ErrShelfNotFound = errors.New("书架不存在")
+	// ErrShelfExists 表示同一用户已有同名书架。
+	ErrShelfExists = errors.New("书架名称已存在")
+	// ErrEntryNotFound 表示书架中没有该书。
+	ErrEntryNotFound = errors.New("书架中没有这本书")
+	// ErrEntryExists 表示该书已在书架中。
+	ErrEntryExists = errors.New("这本书已在书架中")
+	// ErrLimitExceeded 表示书架数量或书架中的条目数已达上限。
+	ErrLimitExceeded = errors.New("已达到数量上限")
+	// ErrInvalid 表示书架或条目的字段不符合要求。
+	ErrInvalid = errors.New("书架信息不合法")
+)
+
+const schemaSQL = `
+CREATE TABLE IF NOT EXISTS shelves (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	owner       TEXT    NOT NULL COLLATE NOCASE,
+	name        TEXT    NOT NULL COLLATE NOCASE,
+	description TEXT    NOT NULL DEFAULT '',
+	created_at  INTEGER NOT NULL,
+	updated_at  INTEGER NOT NULL,
+	UNIQUE (owner, name)
+);
+CREATE TABLE IF NOT EXISTS shelf_entries (
+	shelf_id INTEGER NOT NULL REFERENCES shelves(id) ON DELETE CASCADE,
+	source   TEXT    NOT NULL,
+	book_id  TEXT    NOT NULL,
+	note     TEXT    NOT NULL DEFAULT '',
+	position INTEGER NOT NULL,
+	added_at INTEGER NOT NULL,
+	PRIMARY KEY (shelf_id, source, book_id)
+);
+CREATE INDEX IF NOT EXISTS idx_shelf_entries_position ON shelf_entries(shelf_id, position);
+`
+
+// Shelf 是用户的一个命名书架。
+type Shelf struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	EntryCount  int       `json:"entryCount"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Entry 是书架中的一本书，以数据源名称与书籍 ID 标识，Position 从 0 开始连续编号。
+type Entry struct {
+	Source   string    `json:"source"`
+	BookID   string    `json:"id"`
+	Note     string    `json:"note"`
+	Position int       `json:"position"`
+	AddedAt  time.Time `json:"addedAt"`
+}
+
+// ShelfUpdate 描述对书架的部分修改，为 nil 的字段保持不变。
+type ShelfUpdate struct {
+	Name        *string
+	Description *string
+}
+
+// EntryUpdate 描述对书架条目的部分修改，Position 超出范围时移动到最前或最后。
+type EntryUpdate struct {
+	Note     *string
+	Position *int
+}
+
+// Store 保存所有用户的书架，数据库位于实例目录下的 shelves.db。owner 由调用方决定且不区分大小写，
+// 所有读写都限定在 owner 自己的书架内，访问他人的书架与书架不存在同样返回 ErrShelfNotFound。
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// Open 打开（必要时创建）书架数据库。
+func Open(path string) (*Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("未指定书架数据库路径")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("创建书架数据库目录失败: %w", err)
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)", filepath.ToSlash(path))
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开书架数据库失败: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schemaSQL); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化书架数据库失败: %w", err)
+	}
+	return &Store{db: db, now: time.Now}, nil
+}
+
+// Close 关闭书架数据库。
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// ListShelves 按创建顺序返回 owner 的全部书架。
+func (s *Store) ListShelves(ctx context.Context, owner string) ([]Shelf, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT s.id, s.name, s.description, s.created_at, s.updated_at,
+		(SELECT COUNT(*) FROM shelf_entries e WHERE e.shelf_id = s.id)
+		FROM shelves s WHERE s.owner = ? ORDER BY s.id`, owner)
+	if err != nil {
+		return nil, fmt.Errorf("读取书架列表失败: %w", err)
+	}
+	defer rows.Close()
+
+	shelves := make([]Shelf, 0)
+	for rows.Next() {
+		shelf, err := scanShelf(rows)
+		if err != nil {
+			return nil, fmt.Errorf("读取书架列表失败: %w", err)
+		}
+		shelves = append(shelves, shelf)
+	}
+	return shelves, rows.Err()
+}
+
+// GetShelf 返回 owner 的指定书架。
+func (s *Store) GetShelf(ctx context.Context, owner string, id int64) (Shelf, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT s.id, s.name, s.description, s.created_at, s.updated_at,
+		(SELECT COUNT(*) FROM shelf_entries e WHERE e.shelf_id = s.id)
+		FROM shelves s WHERE s.id = ? AND s.owner = ?`, id, owner)
+	shelf, err := scanShelf(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Shelf{}, ErrShelfNotFound
+	}
+	if err != nil {
+		return Shelf{}, fmt.Errorf("读取书架失败: %w", err)
+	}
+	return shelf, nil
+}
+
+// CreateShelf 为 owner 新建书架，名称在同一用户内不区分大小写地唯一。
+func (s *Store) CreateShelf(ctx context.Context, owner, name, description string) (Shelf, error) {
+	name, description = strings.TrimSpace(name), strings.TrimSpace(description)
+	if err := validateShelf(name, description); err != nil {
+		return Shelf{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Shelf{}, fmt.Errorf("新建书架失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM shelves WHERE owner = ?", owner).Scan(&count); err != nil {
+		return Shelf{}, fmt.Errorf("新建书架失败: %w", err)
+	}
+	if count >= MaxShelvesPerOwner {
+		return Shelf{}, fmt.Errorf("%w: 每个用户最多 %d 个书架", ErrLimitExceeded, MaxShelvesPerOwner)
+	}
+	if err := ensureNameFree(ctx, tx, owner, name, 0); err != nil {
+		return Shelf{}, err
+	}
+
+	now := s.now().Unix()
+	result, err := tx.ExecContext(ctx, `INSERT INTO shelves (owner, name, description, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)`, owner, name, description, now, now)
+	if err != nil {
+		return Shelf{}, fmt.Errorf("新建书架失败: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return Shelf{}, fmt.Errorf("新建书架失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Shelf{}, fmt.Errorf("新建书架失败: %w", err)
+	}
+	return s.GetShelf(ctx, owner, id)
+}
+
+// UpdateShelf 修改书架名称或描述。
+func (s *Store) UpdateShelf(ctx context.Context, owner string, id int64, update ShelfUpdate) (Shelf, error) {
+	current, err := s.GetShelf(ctx, owner, id)
+	if err != nil {
+		return Shelf{}, err
+	}
+	name, description := current.Name, current.Description
+	if update.Name != nil {
+		name = strings.TrimSpace(*update.Name)
+	}
+	if update.Description != nil {
+		description = strings.TrimSpace(*update.Description)
+	}
+	if err := validateShelf(name, description); err != nil {
+		return Shelf{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Shelf{}, fmt.Errorf("修改书架失败: %w", err)
+	}
+	defer tx.Rollback()
+	if err := ensureNameFree(ctx, tx, owner, name, id); err != nil {
+		return Shelf{}, err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE shelves SET name = ?, description = ?, updated_at = ? WHERE id = ? AND owner = ?",
+		name, description, s.now().Unix(), id, owner); err != nil {
+		return Shelf{}, fmt.Errorf("修改书架失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Shelf{}, fmt.Errorf("修改书架失败: %w", err)
+	}
+	return s.GetShelf(ctx, owner, id)
+}
+
+// DeleteShelf 删除书架及其中的全部条目。
+func (s *Store) DeleteShelf(ctx context.Context, owner string, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM shelves WHERE id = ? AND owner = ?", id, owner)
+	if err != nil {
+		return fmt.Errorf("删除书架失败: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrShelfNotFound
+	}
+	return nil
+}
+
+// DeleteOwner 删除 owner 的全部书架，用于删除用户或吊销 API Key 之后清理数据。
+func (s *Store) DeleteOwner(ctx context.Context, owner string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM shelves WHERE owner = ?", owner); err != nil {
+		return fmt.Errorf("删除书架失败: %w", err)
+	}
+	return nil
+}
+
+// Entries 按位置返回书架中的全部条目。
+func (s *Store) Entries(ctx context.Context, owner string, id int64) ([]Entry, error) {
+	if _, err := s.GetShelf(ctx, owner, id); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT source, book_id, note, position, added_at FROM shelf_entries
+		WHERE shelf_id = ? ORDER BY position`, id)
+	if err != nil {
+		return nil, fmt.Errorf("读取书架条目失败: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]Entry, 0)
+	for rows.Next() {
+		var (
+			entry   Entry
+			addedAt int64
+		)
+		if err := rows.Scan(&entry.Source, &entry.BookID, &entry.Note, &entry.Position, &addedAt); err != nil {
+			return nil, fmt.Errorf("读取书架条目失败: %w", err)
+		}
+		entry.AddedAt = time.Unix(addedAt, 0).UTC()
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// AddEntry 把一本书加入书架。position 为 nil 时追加到末尾，否则插入到该位置，其后的条目依次后移。
+func (s *Store) AddEntry(ctx context.Context, owner string, id int64, source, bookID, note string, position *int) (Entry, error) {
+	source, bookID, note = strings.TrimSpace(source), strings.TrimSpace(bookID), strings.TrimSpace(note)
+	if source == "" || bookID == "" {
+		return Entry{}, fmt.Errorf("%w: 缺少数据源或书籍 ID", ErrInvalid)
+	}
+	if err := validateNote(note); err != nil {
+		return Entry{}, err
+	}
+
+	tx, err := s.beginOwned(ctx, owner, id)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := entryPosition(ctx, tx, id, source, bookID); err == nil {
+		return Entry{}, ErrEntryExists
+	} else if !errors.Is(err, ErrEntryNotFound) {
+		return Entry{}, err
+	}
+	count, err := entryCount(ctx, tx, id)
+	if err != nil {
+		return Entry{}, err
+	}
+	if count >= MaxEntriesPerShelf {
+		return Entry{}, fmt.Errorf("%w: 每个书架最多 %d 本书", ErrLimitExceeded, MaxEntriesPerShelf)
+	}
+
+	target := count
+	if position != nil {
+		target = min(max(*position, 0), count)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE shelf_entries SET position = position + 1 WHERE shelf_id = ? AND position >= ?", id, target); err != nil {
+		return Entry{}, fmt.Errorf("调整书架顺序失败: %w", err)
+	}
+	now := s.now()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO shelf_entries (shelf_id, source, book_id, note, position, added_at)
+		VALUES (?, ?, ?, ?, ?, ?)`, id, source, bookID, note, target, now.Unix()); err != nil {
+		return Entry{}, fmt.Errorf("加入书架失败: %w", err)
+	}
+	if err := s.touch(ctx, tx, id); err != nil {
+		return Entry{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Entry{}, fmt.Errorf("加入书架失败: %w", err)
+	}
+	return Entry{Source: source, BookID: bookID, Note: note, Position: target, AddedAt: time.Unix(now.Unix(), 0).UTC()}, nil
+}
+
+// UpdateEntry 修改条目的备注，或把条目移动到新位置，两者之间的条目依次前移或后移。
+func (s *Store) UpdateEntry(ctx context.Context, owner string, id int64, source, bookID string, update EntryUpdate) error {
+	if update.Note != nil {
+		note := strings.TrimSpace(*update.Note)
+		if err := validateNote(note); err != nil {
+			return err
+		}
+		update.Note = &note
+	}
+
+	tx, err := s.beginOwned(ctx, owner, id)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := entryPosition(ctx, tx, id, source, bookID)
+	if err != nil {
+		return err
+	}
+	if update.Note != nil {
+		if _, err := tx.ExecContext(ctx, "UPDATE shelf_entries SET note = ? WHERE shelf_id = ? AND source = ? AND book_id = ?",
+			*update.Note, id, source, bookID); err != nil {
+			return fmt.Errorf("修改书架条目失败: %w", err)
+		}
+	}
+	if update.Position != nil {
+		count, err := entryCount(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		target := min(max(*update.Position, 0), count-1)
+		var shift string
+		switch {
+		case target < current:
+			shift = "UPDATE shelf_entries SET position = position + 1 WHERE shelf_id = ? AND position >= ? AND position < ?"
+		case target > current:
+			shift = "UPDATE shelf_entries SET position = position - 1 WHERE shelf_id = ? AND position > ? AND position <= ?"
+		}
+		if shift != "" {
+			if _, err := tx.ExecContext(ctx, shift, id, min(target, current), max(target, current)); err != nil {
+				return fmt.Errorf("调整书架顺序失败: %w", err)
+			}
+			if _, err := tx.ExecContext(ctx, "UPDATE shelf_entries SET position = ? WHERE shelf_id = ? AND source = ? AND book_id = ?",
+				target, id, source, bookID); err != nil {
+				return fmt.Errorf("调整书架顺序失败: %w", err)
+			}
+		}
+	}
+	if err := s.touch(ctx, tx, id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("修改书架条目失败: %w", err)
+	}
+	return nil
+}
+
+// RemoveEntry 把一本书移出书架，其后的条目依次前移。
+func (s *Store) RemoveEntry(ctx context.Context, owner string, id int64, source, bookID string) error {
+	tx, err := s.beginOwned(ctx, owner, id)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	position, err := entryPosition(ctx, tx, id, source, bookID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM shelf_entries WHERE shelf_id = ? AND source = ? AND book_id = ?", id, source, bookID); err != nil {
+		return fmt.Errorf("移出书架失败: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE shelf_entries SET position = position - 1 WHERE shelf_id = ? AND position > ?", id, position); err != nil {
+		return fmt.Errorf("调整书架顺序失败: %w", err)
+	}
+	if err := s.touch(ctx, tx, id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("移出书架失败: %w", err)
+	}
+	return nil
+}
+
+// beginOwned 开启事务并确认书架属于 owner。
+func (s *Store) beginOwned(ctx context.Context, owner string, id int64) (*sql.Tx, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启书架事务失败: %w", err)
+	}
+	var found int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM shelves WHERE id = ? AND owner = ?", id, owner).Scan(&found)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrShelfNotFound
+		}
+		return nil, fmt.Errorf("读取书架失败: %w", err)
+	}
+	return tx, nil
+}
+
+func (s *Store) touch(ctx context.Context, tx *sql.Tx, id int64) error {
+	if _, err := tx.ExecContext(ctx, "UPDATE shelves SET updated_at = ? WHERE id = ?", s.now().Unix(), id); err != nil {
+		return fmt.Errorf("更新书架时间失败: %w", err)
+	}
+	return nil
+}
+
+func entryPosition(ctx context.Context, tx *sql.Tx, id int64, source, bookID string) (int, error) {
+	var position int
+	err := tx.QueryRowContext(ctx, "SELECT position FROM shelf_entries WHERE shelf_id = ? AND source = ? AND book_id = ?",
+		id, source, bookID).Scan(&position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrEntryNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("读取书架条目失败: %w", err)
+	}
+	return position, nil
+}
+
+func entryCount(ctx context.Context, tx *sql.Tx, id int64) (int, error) {
+	var count int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM shelf_entries WHERE shelf_id = ?", id).Scan(&count); err != nil {
+		return 0, fmt.Errorf("读取书架条目失败: %w", err)
+	}
+	return count, nil
+}
+
+func ensureNameFree(ctx context.Context, tx *sql.Tx, owner, name string, except int64) error {
+	var found int
+	err := tx.QueryRowContext(ctx, "SELECT 1 FROM shelves WHERE owner = ? AND name = ? AND id <> ?", owner, name, except).Scan(&found)
+	if err == nil {
+		return fmt.Errorf("%w: %s", ErrShelfExists, name)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("读取书架失败: %w", err)
+	}
+	return nil
+}
+
+func validateShelf(name, description string) error {
+	if name == "" || utf8.RuneCountInString(name) > maxNameRunes {
+		return fmt.Errorf("%w: 名称不能为空且不超过 %d 个字符", ErrInvalid, maxNameRunes)
+	}
+	if utf8.RuneCountInString(description) > maxDescriptionRunes {
+		return fmt.Errorf("%w: 描述不超过 %d 个字符", ErrInvalid, maxDescriptionRunes)
+	}
+	return nil
+}
+
+func validateNote(note string) error {
+	if utf8.RuneCountInString(note) > maxNoteRunes {
+		return fmt.Errorf("%w: 备注不超过 %d 个字符", ErrInvalid, maxNoteRunes)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanShelf(row rowScanner) (Shelf, error) {
+	var (
+		shelf            Shelf
+		created, updated int64
+	)
+	if err := row.Scan(&shelf.ID, &shelf.Name, &shelf.Description, &created, &updated, &shelf.EntryCount); err != nil {
+		return Shelf{}, err
+	}
+	shelf.CreatedAt = time.Unix(created, 0).UTC()
+	shelf.UpdatedAt = time.Unix(updated, 0).UTC()
+	return shelf, nil
+}
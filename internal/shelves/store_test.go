@@ -0,0 +1,143 @@
+package shelves
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := Open(filepath.Join(t.TempDir(), "nested", "shelves.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func entryOrder(t *testing.T, store *Store, owner string, id int64) string {
+	t.Helper()
+	entries, err := store.Entries(context.Background(), owner, id)
+	if err != nil {
+		t.Fatalf("Entries returned error: %v", err)
+	}
+	ids := make([]string, 0, len(entries))
+	for i, entry := range entries {
+		if entry.Position != i {
+			t.Fatalf("expected contiguous positions, got %+v", entries)
+		}
+		ids = append(ids, entry.BookID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestShelvesAreScopedToOwner(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+
+	shelf, err := store.CreateShelf(ctx, "user:alice", " 待读 ", "周末读完")
+	if err != nil {
+		t.Fatalf("CreateShelf returned error: %v", err)
+	}
+	if shelf.Name != "待读" || shelf.EntryCount != 0 {
+		t.Fatalf("unexpected shelf: %+v", shelf)
+	}
+	if _, err := store.CreateShelf(ctx, "user:alice", "待读", ""); !errors.Is(err, ErrShelfExists) {
+		t.Fatalf("expected duplicate name to be rejected, got %v", err)
+	}
+	if _, err := store.CreateShelf(ctx, "user:bob", "待读", ""); err != nil {
+		t.Fatalf("expected other owners to reuse the name, got %v", err)
+	}
+	if _, err := store.CreateShelf(ctx, "user:alice", "  ", ""); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected empty name to be rejected, got %v", err)
+	}
+
+	if _, err := store.GetShelf(ctx, "user:bob", shelf.ID); !errors.Is(err, ErrShelfNotFound) {
+		t.Fatalf("expected other owners not to see the shelf, got %v", err)
+	}
+	if _, err := store.AddEntry(ctx, "user:bob", shelf.ID, "lib", "1", "", nil); !errors.Is(err, ErrShelfNotFound) {
+		t.Fatalf("expected other owners not to modify the shelf, got %v", err)
+	}
+
+	renamed := "已读"
+	updated, err := store.UpdateShelf(ctx, "user:alice", shelf.ID, ShelfUpdate{Name: &renamed})
+	if err != nil || updated.Name != "已读" || updated.Description != "周末读完" {
+		t.Fatalf("unexpected update result: %+v, %v", updated, err)
+	}
+
+	if err := store.DeleteOwner(ctx, "user:alice"); err != nil {
+		t.Fatalf("DeleteOwner returned error: %v", err)
+	}
+	if list, err := store.ListShelves(ctx, "user:alice"); err != nil || len(list) != 0 {
+		t.Fatalf("expected owner shelves to be deleted, got %+v, %v", list, err)
+	}
+	if list, _ := store.ListShelves(ctx, "user:bob"); len(list) != 1 {
+		t.Fatalf("expected other owners to keep their shelves, got %+v", list)
+	}
+}
+
+func TestShelfEntriesKeepOrder(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+	owner := "admin"
+
+	shelf, err := store.CreateShelf(ctx, owner, "收藏", "")
+	if err != nil {
+		t.Fatalf("CreateShelf returned error: %v", err)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if _, err := store.AddEntry(ctx, owner, shelf.ID, "lib", id, "", nil); err != nil {
+			t.Fatalf("AddEntry returned error: %v", err)
+		}
+	}
+	first := 0
+	if entry, err := store.AddEntry(ctx, owner, shelf.ID, "other", "d", " 好书 ", &first); err != nil || entry.Position != 0 || entry.Note != "好书" {
+		t.Fatalf("unexpected inserted entry: %+v, %v", entry, err)
+	}
+	if _, err := store.AddEntry(ctx, owner, shelf.ID, "lib", "a", "", nil); !errors.Is(err, ErrEntryExists) {
+		t.Fatalf("expected duplicate entry to be rejected, got %v", err)
+	}
+	if got := entryOrder(t, store, owner, shelf.ID); got != "d,a,b,c" {
+		t.Fatalf("unexpected order after insert: %s", got)
+	}
+
+	last := 99
+	if err := store.UpdateEntry(ctx, owner, shelf.ID, "other", "d", EntryUpdate{Position: &last}); err != nil {
+		t.Fatalf("UpdateEntry returned error: %v", err)
+	}
+	if got := entryOrder(t, store, owner, shelf.ID); got != "a,b,c,d" {
+		t.Fatalf("unexpected order after moving down: %s", got)
+	}
+	second := 1
+	note := "重读"
+	if err := store.UpdateEntry(ctx, owner, shelf.ID, "lib", "c", EntryUpdate{Position: &second, Note: &note}); err != nil {
+		t.Fatalf("UpdateEntry returned error: %v", err)
+	}
+	if got := entryOrder(t, store, owner, shelf.ID); got != "a,c,b,d" {
+		t.Fatalf("unexpected order after moving up: %s", got)
+	}
+
+	if err := store.RemoveEntry(ctx, owner, shelf.ID, "lib", "c"); err != nil {
+		t.Fatalf("RemoveEntry returned error: %v", err)
+	}
+	if got := entryOrder(t, store, owner, shelf.ID); got != "a,b,d" {
+		t.Fatalf("unexpected order after removal: %s", got)
+	}
+	if err := store.RemoveEntry(ctx, owner, shelf.ID, "lib", "c"); !errors.Is(err, ErrEntryNotFound) {
+		t.Fatalf("expected missing entry error, got %v", err)
+	}
+	if current, _ := store.GetShelf(ctx, owner, shelf.ID); current.EntryCount != 3 {
+		t.Fatalf("expected entry count 3, got %+v", current)
+	}
+
+	if err := store.DeleteShelf(ctx, owner, shelf.ID); err != nil {
+		t.Fatalf("DeleteShelf returned error: %v", err)
+	}
+	var remaining int
+	if err := store.db.QueryRow("SELECT COUNT(*) FROM shelf_entries").Scan(&remaining); err != nil || remaining != 0 {
+		t.Fatalf("expected entries to be deleted with the shelf, got %d, %v", remaining, err)
+	}
+}
@@ -0,0 +1,51 @@
+// Path: logger/context.go
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// RequestIDKey 是日志中请求 ID 字段的名称。
+const RequestIDKey = "request_id"
+
+type requestIDContextKey struct{}
+
+// WithRequestID 返回携带请求 ID 的 context，经由 slog 的 *Context 方法记录的日志会自动附带该 ID。
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext 返回 context 中的请求 ID，不存在时返回空字符串。
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// contextHandler 在记录日志时从 context 中取出请求 ID 并作为 request_id 字段追加。
+type contextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler 包装 handler，使其自动记录 context 中的请求 ID。
+func NewContextHandler(handler slog.Handler) slog.Handler {
+	return contextHandler{Handler: handler}
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		record.AddAttrs(slog.String(RequestIDKey, id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -7,8 +7,12 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/lumberjack/lumberjack"
+
+	"ebookdatabase/config"
 )
 
 // Options 定义 InitLogger 的自定义配置。
@@ -20,7 +24,11 @@ type Options struct {
 	Compress   bool
 	Level      slog.Level
 	AddSource  bool
-	Writers    []io.Writer
+	// Format 为 json 或 text，留空时为 json。
+	Format string
+	// Stdout 为 true 时同时输出到标准输出；指定 Writers 时改为输出到 Writers。
+	Stdout  bool
+	Writers []io.Writer
 }
 
 // DefaultOptions 返回一份可安全修改的默认日志配置。
@@ -33,10 +41,39 @@ func DefaultOptions() *Options {
 		Compress:   true,
 		Level:      slog.LevelInfo,
 		AddSource:  true,
+		Format:     "json",
+		Stdout:     true,
 	}
 }
 
+// OptionsFromConfig 把设置文件中的 logging 配置转换为 Options，cfg 需已经过 config.NormalizeLogging。
+func OptionsFromConfig(cfg config.LoggingConfig) (*Options, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("无法解析日志级别 %s: %w", cfg.Level, err)
+	}
+	return &Options{
+		Filename:   cfg.File,
+		MaxSizeMB:  cfg.MaxSizeMB,
+		MaxBackups: cfg.MaxBackups,
+		MaxAgeDays: cfg.MaxAgeDays,
+		Compress:   cfg.CompressEnabled(),
+		Level:      level,
+		AddSource:  cfg.AddSource,
+		Format:     cfg.Format,
+		Stdout:     cfg.StdoutEnabled(),
+	}, nil
+}
+
+// state 记录 InitLogger 创建的日志文件，重新配置时复用或关闭它。
+var state struct {
+	mu     sync.Mutex
+	writer *lumberjack.Logger
+}
+
 // InitLogger 使用 lumberjack 和 slog 初始化结构化日志记录。返回创建的 slog.Logger，并将其设置为全局默认 logger。
+// 通过 slog 的 *Context 方法记录的日志会自动附带 context 中的请求 ID。
+// 重复调用会按新配置替换全局 logger，日志文件与轮转参数不变时继续使用原文件。
 func InitLogger(opts *Options) (*slog.Logger, error) {
 	if opts == nil {
 		opts = DefaultOptions()
@@ -46,33 +83,83 @@ func InitLogger(opts *Options) (*slog.Logger, error) {
 		return nil, fmt.Errorf("日志文件路径不能为空")
 	}
 
-	if err := os.MkdirAll(filepath.Dir(opts.Filename), 0o755); err != nil {
-		return nil, fmt.Errorf("创建日志目录失败: %w", err)
+	var handlerFactory func(io.Writer, *slog.HandlerOptions) slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", "json":
+		handlerFactory = func(w io.Writer, o *slog.HandlerOptions) slog.Handler { return slog.NewJSONHandler(w, o) }
+	case "text":
+		handlerFactory = func(w io.Writer, o *slog.HandlerOptions) slog.Handler { return slog.NewTextHandler(w, o) }
+	default:
+		return nil, fmt.Errorf("不支持的日志格式: %s", opts.Format)
 	}
 
-	writer := &lumberjack.Logger{
-		Filename:   opts.Filename,
-		MaxSize:    opts.MaxSizeMB,
-		MaxBackups: opts.MaxBackups,
-		MaxAge:     opts.MaxAgeDays,
-		Compress:   opts.Compress,
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	writer := state.writer
+	if writer == nil || !sameFile(writer, opts) {
+		if err := os.MkdirAll(filepath.Dir(opts.Filename), 0o755); err != nil {
+			return nil, fmt.Errorf("创建日志目录失败: %w", err)
+		}
+		writer = &lumberjack.Logger{
+			Filename:   opts.Filename,
+			MaxSize:    opts.MaxSizeMB,
+			MaxBackups: opts.MaxBackups,
+			MaxAge:     opts.MaxAgeDays,
+			Compress:   opts.Compress,
+		}
 	}
 
 	var outputs []io.Writer
 	outputs = append(outputs, writer)
 	if len(opts.Writers) > 0 {
 		outputs = append(outputs, opts.Writers...)
-	} else {
+	} else if opts.Stdout {
 		outputs = append(outputs, os.Stdout)
 	}
 
-	handler := slog.NewJSONHandler(io.MultiWriter(outputs...), &slog.HandlerOptions{
+	handler := handlerFactory(io.MultiWriter(outputs...), &slog.HandlerOptions{
 		Level:     opts.Level,
 		AddSource: opts.AddSource,
 	})
 
-	logger := slog.New(handler)
+	logger := slog.New(NewContextHandler(handler))
 	slog.SetDefault(logger)
 
+	if previous := state.writer; previous != nil && previous != writer {
+		_ = previous.Close()
+	}
+	state.writer = writer
+
 	return logger, nil
 }
+
+// Reconfigure 在已通过 InitLogger 初始化日志后按新配置重建全局 logger，无需重启进程。
+// 尚未初始化（例如命令行子命令只输出到终端）时不做任何修改并返回 false。
+func Reconfigure(opts *Options) (bool, error) {
+	state.mu.Lock()
+	initialized := state.writer != nil
+	state.mu.Unlock()
+	if !initialized {
+		return false, nil
+	}
+	if _, err := InitLogger(opts); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Initialized 返回是否已通过 InitLogger 初始化文件日志。
+func Initialized() bool {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.writer != nil
+}
+
+func sameFile(writer *lumberjack.Logger, opts *Options) bool {
+	return writer.Filename == opts.Filename &&
+		writer.MaxSize == opts.MaxSizeMB &&
+		writer.MaxBackups == opts.MaxBackups &&
+		writer.MaxAge == opts.MaxAgeDays &&
+		writer.Compress == opts.Compress
+}